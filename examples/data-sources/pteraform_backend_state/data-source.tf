@@ -0,0 +1,12 @@
+data "pteraform_backend_state" "network" {
+  backend = "s3"
+  config = {
+    bucket = "example-state"
+    key    = "network/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
+
+output "vpc_id" {
+  value = jsondecode(data.pteraform_backend_state.network.outputs["vpc_id"])
+}
@@ -0,0 +1,10 @@
+provider "pteraform" {
+  plugin_cache_dir = "/var/cache/pteraform/plugins"
+  cache_max_size   = "5GiB"
+}
+
+data "pteraform_cache_usage" "this" {}
+
+output "plugin_cache_usage" {
+  value = "${data.pteraform_cache_usage.this.size} of ${data.pteraform_cache_usage.this.max_size} bytes"
+}
@@ -0,0 +1,7 @@
+data "pteraform_config_inspect" "second" {
+  working_dir = "${path.module}/second"
+}
+
+output "second_required_variables" {
+  value = data.pteraform_config_inspect.second.required_variables
+}
@@ -0,0 +1,15 @@
+resource "pteraform_apply" "network" {
+  working_dir = "${path.module}/network"
+}
+
+data "pteraform_console_eval" "subnets" {
+  working_dir = pteraform_apply.network.working_dir
+  expression  = "local.subnet_cidrs"
+  variables = {
+    region = "us-east-1"
+  }
+}
+
+output "subnet_cidrs" {
+  value = jsondecode(data.pteraform_console_eval.subnets.result)
+}
@@ -0,0 +1,14 @@
+data "pteraform_env_check" "this" {
+  working_dir       = "${path.module}/nested"
+  terraform_version = ">= 1.5"
+  required_env      = ["AWS_REGION"]
+  registry_host     = "registry.terraform.io"
+  min_free_disk     = "1GiB"
+
+  lifecycle {
+    postcondition {
+      condition     = self.passed
+      error_message = join("\n", [for c in self.checks : "${c.name}: ${c.detail}" if !c.passed])
+    }
+  }
+}
@@ -0,0 +1,11 @@
+resource "pteraform_apply" "network" {
+  working_dir = "${path.module}/network"
+}
+
+data "pteraform_provider_schemas" "network" {
+  working_dir = pteraform_apply.network.working_dir
+}
+
+locals {
+  aws_resource_types = keys(jsondecode(data.pteraform_provider_schemas.network.schemas).provider_schemas["registry.terraform.io/hashicorp/aws"].resource_schemas)
+}
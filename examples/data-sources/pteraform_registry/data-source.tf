@@ -0,0 +1,20 @@
+resource "pteraform_apply" "network" {
+  working_dir = "${path.module}/network"
+
+  publish_outputs = {
+    network_vpc_id = "vpc_id"
+  }
+}
+
+# Elsewhere in the outer configuration, without referring to
+# pteraform_apply.network.
+data "pteraform_registry" "vpc_id" {
+  name = "network_vpc_id"
+}
+
+resource "pteraform_apply" "app" {
+  working_dir = "${path.module}/app"
+  variables = {
+    vpc_id = jsondecode(data.pteraform_registry.vpc_id.value)
+  }
+}
@@ -0,0 +1,12 @@
+data "pteraform_revision" "desired" {
+  url = "https://deploy.example.com/stacks/network/version"
+}
+
+resource "pteraform_apply" "network" {
+  working_dir = "${path.module}/network"
+  args        = ["-var=module_version=${data.pteraform_revision.desired.value}"]
+
+  triggers = {
+    revision = data.pteraform_revision.desired.value
+  }
+}
@@ -0,0 +1,13 @@
+resource "pteraform_apply" "network" {
+  working_dir = "${path.module}/network"
+}
+
+# What the last apply changed.
+data "pteraform_state_diff" "last_apply" {
+  working_dir = pteraform_apply.network.working_dir
+  from_backup = 1
+}
+
+output "removed" {
+  value = data.pteraform_state_diff.last_apply.removed
+}
@@ -0,0 +1,7 @@
+data "pteraform_terraform_cli" "this" {
+  working_dir = path.module
+}
+
+output "terraform_version" {
+  value = "${data.pteraform_terraform_cli.this.version} at ${data.pteraform_terraform_cli.this.resolved_path}"
+}
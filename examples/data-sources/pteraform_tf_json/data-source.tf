@@ -0,0 +1,16 @@
+data "pteraform_tf_json" "defaults" {
+  value = jsonencode({
+    variable = {
+      greeting = { default = var.greeting }
+      zones    = { default = var.zones }
+    }
+  })
+}
+
+resource "pteraform_apply" "app" {
+  working_dir = "${path.module}/app"
+
+  override_files = {
+    "defaults_override.tf.json" = data.pteraform_tf_json.defaults.json
+  }
+}
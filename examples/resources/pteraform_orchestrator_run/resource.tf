@@ -0,0 +1,12 @@
+resource "pteraform_orchestrator_run" "network" {
+  platform = "spacelift"
+  endpoint = "https://example.app.spacelift.io"
+  stack    = "network"
+}
+
+resource "pteraform_apply" "app" {
+  working_dir = "${path.module}/app"
+  triggers = {
+    network_run = pteraform_orchestrator_run.network.id
+  }
+}
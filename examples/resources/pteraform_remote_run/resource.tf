@@ -0,0 +1,9 @@
+resource "pteraform_remote_run" "network" {
+  organization = "example-org"
+  workspace    = "network"
+  working_dir  = "${path.module}/network"
+}
+
+output "vpc_id" {
+  value = jsondecode(pteraform_remote_run.network.outputs["vpc_id"])
+}
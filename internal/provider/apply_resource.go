@@ -4,15 +4,23 @@
 package provider
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -20,101 +28,2014 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &ApplyResource{}
 var _ resource.ResourceWithImportState = &ApplyResource{}
+var _ resource.ResourceWithModifyPlan = &ApplyResource{}
+var _ resource.ResourceWithValidateConfig = &ApplyResource{}
 
 func NewApplyResource() resource.Resource {
 	return &ApplyResource{}
 }
 
 // ApplyResource defines the resource implementation.
-type ApplyResource struct{}
+type ApplyResource struct {
+	// newRunner, if set, returns the runner used to run terraform. It is
+	// overridden in tests.
+	newRunner func(resourceLimits) runner
+
+	provider *providerData
+}
+
+// runner returns the runner used to run terraform for m in the nested
+// operation runID, or an error if a nested run would exceed
+// max_nesting_depth.
+func (r *ApplyResource) runner(limits resourceLimits, m *ApplyResourceModel, runID string) (runner, error) {
+	env, err := r.env(runID)
+	if err != nil {
+		return nil, err
+	}
+	timeouts, err := m.PhaseTimeouts.timeouts()
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, passthroughVars(r.provider.inherit().filter(os.Environ()), m.PassthroughPrefix.ValueString())...)
+	env = append(env, m.rateLimitEnv()...)
+	env = append(env, m.environmentEnv()...)
+	offline := r.offline(m)
+	if offline {
+		// Don't check for a newer terraform either.
+		env = append(env, "CHECKPOINT_DISABLE=1")
+	}
+	t := terraformRunner{limits: limits, inherit: r.provider.inherit(), env: env, root: m.RootDir.ValueString(), fileMode: r.fileMode(), logDir: r.outputLogsDir(m), workspace: m.WorkspaceName.ValueString()}
+	if v := m.TerraformVersion.ValueString(); v != "" {
+		dir, err := r.provider.terraformVersionsDir()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to find the terraform versions directory, got error: %s", err)
+		}
+		t.install = &terraformInstall{version: v, dir: dir, offline: offline}
+	}
+	var tf runner = t
+	if r.newRunner != nil {
+		tf = r.newRunner(limits)
+	}
+	if m.commands != nil {
+		m.commands.env = r.childEnvironNames(env)
+		m.commands.workspace = m.WorkspaceName.ValueString()
+		tf = recordingRunner{runner: tf, log: m.commands}
+	}
+	if offline {
+		dirs, err := r.offlinePluginDirs()
+		if err != nil {
+			return nil, err
+		}
+		tf = offlineRunner{runner: tf, pluginDirs: dirs}
+	}
+	if len(timeouts) > 0 {
+		tf = timeoutRunner{runner: tf, timeouts: timeouts}
+	}
+	return tf, nil
+}
+
+// outputLogsDir returns where the full output of m's failed commands is
+// saved.
+func (r *ApplyResource) outputLogsDir(m *ApplyResourceModel) string {
+	return outputLogsDir(r.provider.tempDir(), m.WorkingDir.ValueString())
+}
+
+// env returns the environment variables set for nested runs in the nested
+// operation runID, in addition to the provider's own, or an error if a
+// nested run would exceed max_nesting_depth.
+func (r *ApplyResource) env(runID string) ([]string, error) {
+	var outer nesting
+	if r.provider != nil {
+		outer = r.provider.Nesting
+	}
+	nested, err := outer.next()
+	if err != nil {
+		return nil, err
+	}
+	env := outerMetadata(os.Getenv)
+	if r.provider != nil && r.provider.PluginCacheDir != "" {
+		env = append(env, "TF_PLUGIN_CACHE_DIR="+r.provider.PluginCacheDir)
+	}
+	env = append(env, r.provider.runEnv(runID)...)
+	return append(env, nested.env()...), nil
+}
 
 // ApplyResourceModel describes the resource data model.
 type ApplyResourceModel struct {
 	WorkingDir types.String `tfsdk:"working_dir"`
 	Args       types.List   `tfsdk:"args"`
 	Id         types.String `tfsdk:"id"`
+	IDStrategy types.String `tfsdk:"id_strategy"`
+	Triggers   types.Map    `tfsdk:"triggers"`
+	Variables  types.Map    `tfsdk:"variables"`
+	VarFiles   types.List   `tfsdk:"var_files"`
+
+	VariablesJSON  types.String `tfsdk:"variables_json"`
+	ProviderConfig types.String `tfsdk:"generated_provider_config"`
+	OverrideFiles  types.Map    `tfsdk:"override_files"`
+	Environment    types.Map    `tfsdk:"environment"`
+
+	PassthroughPrefix types.String `tfsdk:"passthrough_var_prefix"`
+	TerraformVersion  types.String `tfsdk:"terraform_version"`
+	RateLimits        types.Map    `tfsdk:"rate_limits"`
+	DesiredState      types.String `tfsdk:"desired_state"`
+	Suspended         types.Bool   `tfsdk:"suspended"`
+
+	AllowVersionChange types.Bool `tfsdk:"allow_version_change"`
+
+	RootDir      types.String `tfsdk:"root_dir"`
+	RelativePath types.String `tfsdk:"relative_path"`
+
+	Workspace     types.String `tfsdk:"workspace"`
+	WorkspaceName types.String `tfsdk:"workspace_name"`
+
+	AllowedProviders   types.List `tfsdk:"allowed_providers"`
+	DeniedProvisioners types.List `tfsdk:"denied_provisioners"`
+	AllowRecursion     types.Bool `tfsdk:"allow_recursion"`
+	Modules            types.List `tfsdk:"modules"`
+
+	MaxResources types.Int64 `tfsdk:"max_resources"`
+
+	PlanFile     types.String `tfsdk:"plan_file"`
+	PlanFileHash types.String `tfsdk:"plan_file_hash"`
+
+	ApprovalFile    types.String `tfsdk:"approval_file"`
+	ApprovalTimeout types.String `tfsdk:"approval_timeout"`
+
+	PlanArtifactURL types.String `tfsdk:"plan_artifact_url"`
+	ConsoleURL      types.String `tfsdk:"console_url"`
+	RunID           types.String `tfsdk:"run_id"`
+
+	CrashDestination  types.String `tfsdk:"crash_log_destination"`
+	EffectiveCommands types.List   `tfsdk:"effective_commands"`
+
+	WorkingDirAbs   types.String `tfsdk:"working_dir_abs"`
+	Host            types.String `tfsdk:"host"`
+	EnvironmentHash types.String `tfsdk:"environment_hash"`
+	InputManifest   types.String `tfsdk:"input_manifest"`
+
+	RepairLockfile  types.Bool `tfsdk:"repair_lockfile"`
+	CleanupOnDelete types.Bool `tfsdk:"cleanup_on_delete"`
+	PreviewDestroy  types.Bool `tfsdk:"preview_destroy"`
+
+	ModulesOnlyUpdate types.Bool `tfsdk:"modules_only_update"`
+	PrefetchProviders types.Bool `tfsdk:"prefetch_providers"`
+
+	BackendConfig      types.Map  `tfsdk:"backend_config"`
+	BackendConfigFiles types.List `tfsdk:"backend_config_files"`
+
+	SkipUnchanged types.Bool `tfsdk:"skip_unchanged"`
+	Skipped       types.Bool `tfsdk:"skipped"`
+
+	FullRefreshEvery types.String `tfsdk:"full_refresh_every"`
+	HostAffinity     types.Bool   `tfsdk:"host_affinity"`
+	Offline          types.Bool   `tfsdk:"offline"`
+
+	ComputePlanHash types.Bool   `tfsdk:"compute_plan_hash"`
+	PlanHash        types.String `tfsdk:"plan_hash"`
+
+	ReadRunsPlan   types.Bool  `tfsdk:"read_runs_plan"`
+	VerifyOutputs  types.List  `tfsdk:"verify_outputs"`
+	PublishOutputs types.Map   `tfsdk:"publish_outputs"`
+	OutputsChanged types.Map   `tfsdk:"outputs_changed"`
+	PendingAdd     types.Int64 `tfsdk:"pending_add"`
+	PendingChange  types.Int64 `tfsdk:"pending_change"`
+	PendingDestroy types.Int64 `tfsdk:"pending_destroy"`
+
+	SuppressWarnings types.List `tfsdk:"suppress_warnings"`
+
+	Capture         types.String `tfsdk:"capture"`
+	CompactWarnings types.Bool   `tfsdk:"compact_warnings"`
+	Concise         types.Bool   `tfsdk:"concise"`
+	Parallelism     types.Int64  `tfsdk:"parallelism"`
+	Output          types.String `tfsdk:"output"`
+	LastError       types.Object `tfsdk:"last_error"`
+
+	ChangeDiagram  types.Bool   `tfsdk:"change_diagram"`
+	DiagramMermaid types.String `tfsdk:"change_diagram_mermaid"`
+
+	Checks types.List `tfsdk:"checks"`
+
+	Stages       types.List `tfsdk:"stages"`
+	StageResults types.List `tfsdk:"stage_results"`
+
+	Attestation    *ApplyAttestationModel    `tfsdk:"attestation"`
+	PlanArtifact   *ApplyPlanArtifactModel   `tfsdk:"plan_artifact"`
+	Expected       *ApplyExpectedModel       `tfsdk:"expected_resources"`
+	WaitForHTTP    *ApplyWaitForHTTPModel    `tfsdk:"wait_for_http"`
+	EventSink      *ApplyEventSinkModel      `tfsdk:"event_sink"`
+	PhaseTimeouts  *ApplyPhaseTimeoutsModel  `tfsdk:"phase_timeouts"`
+	Timeouts       *ApplyTimeoutsModel       `tfsdk:"timeouts"`
+	ResourceLimits *ApplyResourceLimitsModel `tfsdk:"resource_limits"`
+	StateExport    *ApplyStateExportModel    `tfsdk:"state_export"`
+	Templating     *ApplyTemplatingModel     `tfsdk:"templating"`
+
+	// skipRefresh is set by Update to apply with -refresh=false.
+	skipRefresh bool
+	// appliedPlan is the digest of the last plan_file applied, set by Update
+	// so it isn't applied again, and by doApply when it applies one.
+	appliedPlan string
+	// commands, if set, records the commands the apply runs.
+	commands *commandLog
+	// states, if set, caches the state files the resource reads.
+	states *stateCache
+}
+
+// ApplyResourceLimitsModel describes the resource_limits block.
+type ApplyResourceLimitsModel struct {
+	CPUNice   types.Int64  `tfsdk:"cpu_nice"`
+	MaxMemory types.String `tfsdk:"max_memory"`
+}
+
+// limits returns the resource limits described by m, which may be nil.
+func (m *ApplyResourceLimitsModel) limits() (resourceLimits, error) {
+	var l resourceLimits
+	if m == nil {
+		return l, nil
+	}
+	l.Nice = int(m.CPUNice.ValueInt64())
+	if l.Nice < -20 || l.Nice > 19 {
+		return l, fmt.Errorf("cpu_nice must be between -20 and 19, got %d", l.Nice)
+	}
+	if !m.MaxMemory.IsNull() {
+		n, err := parseSize(m.MaxMemory.ValueString())
+		if err != nil {
+			return l, err
+		}
+		l.MaxMemory = n
+	}
+	return l, nil
+}
+
+// ApplyAttestationModel describes the attestation block.
+type ApplyAttestationModel struct {
+	Path           types.String `tfsdk:"path"`
+	SigningKeyFile types.String `tfsdk:"signing_key_file"`
+}
+
+// ApplyPlanArtifactModel describes the plan_artifact block.
+type ApplyPlanArtifactModel struct {
+	Destination types.String `tfsdk:"destination"`
+}
+
+// ApplyExpectedModel describes the expected_resources block.
+type ApplyExpectedModel struct {
+	Addresses  types.List   `tfsdk:"addresses"`
+	TypeCounts types.Map    `tfsdk:"type_counts"`
+	Match      types.String `tfsdk:"match"`
+}
+
+// expected returns the expected resources described by m.
+func (m *ApplyExpectedModel) expected(ctx context.Context) (expectedResources, diag.Diagnostics) {
+	e := expectedResources{Exact: m.Match.ValueString() == "exact"}
+	diags := m.Addresses.ElementsAs(ctx, &e.Addresses, false)
+	diags.Append(m.TypeCounts.ElementsAs(ctx, &e.TypeCounts, false)...)
+	return e, diags
+}
+
+// ApplyModuleModel describes a module installed in the working directory.
+type ApplyModuleModel struct {
+	Key     types.String `tfsdk:"key"`
+	Source  types.String `tfsdk:"source"`
+	Version types.String `tfsdk:"version"`
+	Dir     types.String `tfsdk:"dir"`
+
+	ResolvedSource types.String `tfsdk:"resolved_source"`
+}
+
+var applyModuleAttrTypes = map[string]attr.Type{
+	"key":             types.StringType,
+	"source":          types.StringType,
+	"version":         types.StringType,
+	"dir":             types.StringType,
+	"resolved_source": types.StringType,
+}
+
+// idStrategies are the values of id_strategy, the first of which is the
+// default.
+var idStrategies = []string{"state_hash", "lineage", "uuid", "workdir"}
+
+// idStrategy returns m's id_strategy, or the default if it isn't set.
+func (m *ApplyResourceModel) idStrategy() string {
+	if s := m.IDStrategy.ValueString(); s != "" {
+		return s
+	}
+	return idStrategies[0]
+}
+
+// ID returns m's ID, as chosen by its id_strategy.
+func (m *ApplyResourceModel) ID() (string, error) {
+	switch m.idStrategy() {
+	case "workdir":
+		return filepath.Abs(m.WorkingDir.ValueString())
+	case "uuid":
+		// Generated once, and kept until id_strategy changes.
+		if id := m.Id.ValueString(); id != "" {
+			return id, nil
+		}
+		return uuid.GenerateUUID()
+	}
+	fn := filepath.Join(m.WorkingDir.ValueString(), statePath(m.WorkspaceName.ValueString()))
+	if m.idStrategy() == "lineage" {
+		s, err := m.states.header(fn)
+		if err != nil {
+			return "", fmt.Errorf("Unable to read %s, got error: %s", fn, err)
+		}
+		return s.Lineage, nil
+	}
+	digest, err := m.states.digest(fn)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read %s, got error: %s", fn, err)
+	}
+	return digest, nil
+}
+
+// fileDigest returns the hex-encoded SHA-256 digest of the file fn.
+func fileDigest(fn string) (string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// refresh updates the computed attributes from the working directory.
+func (m *ApplyResourceModel) refresh(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.WorkspaceName.IsNull() {
+		// Resources created before workspace was supported.
+		m.WorkspaceName = types.StringValue("default")
+	}
+
+	id, err := m.ID()
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to get ID, got error: %s", err))
+	}
+	m.Id = basetypes.NewStringValue(id)
+
+	installed, err := readModulesManifest(m.WorkingDir.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to get modules, got error: %s", err))
+	}
+	modules := []ApplyModuleModel{}
+	for _, mod := range installed {
+		if mod.Key == "" {
+			// The root module.
+			continue
+		}
+		version := types.StringNull()
+		if mod.Version != "" {
+			version = types.StringValue(mod.Version)
+		}
+		resolved := types.StringNull()
+		if src, err := resolvedSource(ctx, m.WorkingDir.ValueString(), mod); err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to resolve module source, got error: %s", err))
+		} else if src != "" {
+			resolved = types.StringValue(src)
+		}
+		modules = append(modules, ApplyModuleModel{
+			Key:            types.StringValue(mod.Key),
+			Source:         types.StringValue(mod.Source),
+			Version:        version,
+			Dir:            types.StringValue(mod.Dir),
+			ResolvedSource: resolved,
+		})
+	}
+	var d diag.Diagnostics
+	m.Modules, d = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: applyModuleAttrTypes}, modules)
+	diags.Append(d...)
+
+	return diags
+}
+
+func (r *ApplyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_apply"
+}
+
+func (r *ApplyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Terraform Apply resource",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "What directory to run `terraform apply` in. Exactly one of `working_dir` or `root_dir` must be set; with `root_dir`, this is `relative_path` in it.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"variables": schema.MapAttribute{
+				MarkdownDescription: "Values for nested variables, passed as `-var` arguments before `args`. Values are passed as they are, without quoting or escaping: string variables get them verbatim, including quotes, newlines and JSON, and other variables parse them as HCL, like `[\"a\", \"b\"]`. Can't be used with `plan_file`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"var_files": schema.ListAttribute{
+				MarkdownDescription: "Paths of variable definitions files, like `env/prod.tfvars`, relative to the working directory, passed as `-var-file` arguments before those of `variables`, `variables_json` and `args`, which take precedence over them. Planning fails if any of them doesn't exist. Can't be used with `plan_file`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"variables_json": schema.StringAttribute{
+				MarkdownDescription: "Values for nested variables of any type, as a JSON object, like `jsonencode({ zones = [\"a\", \"b\"], tags = { team = \"web\" } })`. Each is passed as a `-var` argument after those of `variables` and before `args`: strings as they are, and other values as HCL, with strings quoted and escaped, so lists, maps and objects reach the nested configuration as they were written. Variables can't be set by both `variables` and `variables_json`, or be null. Can't be used with `plan_file`.",
+				Optional:            true,
+			},
+			"generated_provider_config": schema.StringAttribute{
+				MarkdownDescription: "Settings for the nested configuration's providers, as a JSON object by provider name, like `jsonencode({ aws = { region = \"us-east-1\", assume_role = { role_arn = var.role_arn } } })`, so a wrapped module can be pointed at another account or region without editing it. They're written to `" + providersOverrideFile + "` in the working directory before each nested apply, which terraform merges into the configuration's own `provider` blocks, so there must be one for each provider, even if it's empty. Nested blocks, like `assume_role`, are written as objects. The file stays in the working directory, with the provider's `file_permissions`, `0600` by default, and is removed again when this is unset.",
+				Optional:            true,
+			},
+			"override_files": schema.MapAttribute{
+				MarkdownDescription: "Contents of override files, in HCL or JSON, by file name, like `backend_override.tf`, written to the working directory before each nested `terraform init` and removed after the apply, so vendored configuration, like its backend, provider versions or variable defaults, can be changed without editing it. Names must be `override.tf` or `override.tf.json`, or end in `_override.tf` or `_override.tf.json`, for terraform to merge them into the configuration. Files that are already in the working directory are neither overwritten nor removed, and applying fails if their contents differ. They're written with the provider's `file_permissions`, `0600` by default.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"environment": schema.MapAttribute{
+				MarkdownDescription: "Environment variables set for the nested `terraform` commands, like `AWS_PROFILE`, `GOOGLE_APPLICATION_CREDENTIALS` or `TF_VAR_region`, without setting them for the provider itself. They take precedence over the provider's own environment, and those passed through by `passthrough_var_prefix`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"backend_config": schema.MapAttribute{
+				MarkdownDescription: "Settings for the nested configuration's backend, passed to `terraform init` as `-backend-config` arguments, like `key = \"network/terraform.tfstate\"`, for configurations with a partial backend configuration. They take precedence over `backend_config_files`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"backend_config_files": schema.ListAttribute{
+				MarkdownDescription: "Paths of files of settings for the nested configuration's backend, like `backends/prod.tfbackend`, relative to the working directory, passed to `terraform init` as `-backend-config` arguments, in order. Planning fails if any of them doesn't exist.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"passthrough_var_prefix": schema.StringAttribute{
+				MarkdownDescription: "If set, like `nested_`, each `TF_VAR_` environment variable of the provider whose name starts with the prefix after `TF_VAR_`, like `TF_VAR_nested_region`, is passed to the nested configuration with the prefix removed, as `TF_VAR_region`, so pipelines can set nested variables without listing each one. Like other `TF_VAR_` variables, `variables` and `args` take precedence.",
+				Optional:            true,
+			},
+			"terraform_version": schema.StringAttribute{
+				MarkdownDescription: "Exact version of terraform to run the nested configuration with, like `1.7.5`, instead of the one on `PATH`. It's downloaded from releases.hashicorp.com, and its signature verified, the first time it's used, into the provider's `terraform_versions_dir`, which is shared by all resources: however many ask for a version at once, it's only downloaded once. With `offline`, it must already be installed there. Whichever terraform is used, applying fails before it runs if the nested state was written by a newer minor version of terraform.",
+				Optional:            true,
+			},
+			"allow_version_change": schema.BoolAttribute{
+				MarkdownDescription: "Whether the major or minor version of terraform running the nested configuration may differ from the one that last applied it, say after terraform on `PATH` was upgraded. New versions may upgrade the nested state so older ones can't read it. By default such a change is warned about when planning; if `false`, planning fails instead, and if `true`, it's allowed silently.",
+				Optional:            true,
+			},
+			"rate_limits": schema.MapAttribute{
+				MarkdownDescription: "Retry and rate-limit settings for the nested providers, set through the environment variables they read, so wrapped stacks can be throttled uniformly without editing their modules. The keys are `aws_max_attempts` and `aws_retry_mode` (`AWS_MAX_ATTEMPTS` and `AWS_RETRY_MODE`, read by the AWS SDK), `cloudflare_rps` and `cloudflare_retries` (`CLOUDFLARE_RPS` and `CLOUDFLARE_RETRIES`), and `vault_max_retries` and `vault_rate_limit` (`VAULT_MAX_RETRIES` and `VAULT_RATE_LIMIT`). They take precedence over the provider's own environment, but nested provider blocks that configure the same settings take precedence over them.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"desired_state": schema.StringAttribute{
+				MarkdownDescription: "Whether the nested resources should exist, `present`, the default, or be destroyed, `absent`. When `absent`, applies run with `-destroy`, so the nested stack can be scaled to zero and brought back later by setting it to `present` again, without removing this resource. `expected_resources`, `wait_for_http` and `checks` are skipped. Can't be used with `plan_file`.",
+				Optional:            true,
+			},
+			"suspended": schema.BoolAttribute{
+				MarkdownDescription: "Whether the nested stack is parked, which is the same as setting `desired_state` to `absent`: the nested resources are destroyed, and created again when it's unset. Toggle it, say from a variable, to park development environments when they aren't used, like `suspended = var.after_hours`.",
+				Optional:            true,
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values that cause the nested configuration to be applied again when they change, like the `value` of a `pteraform_revision` data source or the digest of an image it deploys. As with the `triggers` of `null_resource`, changing them updates the resource, running `terraform init` and `terraform apply` again even if nothing else changed.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"root_dir": schema.StringAttribute{
+				MarkdownDescription: "Root of a repository containing the nested configuration at `relative_path`, for configurations that use shared modules elsewhere in it, like `../../modules/vpc`. `terraform` is run in `root_dir` with `-chdir=<relative_path>`.",
+				Optional:            true,
+			},
+			"relative_path": schema.StringAttribute{
+				MarkdownDescription: "Path of the nested configuration in `root_dir`, which it must not be outside of. Requires `root_dir`.",
+				Optional:            true,
+			},
+			"args": schema.ListAttribute{
+				MarkdownDescription: "Arguments to pass to `terraform apply`. The outer workspace, and the outer run ID in HCP Terraform, are always passed as the `pteraform_outer_workspace` and `pteraform_outer_run_id` variables, which the nested configuration can declare to record them. Options pteraform sets itself, like `-json` and `-auto-approve`, can't be passed, and `-target` and `-replace` addresses, and options terraform rejects together, like `-replace` with `-refresh-only`, are checked when the configuration is validated.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"workspace": schema.StringAttribute{
+				MarkdownDescription: "Nested workspace to apply in, created if it doesn't exist. If `auto`, a unique name is generated when the resource is created and kept afterwards, so resources using the same backend, such as `for_each` instances, don't collide. Nested commands select it with `TF_WORKSPACE`, so instances sharing a `working_dir` never change each other's workspace. Defaults to the `default` workspace.",
+				Optional:            true,
+			},
+			"workspace_name": schema.StringAttribute{
+				MarkdownDescription: "Name of the nested workspace that is applied in.",
+				Computed:            true,
+			},
+			"allowed_providers": schema.ListAttribute{
+				MarkdownDescription: "Provider source addresses (e.g. `hashicorp/null`) the nested configuration may use. If set, applying a configuration that uses any other provider, including in child modules, fails.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"denied_provisioners": schema.ListAttribute{
+				MarkdownDescription: "Provisioner types (e.g. `local-exec`) the nested configuration may not use. Applying a configuration that uses any of them, including in child modules, fails.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"allow_recursion": schema.BoolAttribute{
+				MarkdownDescription: "Allow the nested configuration, including its child modules, to use the `pteraform` provider itself. By default applying such a configuration fails, since wrapping a stack in itself by mistake recurses until the host runs out of resources.",
+				Optional:            true,
+			},
+			"max_resources": schema.Int64Attribute{
+				MarkdownDescription: "Most managed resource instances the nested state may have after an apply. The nested plan is saved and checked before it's applied, and isn't applied if it would leave more, which guards against runaway `count` or `for_each` in wrapped third-party modules.",
+				Optional:            true,
+			},
+			"plan_file": schema.StringAttribute{
+				MarkdownDescription: "Saved plan file to apply instead of planning: a path relative to `working_dir`, or a remote reference to download it from. Remote references are `https://` URLs, `s3://` and `gs://` objects, downloaded with the `aws` and `gcloud` CLIs, and `oci://` blob references like `oci://example.com/plans@sha256:...`, downloaded with `oras`; `plan_file_hash` is required with them, and `http://` URLs are rejected. Variables can't be passed in `args` when applying a saved plan. A saved plan is only applied once: if the resource is updated for another reason while `plan_file` still has the digest of the plan last applied, it isn't applied again, with a warning.",
+				Optional:            true,
+			},
+			"plan_file_hash": schema.StringAttribute{
+				MarkdownDescription: "Expected hex-encoded SHA-256 digest of `plan_file`, optionally prefixed with `sha256:`. If set, the plan file is verified before it is applied, so the plan that was reviewed can't be swapped for another one.",
+				Optional:            true,
+			},
+			"approval_file": schema.StringAttribute{
+				MarkdownDescription: "Local path, relative to `working_dir`, or `https://` URL that must contain the hex-encoded SHA-256 digest of the nested plan before it's applied. The plan is saved, and uploaded by `plan_artifact` if it's set, then the apply waits for the digest to be written there, so someone can review the plan out of band and approve exactly it. While it waits, the digest is written to the approval file's path with a `.pending` suffix, or for a URL, to `.terraform/pteraform-approval.pending` in `working_dir`, with the provider's `file_permissions`, and logged. Can't be used with `plan_file`.",
+				Optional:            true,
+			},
+			"approval_timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to wait for `approval_file`, like `30m`, before failing the apply. Defaults to `1h`.",
+				Optional:            true,
+			},
+			"plan_artifact_url": schema.StringAttribute{
+				MarkdownDescription: "Where the plan was uploaded by `plan_artifact`, in a form `plan_file` accepts, or null if `plan_artifact` isn't set.",
+				Computed:            true,
+			},
+			"crash_log_destination": schema.StringAttribute{
+				MarkdownDescription: "Where to upload the `crash.log` the nested terraform writes if it panics, like `plan_artifact`'s `destination`. The start of the crash log is always included in the error.",
+				Optional:            true,
+			},
+			"effective_commands": schema.ListAttribute{
+				MarkdownDescription: "The `terraform` commands the last nested apply ran, in order, to run them by hand when debugging: the `phase` each was run in, like `init` or `apply`, the `working_dir` it was run in, the `command` line, with the values of `-var` and `-backend-config` settings masked, and the names of the variables in its `environment`.",
+				ElementType:         types.ObjectType{AttrTypes: applyEffectiveCommandAttrTypes},
+				Computed:            true,
+			},
+			"run_id": schema.StringAttribute{
+				MarkdownDescription: "Unique ID of the last nested apply, to correlate it across the provider's logs, where it's the `pteraform_run_id` field, the user agent of the nested providers' API requests, set with `TF_APPEND_USER_AGENT`, its attestation, and the `" + runIDHeader + "` header of `event_sink` requests.",
+				Computed:            true,
+			},
+			"console_url": schema.StringAttribute{
+				MarkdownDescription: "Link to the last apply's run in HCP Terraform or Terraform Enterprise, if the nested configuration runs remotely with a `cloud` block or the `remote` backend, or null otherwise.",
+				Computed:            true,
+			},
+			"working_dir_abs": schema.StringAttribute{
+				MarkdownDescription: "Absolute path of the directory the last apply ran in.",
+				Computed:            true,
+			},
+			"host": schema.StringAttribute{
+				MarkdownDescription: "Hostname of the machine the last apply ran on.",
+				Computed:            true,
+			},
+			"environment_hash": schema.StringAttribute{
+				MarkdownDescription: "Hex-encoded SHA-256 digest of the `TF_*` and `PTERAFORM_*` environment variables, including `TF_VAR_*` variables, the last apply ran with. Comparing it between machines shows whether their nested runs saw the same settings, without exposing them.",
+				Computed:            true,
+			},
+			"input_manifest": schema.StringAttribute{
+				MarkdownDescription: "JSON-encoded manifest of the inputs of the last successful apply, for build systems that cache the outer run to key on: `files`, the path and SHA-256 digest of each file of the nested configuration, sorted by path and excluding `.terraform`, `.git` and local state; `source_digest`, the digest of all of them; `modules`, the resolved source of each installed remote module, keyed by module key; and `variables_digest`, a digest of the arguments, including variables, the backend configuration, and `environment` passed to terraform. It's the same for the same inputs, and contains no variable values.",
+				Computed:            true,
+			},
+			"checks": schema.ListNestedAttribute{
+				MarkdownDescription: "Commands run in `working_dir` after each successful apply, to check the nested stack is healthy. If any fails, the apply fails. The nested outputs are passed to them as environment variables: `PTERAFORM_OUTPUT_<name>` for each, which is the value of strings and JSON-encoded otherwise, and `PTERAFORM_OUTPUTS` with all of them as a JSON object.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"command": schema.StringAttribute{
+							MarkdownDescription: "Command to run.",
+							Required:            true,
+						},
+						"interpreter": schema.ListAttribute{
+							MarkdownDescription: "Interpreter and arguments to run `command` with, which is passed as the last argument. Defaults to `[\"/bin/sh\", \"-c\"]`, or `[\"cmd\", \"/C\"]` on Windows.",
+							ElementType:         basetypes.StringType{},
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"stages": schema.ListNestedAttribute{
+				MarkdownDescription: "Targeted applies run in order before the full apply, for nested configurations that must be brought up in steps. Each stage is applied with `-target` set to each of its `targets` and the other `args`. If a stage fails, the full apply is skipped and the apply fails, and the stages after it are skipped too if its `on_failure` is `abort`, the default, or still applied if it's `continue`. The outcome of each is reported in `stage_results`.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Name of the stage, as reported in `stage_results`.",
+							Required:            true,
+						},
+						"targets": schema.ListAttribute{
+							MarkdownDescription: "Addresses of the resources or modules the stage applies, like `module.network`.",
+							ElementType:         basetypes.StringType{},
+							Required:            true,
+						},
+						"on_failure": schema.StringAttribute{
+							MarkdownDescription: "Whether the stages after this one are skipped, `abort`, or still applied, `continue`, if it fails. Defaults to `abort`.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"stage_results": schema.ListAttribute{
+				MarkdownDescription: "Outcome of each of `stages` in the last apply: its `name`, its `status`, which is `succeeded`, `failed` or `skipped`, and the `error` it failed with, if any. Null if there are no stages, or the apply failed before they ran.",
+				ElementType:         types.ObjectType{AttrTypes: applyStageResultAttrTypes},
+				Computed:            true,
+			},
+			"compute_plan_hash": schema.BoolAttribute{
+				MarkdownDescription: "Whether to compute `plan_hash` during the outer plan. This runs `terraform init` and `terraform plan` in `working_dir` during every outer plan.",
+				Optional:            true,
+			},
+			"plan_hash": schema.StringAttribute{
+				MarkdownDescription: "Hex-encoded SHA-256 digest of the changes the nested apply would make, computed during the outer plan if `compute_plan_hash` is set. It is left unchanged when the nested plan has no changes, so a change to `plan_hash` in the outer plan means the nested apply will change something. Null if it couldn't be computed before applying.",
+				Computed:            true,
+			},
+			"modules": schema.ListAttribute{
+				MarkdownDescription: "Modules installed by `terraform init` in the working directory, with the source and version each resolved to. `version` is null for modules not installed from a registry. `resolved_source` records exactly what was installed, so it can be promoted verbatim: for modules cloned with git, `source` with `ref` set to the commit checked out, and for other remote modules, including registry modules, `sha256:` and the digest of the installed files. It's null for local modules.",
+				ElementType:         types.ObjectType{AttrTypes: applyModuleAttrTypes},
+				Computed:            true,
+			},
+			"repair_lockfile": schema.BoolAttribute{
+				MarkdownDescription: "Whether to delete the nested `.terraform.lock.hcl` and run `terraform init` again, once, if init fails because the lock file is corrupt or inconsistent with the configuration, as can happen after switching between Terraform and OpenTofu. A warning is reported when it's regenerated.",
+				Optional:            true,
+			},
+			"modules_only_update": schema.BoolAttribute{
+				MarkdownDescription: "Whether to run `terraform get -update` instead of `terraform init` when the providers have already been installed by a previous apply for the current `.terraform.lock.hcl`, which is much faster for configurations whose local modules change often. Changes to the backend configuration aren't detected, so run a full init, say by removing `.terraform`, after changing it.",
+				Optional:            true,
+			},
+			"prefetch_providers": schema.BoolAttribute{
+				MarkdownDescription: "Whether to start `terraform init -backend=false` in the background when the outer configuration is planned and this resource would change, so the nested providers and modules are already installed when it's applied. The apply waits for it to finish. At most the provider's `max_prefetches` run at once.",
+				Optional:            true,
+			},
+			"skip_unchanged": schema.BoolAttribute{
+				MarkdownDescription: "Whether to skip updates, without even running `terraform plan`, when nothing the nested apply depends on changed since the last successful apply: the arguments, `variables`, `variables_json`, the contents of `var_files` and other `-var-file`s, even outside the working directory, `backend_config` and the contents of `backend_config_files`, `environment`, the workspace, `triggers`, `generated_provider_config`, `override_files`, the variables `templating` references, the dependency lock file and the files of the nested configuration, ignoring `.terraform` and local state. Changes made outside the nested configuration, like to the nested resources themselves, aren't detected, except for `verify_outputs`. Can't be used with `plan_file`.",
+				Optional:            true,
+			},
+			"skipped": schema.BoolAttribute{
+				MarkdownDescription: "Whether the last update was skipped because of `skip_unchanged`.",
+				Computed:            true,
+			},
+			"full_refresh_every": schema.StringAttribute{
+				MarkdownDescription: "If set, like `24h`, applies run with `-refresh=false` so large nested configurations converge quickly, except the first apply and any apply this long after the last one that refreshed, which reconcile changes made outside Terraform. Saved plans are applied as they are.",
+				Optional:            true,
+			},
+			"host_affinity": schema.BoolAttribute{
+				MarkdownDescription: "Whether to refuse to refresh or update a nested configuration that keeps its state locally from any machine but the one that last applied it, where the state is. Without this, applying it elsewhere silently starts from missing or stale state. Nested configurations with a remote backend are unaffected.",
+				Optional:            true,
+			},
+			"offline": schema.BoolAttribute{
+				MarkdownDescription: "Whether `terraform init` may only install providers from the provider's `plugin_dirs` and `plugin_cache_dir`, never from a registry, so nested applies work without network access and always use the same provider packages. Init fails if a provider isn't there. Defaults to the provider's `offline`.",
+				Optional:            true,
+			},
+			"cleanup_on_delete": schema.BoolAttribute{
+				MarkdownDescription: "Whether to remove the nested `.terraform` directory, with its installed modules, providers and saved plans, and any crash logs from the working directory when the resource is destroyed. Nested state and the lock file are kept. Other resources using the same working directory will run `terraform init` again.",
+				Optional:            true,
+			},
+			"preview_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Whether to plan destroying the nested resources when the outer plan destroys this resource, and warn how many nested resources that would remove. Destroying this resource leaves them in place, so reviewers can see what's left behind, or what to destroy first by setting `desired_state` to `absent`.",
+				Optional:            true,
+			},
+			"read_runs_plan": schema.BoolAttribute{
+				MarkdownDescription: "Whether to run `terraform plan` in the working directory whenever the resource is refreshed, recording how many nested resources it would change in `pending_add`, `pending_change` and `pending_destroy`. Changes made outside Terraform are then shown when planning the outer configuration. Can't be used with `plan_file`.",
+				Optional:            true,
+			},
+			"verify_outputs": schema.ListAttribute{
+				MarkdownDescription: "Names of nested outputs, like `endpoint`, that are checked with `terraform output` whenever the resource is refreshed. If any changed since the last apply, for example because the nested state was edited or applied directly, the resource is updated to apply the nested configuration again.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"publish_outputs": schema.MapAttribute{
+				MarkdownDescription: "Nested outputs published to the provider's registry after each apply, keyed by the name to publish each under, for `pteraform_registry` data sources to read, like `{ network_vpc_id = \"vpc_id\" }`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"outputs_changed": schema.MapAttribute{
+				MarkdownDescription: "The nested outputs the last apply changed, keyed by name, with their JSON-encoded `old` and `new` values. `old` is null for outputs that were added, and `new` for those that were removed. The values of sensitive outputs are shown as `(sensitive)`, and only digests of them are kept between applies. Empty if the last update was skipped.",
+				ElementType:         types.ObjectType{AttrTypes: applyOutputChangeAttrTypes},
+				Computed:            true,
+			},
+			"pending_add": schema.Int64Attribute{
+				MarkdownDescription: "How many nested resources `terraform plan` would add when the resource was last refreshed, if `read_runs_plan` is set. Replacements count as an add and a destroy.",
+				Computed:            true,
+			},
+			"pending_change": schema.Int64Attribute{
+				MarkdownDescription: "How many nested resources `terraform plan` would change in place when the resource was last refreshed, if `read_runs_plan` is set.",
+				Computed:            true,
+			},
+			"pending_destroy": schema.Int64Attribute{
+				MarkdownDescription: "How many nested resources `terraform plan` would destroy when the resource was last refreshed, if `read_runs_plan` is set.",
+				Computed:            true,
+			},
+			"suppress_warnings": schema.ListAttribute{
+				MarkdownDescription: "Regular expressions matching the summaries of nested `terraform apply` warnings not to report, such as deprecation warnings from third-party modules. Other warnings are reported as a single warning, with repeats combined. Suppressed warnings are still logged and kept in `output`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"capture": schema.StringAttribute{
+				MarkdownDescription: "What nested `terraform apply` output to keep in `output`: `human` for its usual human-readable output, the JSON events from running it with `-json` at `errors`, `warnings` (and errors) or `all` levels, or `none`. Defaults to `none`.",
+				Optional:            true,
+			},
+			"compact_warnings": schema.BoolAttribute{
+				MarkdownDescription: "Whether to run `terraform apply` with `-compact-warnings`, so warnings in its human-readable output are shown as summaries only.",
+				Optional:            true,
+			},
+			"concise": schema.BoolAttribute{
+				MarkdownDescription: "Whether to run `terraform apply` with `-concise`, leaving progress messages out of its human-readable output. Requires Terraform 1.5 or later.",
+				Optional:            true,
+			},
+			"parallelism": schema.Int64Attribute{
+				MarkdownDescription: "How many nested resources `terraform apply` changes at once, passed as `-parallelism`, including when destroying them because `desired_state` is `absent`. Must be positive. Terraform defaults to 10.",
+				Optional:            true,
+			},
+			"output": schema.StringAttribute{
+				MarkdownDescription: "Output of the last `terraform apply` kept by `capture`: the human-readable output, or the JSON events retained, one per line. Null if `capture` is `none`. Kept when the apply fails, too. Line endings are normalized to `\\n`, and bytes that aren't valid UTF-8, as written by terraform in some legacy locales, are replaced with `U+FFFD`. The full output of a failed command that's too long for the error is saved separately, as described for `last_error`.",
+				Computed:            true,
+			},
+			"change_diagram": schema.BoolAttribute{
+				MarkdownDescription: "Whether to render the nested resources the last apply changed as a [Mermaid](https://mermaid.js.org/) flowchart in `change_diagram_mermaid`, to paste into pull request descriptions and runbooks. Applies run with `-json`, so it can't be used with `capture = \"human\"`.",
+				Optional:            true,
+			},
+			"change_diagram_mermaid": schema.StringAttribute{
+				MarkdownDescription: "Mermaid flowchart of the nested resources the last apply changed, if `change_diagram` is set, with a subgraph for each module and the resources colored by whether they were created, updated, replaced or deleted.",
+				Computed:            true,
+			},
+			"last_error": schema.ObjectAttribute{
+				MarkdownDescription: "Summary of why the last apply failed, or null if it succeeded: the `phase` that failed (`setup`, `templating`, `init`, `policy`, `stages`, `plan_file`, `plan`, `max_resources`, `plan_artifact`, `approval`, `apply`, `expected_resources`, `wait_for_http`, `checks` or `attestation`), terraform's `exit_code` (-1 if terraform didn't run or was killed), the failure `class`, and the first few error `diagnostics`. The class is one of `usage` (terraform rejected its arguments), `diagnostics` (terraform reported errors), `lock` (the state couldn't be locked), `cancelled` (the operation was cancelled or timed out), `killed` (terraform was killed, usually for running out of memory), or `other`. When the output of a failed command is too long for the error, the full output is saved in `.terraform/pteraform-logs` in `working_dir`, or in a directory for it in the provider's `temp_dir` if that's set, with the provider's `file_permissions`, `0600` by default. It's kept until the next successful apply, or until the resource is deleted.",
+				AttributeTypes:      applyLastErrorAttrTypes,
+				Computed:            true,
+			},
+			"id_strategy": schema.StringAttribute{
+				MarkdownDescription: "How `id` is chosen: `state_hash`, the default, is the hex-encoded SHA-256 digest of the nested state file, so it changes whenever the nested state does; `lineage` is the nested state's lineage, which only changes if the state is recreated; `uuid` is random, chosen once; and `workdir` is the absolute path of `working_dir`. Changing it changes `id`.",
+				Optional:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the resource, chosen by `id_strategy`.",
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+
+		Blocks: map[string]schema.Block{
+			"attestation": schema.SingleNestedBlock{
+				MarkdownDescription: "Write an [in-toto](https://in-toto.io/) attestation after each apply, recording the module sources, locked provider versions, saved plan digest, and outcome. A failure is recorded by its phase, exit code and class, as in `last_error`, but not its diagnostics or terraform's output, which can include sensitive values. When set, `args` are passed to `terraform plan -out` and the saved plan is then applied.",
+				Attributes: map[string]schema.Attribute{
+					"path": schema.StringAttribute{
+						MarkdownDescription: "Where to write the attestation.",
+						Required:            true,
+					},
+					"signing_key_file": schema.StringAttribute{
+						MarkdownDescription: "PEM-encoded Ed25519 or ECDSA private key. If set, the attestation is written as a signed [DSSE](https://github.com/secure-systems-lab/dsse) envelope; otherwise the bare statement is written.",
+						Optional:            true,
+					},
+				},
+			},
+			"plan_artifact": schema.SingleNestedBlock{
+				MarkdownDescription: "Upload the plan that is applied, for audit retention. When set, `args` are passed to `terraform plan -out`, the saved plan is uploaded, and then it's applied. The apply fails without changing anything if the upload fails.",
+				Attributes: map[string]schema.Attribute{
+					"destination": schema.StringAttribute{
+						MarkdownDescription: "Where to upload the plan: an `https://` URL to `PUT` it to, `s3://` or `gs://` objects, uploaded with the `aws` and `gcloud` CLIs, or an `oci://` repository like `oci://example.com/plans` to push it to as a blob with `oras`.",
+						Required:            true,
+					},
+				},
+			},
+			"expected_resources": schema.SingleNestedBlock{
+				MarkdownDescription: "Resources the nested state is expected to contain after each apply. If it doesn't, the apply fails. Data sources are ignored.",
+				Attributes: map[string]schema.Attribute{
+					"addresses": schema.ListAttribute{
+						MarkdownDescription: "Resource addresses, like `module.vpc.aws_vpc.this`, that must be in the nested state.",
+						ElementType:         basetypes.StringType{},
+						Optional:            true,
+					},
+					"type_counts": schema.MapAttribute{
+						MarkdownDescription: "How many resources of each type, keyed by type, must be in the nested state.",
+						ElementType:         basetypes.Int64Type{},
+						Optional:            true,
+					},
+					"match": schema.StringAttribute{
+						MarkdownDescription: "`at_least` if the nested state may contain other resources too, or `exact` if it may only contain `addresses`, if set, and resources of the types in `type_counts`, if set, in exactly those numbers. Defaults to `at_least`.",
+						Optional:            true,
+					},
+				},
+			},
+			"wait_for_http": schema.SingleNestedBlock{
+				MarkdownDescription: "Wait after each apply until a nested service responds, so dependents don't race it coming up. If it doesn't within `timeout`, the apply fails. This is after `expected_resources` and before `checks`.",
+				Attributes: map[string]schema.Attribute{
+					"url": schema.StringAttribute{
+						MarkdownDescription: "URL to `GET`. Nested outputs can be referenced like in `checks`, as `$PTERAFORM_OUTPUT_<name>`, like `$PTERAFORM_OUTPUT_endpoint/healthz`.",
+						Required:            true,
+					},
+					"status": schema.Int64Attribute{
+						MarkdownDescription: "Status code to wait for. Defaults to 200.",
+						Optional:            true,
+					},
+					"timeout": schema.StringAttribute{
+						MarkdownDescription: "How long to wait, like `90s`. Defaults to `5m`.",
+						Optional:            true,
+					},
+					"interval": schema.StringAttribute{
+						MarkdownDescription: "How long to wait between requests. Defaults to `10s`.",
+						Optional:            true,
+					},
+				},
+			},
+			"event_sink": schema.SingleNestedBlock{
+				MarkdownDescription: "Post each event `terraform apply` reports in its [machine-readable output](https://developer.hashicorp.com/terraform/internals/machine-readable-ui), such as each resource starting and finishing changing and the final summary, to a URL as it happens, so the nested apply's progress can be followed elsewhere. Each is posted as it is, as the body of its own request. Events are posted in the background, and if the sink fails or falls behind they're dropped with a logged warning, without failing the apply. Can't be used with `capture = \"human\"`.",
+				Attributes: map[string]schema.Attribute{
+					"url": schema.StringAttribute{
+						MarkdownDescription: "URL to `POST` events to.",
+						Required:            true,
+					},
+					"headers": schema.MapAttribute{
+						MarkdownDescription: "Headers to send with each request, like `Authorization`.",
+						ElementType:         basetypes.StringType{},
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
+			"state_export": schema.SingleNestedBlock{
+				MarkdownDescription: "Upload the nested state after each apply to an address the way Terraform's [`http` backend](https://developer.hashicorp.com/terraform/language/settings/backends/http) stores state, so other configurations can read the nested outputs with a `terraform_remote_state` data source using the `http` backend and the same settings. The state includes sensitive values, so the address should be access-controlled.",
+				Attributes: map[string]schema.Attribute{
+					"address": schema.StringAttribute{
+						MarkdownDescription: "URL to `POST` the state to, as the `http` backend's `address`.",
+						Required:            true,
+					},
+					"username": schema.StringAttribute{
+						MarkdownDescription: "Username for HTTP basic authentication.",
+						Optional:            true,
+					},
+					"password": schema.StringAttribute{
+						MarkdownDescription: "Password for HTTP basic authentication.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
+			"templating": schema.SingleNestedBlock{
+				MarkdownDescription: "Templates in the working directory to render before each nested `terraform init`, so environment-specific values, like secrets fetched into the environment by a secret manager, don't have to be committed in the nested configuration. Each template, like `backend.tf.tmpl`, is rendered next to itself without the `.tmpl` suffix, like `backend.tf`, which should be ignored by version control. Rendered files get the provider's `file_permissions`, `0600` by default, and only see the environment variables nested terraform inherits, as limited by `inherit_environment`. With `skip_unchanged`, changes to the variables `envsubst` templates reference are detected by their digest; `gomplate` templates can read any variable, so they can't be used with it.",
+				Attributes: map[string]schema.Attribute{
+					"engine": schema.StringAttribute{
+						MarkdownDescription: "How to render the templates: `envsubst`, the default, replaces `$NAME` and `${NAME}` with the values of environment variables, like GNU `envsubst`, leaving terraform's own interpolations alone; `gomplate` runs `gomplate`, which must be on `PATH`, so the templates can use its functions and data sources.",
+						Optional:            true,
+					},
+					"files": schema.ListAttribute{
+						MarkdownDescription: "Paths of the templates, relative to the working directory, each ending in `.tmpl`.",
+						ElementType:         basetypes.StringType{},
+						Optional:            true,
+					},
+				},
+			},
+			"phase_timeouts": schema.SingleNestedBlock{
+				MarkdownDescription: "Time limits, like `5m`, for each nested `terraform` command of a kind, after which it's interrupted and the apply fails. By default there are none.",
+				Attributes: map[string]schema.Attribute{
+					"init": schema.StringAttribute{
+						MarkdownDescription: "Limit for each `terraform init`.",
+						Optional:            true,
+					},
+					"plan": schema.StringAttribute{
+						MarkdownDescription: "Limit for each `terraform plan`, including those for `compute_plan_hash` and `read_runs_plan`.",
+						Optional:            true,
+					},
+					"apply": schema.StringAttribute{
+						MarkdownDescription: "Limit for `terraform apply`.",
+						Optional:            true,
+					},
+				},
+			},
+			"timeouts": schema.SingleNestedBlock{
+				MarkdownDescription: "Time limits, like `2h`, for creating, updating and deleting the resource, after which the nested `terraform` is interrupted and the operation fails with what it printed so far. By default there are none; unlike `phase_timeouts`, they limit the whole operation, including the checks and waits after the apply.",
+				Attributes: map[string]schema.Attribute{
+					"create": schema.StringAttribute{
+						MarkdownDescription: "Limit for creating the resource, the first apply.",
+						Optional:            true,
+					},
+					"update": schema.StringAttribute{
+						MarkdownDescription: "Limit for updating the resource, including destroying the nested resources when `desired_state` is `absent`.",
+						Optional:            true,
+					},
+					"delete": schema.StringAttribute{
+						MarkdownDescription: "Limit for deleting the resource, which only matters with `cleanup_on_delete`.",
+						Optional:            true,
+					},
+				},
+			},
+			"resource_limits": schema.SingleNestedBlock{
+				MarkdownDescription: "Limits applied to the nested `terraform` processes and the providers they run, so a heavy nested apply can't starve the host. Only supported on Linux.",
+				Attributes: map[string]schema.Attribute{
+					"cpu_nice": schema.Int64Attribute{
+						MarkdownDescription: "Scheduling priority (niceness) from -20 to 19; higher is lower priority. Negative values usually require elevated privileges.",
+						Optional:            true,
+					},
+					"max_memory": schema.StringAttribute{
+						MarkdownDescription: "Maximum memory, like `512MiB` or `2GiB`. Enforced with a cgroup v2 child of the provider's cgroup, which requires the memory controller to be delegated to it; if that isn't possible, a warning is logged and no memory limit is applied.",
+						Optional:            true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *ApplyResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+	r.provider = data
+}
+
+// nestedArgs returns args preceded by -destroy if m's desired_state is
+// absent and the arguments that set m's var_files and variables, so args
+// take precedence, and followed by the arguments that merge the provider's
+// default_tags into the nested configuration, if any.
+func (r *ApplyResource) nestedArgs(ctx context.Context, m *ApplyResourceModel, args []string) ([]string, error) {
+	var vars map[string]string
+	if diags := m.Variables.ElementsAs(ctx, &vars, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting variables: %v", diags.Errors())
+	}
+	typed, err := typedVarArgs(m.VariablesJSON.ValueString())
+	if err != nil {
+		return nil, err
+	}
+	var varFiles []string
+	if diags := m.VarFiles.ElementsAs(ctx, &varFiles, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting var_files: %v", diags.Errors())
+	}
+	args = append(append(append(varFileArgs(varFiles), varArgs(vars)...), typed...), args...)
+	if m.absent() {
+		args = append([]string{"-destroy"}, args...)
+	}
+	if r.provider == nil {
+		return args, nil
+	}
+	tags, err := defaultTagsArgs(m.WorkingDir.ValueString(), args, r.nestedEnviron(m), r.provider.DefaultTagsVariable, r.provider.DefaultTags)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to merge default_tags, got error: %s", err)
+	}
+	return append(args, tags...), nil
+}
+
+// absent reports whether m's nested resources should be destroyed, because
+// its desired_state is absent or it's suspended.
+func (m *ApplyResourceModel) absent() bool {
+	return m.DesiredState.ValueString() == "absent" || m.Suspended.ValueBool()
+}
+
+func (r *ApplyResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		// Destroying.
+		var state ApplyResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() && state.PreviewDestroy.ValueBool() {
+			resp.Diagnostics.Append(r.previewDestroy(ctx, state)...)
+		}
+		return
+	}
+	var data ApplyResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if data.WorkingDir.IsUnknown() {
+		data.resolveWorkingDir()
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("working_dir"), data.WorkingDir)...)
+	}
+	var state ApplyResourceModel
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !data.Workspace.IsUnknown() {
+		ws, err := workspaceName(data.Workspace, state.WorkspaceName)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("workspace"), "Unable to Name Workspace", err.Error())
+			return
+		}
+		data.WorkspaceName = types.StringValue(ws)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("workspace_name"), data.WorkspaceName)...)
+	}
+
+	if !req.State.Raw.IsNull() && data.idStrategy() != state.idStrategy() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), types.StringUnknown())...)
+	}
+
+	if !req.State.Raw.IsNull() && !data.VerifyOutputs.IsNull() {
+		resp.Diagnostics.Append(planOutputDrift(ctx, req.Private, &resp.Plan)...)
+	}
+
+	if data.WorkingDir.IsUnknown() || !listKnown(data.Args) || !mapKnown(data.Variables) || !listKnown(data.VarFiles) || data.VariablesJSON.IsUnknown() || data.PlanFile.IsUnknown() {
+		return
+	}
+	if _, err := os.Stat(data.WorkingDir.ValueString()); os.IsNotExist(err) {
+		// The directory may be created by another resource during apply.
+		return
+	}
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(checkCLIVersion(ctx, &data, req.Private)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	resp.Diagnostics.Append(checkBackendConfigFiles(&data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Variables were set when a saved plan was created.
+	if data.PlanFile.IsNull() {
+		resp.Diagnostics.Append(r.checkVariables(ctx, &data)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if data.ComputePlanHash.ValueBool() && !data.WorkspaceName.IsUnknown() {
+		resp.Diagnostics.Append(r.planHash(ctx, &data, state.PlanHash)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("plan_hash"), data.PlanHash)...)
+	}
+
+	if !resp.Plan.Raw.Equal(req.State.Raw) {
+		r.prefetch(ctx, &data)
+	}
+}
+
+// checkVariables checks that every required nested variable is supplied, so
+// a missing one fails the plan instead of the apply. Nested configurations
+// initialized with a remote backend, like Terraform Cloud, can get variables
+// from it, so for them a missing variable is only a warning.
+func (r *ApplyResource) checkVariables(ctx context.Context, m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	mod, err := loadModule(m.WorkingDir.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("working_dir"), "Invalid Nested Configuration", err.Error())
+		return diags
+	}
+	for i, fn := range m.VarFiles.Elements() {
+		fn, ok := fn.(types.String)
+		if !ok || fn.IsNull() {
+			continue
+		}
+		if _, err := os.Stat(varFilePath(m.WorkingDir.ValueString(), fn.ValueString())); err != nil {
+			diags.AddAttributeError(path.Root("var_files").AtListIndex(i), "Missing Variable Definitions File",
+				fmt.Sprintf("Unable to read variable definitions file %s in %s, got error: %s", fn.ValueString(), m.WorkingDir.ValueString(), err))
+		}
+	}
+	var args []string
+	diags.Append(m.Args.ElementsAs(ctx, &args, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	args, err = r.nestedArgs(ctx, m, args)
+	if err != nil {
+		diags.AddAttributeError(path.Root("args"), "Invalid Nested Variables", err.Error())
+		return diags
+	}
+	vars, err := collectVariables(m.WorkingDir.ValueString(), args, r.nestedEnviron(m))
+	if err != nil {
+		diags.AddAttributeError(path.Root("args"), "Invalid Nested Variables", err.Error())
+		return diags
+	}
+	local := localState(m.WorkingDir.ValueString())
+	for _, name := range missingVariables(mod, vars) {
+		msg := fmt.Sprintf("The nested configuration in %s requires a value for variable %q, but none was supplied in variables, variables_json or var_files, with -var, -var-file, a TF_VAR_%s environment variable, or an automatically loaded .tfvars file.", m.WorkingDir.ValueString(), name, name)
+		if local {
+			diags.AddAttributeError(path.Root("args"), "Missing Nested Variable", msg)
+		} else {
+			diags.AddAttributeWarning(path.Root("args"), "Missing Nested Variable", msg+" Its remote backend may set it.")
+		}
+	}
+	for _, msg := range invalidVariables(mod, vars) {
+		diags.AddAttributeError(path.Root("args"), "Invalid Nested Variable", msg)
+	}
+	return diags
+}
+
+// planHash sets m.PlanHash to the digest of the nested plan's changes, or
+// to prior if there are none.
+func (r *ApplyResource) planHash(ctx context.Context, m *ApplyResourceModel, prior types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var args []string
+	diags.Append(m.Args.ElementsAs(ctx, &args, false)...)
+	limits, err := m.ResourceLimits.limits()
+	if err != nil {
+		diags.AddAttributeError(path.Root("resource_limits"), "Invalid Resource Limits", err.Error())
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	dir := m.WorkingDir.ValueString()
+	var planFile string
+	if !m.PlanFile.IsNull() {
+		f, _, cleanup, err := resolvePlanFile(ctx, http.DefaultClient, dir, r.provider.tempDir(), m.PlanFile.ValueString(), m.PlanFileHash.ValueString(), r.fileMode())
+		if err != nil {
+			diags.AddAttributeError(path.Root("plan_file"), "Invalid Plan File", err.Error())
+			return diags
+		}
+		defer cleanup()
+		planFile = f
+	} else if args, err = r.nestedArgs(ctx, m, args); err != nil {
+		diags.AddAttributeError(path.Root("args"), "Invalid Nested Variables", err.Error())
+		return diags
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return diags
+	}
+	tf, err := r.runner(limits, m, runID)
+	if err != nil {
+		diags.AddAttributeError(path.Root("compute_plan_hash"), "Unable to Plan Nested Configuration", err.Error())
+		return diags
+	}
+	backend, err := m.backendArgs(ctx)
+	if err != nil {
+		diags.AddAttributeError(path.Root("backend_config"), "Invalid Backend Configuration", err.Error())
+		return diags
+	}
+	planJSON, err := nestedPlanJSON(ctx, tf, dir, r.provider.tempDir(), m.WorkspaceName.ValueString(), backend, args, planFile)
+	if err != nil {
+		diags.AddAttributeError(path.Root("compute_plan_hash"), "Unable to Plan Nested Configuration", err.Error())
+		return diags
+	}
+	digest, changed, err := planChangesDigest(planJSON)
+	if err != nil {
+		diags.AddAttributeError(path.Root("compute_plan_hash"), "Unable to Plan Nested Configuration", err.Error())
+		return diags
+	}
+	m.PlanHash = types.StringValue(digest)
+	if !changed && !prior.IsNull() && !prior.IsUnknown() {
+		m.PlanHash = prior
+	}
+	return diags
+}
+
+// pendingChanges sets the pending change counts to what the nested plan would
+// change.
+func (r *ApplyResource) pendingChanges(ctx context.Context, m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var args []string
+	diags.Append(m.Args.ElementsAs(ctx, &args, false)...)
+	limits, err := m.ResourceLimits.limits()
+	if err != nil {
+		diags.AddAttributeError(path.Root("resource_limits"), "Invalid Resource Limits", err.Error())
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	dir := m.WorkingDir.ValueString()
+	if args, err = r.nestedArgs(ctx, m, args); err != nil {
+		diags.AddAttributeError(path.Root("args"), "Invalid Nested Variables", err.Error())
+		return diags
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return diags
+	}
+	tf, err := r.runner(limits, m, runID)
+	if err != nil {
+		diags.AddAttributeError(path.Root("read_runs_plan"), "Unable to Plan Nested Configuration", err.Error())
+		return diags
+	}
+	backend, err := m.backendArgs(ctx)
+	if err != nil {
+		diags.AddAttributeError(path.Root("backend_config"), "Invalid Backend Configuration", err.Error())
+		return diags
+	}
+	planJSON, err := nestedPlanJSON(ctx, tf, dir, r.provider.tempDir(), m.WorkspaceName.ValueString(), backend, args, "")
+	if err != nil {
+		diags.AddAttributeError(path.Root("read_runs_plan"), "Unable to Plan Nested Configuration", err.Error())
+		return diags
+	}
+	summary, err := summarizePlan(planJSON)
+	if err != nil {
+		diags.AddAttributeError(path.Root("read_runs_plan"), "Unable to Plan Nested Configuration", err.Error())
+		return diags
+	}
+	m.setPending(summary)
+	return diags
+}
+
+// setPending sets the pending change counts to s if read_runs_plan is set,
+// and to null otherwise.
+func (m *ApplyResourceModel) setPending(s planSummary) {
+	if !m.ReadRunsPlan.ValueBool() {
+		m.PendingAdd, m.PendingChange, m.PendingDestroy = types.Int64Null(), types.Int64Null(), types.Int64Null()
+		return
+	}
+	m.PendingAdd = types.Int64Value(s.Add)
+	m.PendingChange = types.Int64Value(s.Change)
+	m.PendingDestroy = types.Int64Value(s.Destroy)
+}
+
+// warn returns a warning for the warnings in the output of terraform apply
+// that aren't suppressed, if there are any.
+func (m *ApplyResourceModel) warn(ctx context.Context, output string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var patterns []string
+	diags.Append(m.SuppressWarnings.ElementsAs(ctx, &patterns, false)...)
+	suppress := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			diags.AddAttributeError(path.Root("suppress_warnings"), "Invalid Warning Pattern", err.Error())
+			return diags
+		}
+		suppress = append(suppress, re)
+	}
+	if warnings := nestedWarnings(ctx, output, suppress); len(warnings) > 0 {
+		diags.AddWarning("Nested Configuration Warnings", fmt.Sprintf("terraform apply in %s reported warnings:\n\n- %s", m.WorkingDir.ValueString(), strings.Join(warnings, "\n- ")))
+	}
+	return diags
+}
+
+var sha256Pattern = regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`)
+
+func (r *ApplyResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data ApplyResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(data.validateWorkingDir()...)
+	resp.Diagnostics.Append(data.validateSettings(ctx)...)
+	resp.Diagnostics.Append(data.validateDurations()...)
+	resp.Diagnostics.Append(data.validateStages(ctx)...)
+	resp.Diagnostics.Append(data.validateInputs()...)
+	resp.Diagnostics.Append(data.validateArgs()...)
+	resp.Diagnostics.Append(data.validatePlanFile()...)
+	resp.Diagnostics.Append(data.validateOutputs()...)
+}
+
+// validateWorkingDir returns errors for working_dir, root_dir and relative_path.
+func (m *ApplyResourceModel) validateWorkingDir() diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.WorkingDir.IsNull() == m.RootDir.IsNull() {
+		diags.AddAttributeError(path.Root("working_dir"), "Invalid Working Directory", "Exactly one of working_dir or root_dir must be set.")
+	}
+	if m.RootDir.IsNull() != m.RelativePath.IsNull() {
+		diags.AddAttributeError(path.Root("relative_path"), "Invalid Relative Path", "root_dir and relative_path must be set together.")
+	}
+	if p := m.RelativePath; !p.IsNull() && !p.IsUnknown() && !filepath.IsLocal(p.ValueString()) {
+		diags.AddAttributeError(path.Root("relative_path"), "Invalid Relative Path", fmt.Sprintf("relative_path must be a relative path inside root_dir, got %q.", p.ValueString()))
+	}
+	return diags
+}
+
+// validateSettings returns errors for the settings the other validate
+// methods don't check, which are invalid on their own or conflict with
+// other settings.
+func (m *ApplyResourceModel) validateSettings(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if s := m.IDStrategy; !s.IsNull() && !s.IsUnknown() && !slices.Contains(idStrategies, s.ValueString()) {
+		diags.AddAttributeError(path.Root("id_strategy"), "Invalid ID Strategy", fmt.Sprintf("id_strategy must be one of %s, got %q.", strings.Join(idStrategies, ", "), s.ValueString()))
+	}
+	if s := m.DesiredState; !s.IsNull() && !s.IsUnknown() {
+		if s.ValueString() != "present" && s.ValueString() != "absent" {
+			diags.AddAttributeError(path.Root("desired_state"), "Invalid Desired State", fmt.Sprintf("desired_state must be present or absent, got %q.", s.ValueString()))
+		} else if s.ValueString() == "absent" && !m.PlanFile.IsNull() {
+			diags.AddAttributeError(path.Root("desired_state"), "Conflicting Desired State", "desired_state can't be absent with plan_file, which is applied as it was planned.")
+		}
+	}
+	if t := m.Templating; m.SkipUnchanged.ValueBool() && t != nil && !t.Engine.IsUnknown() && t.engine() == "gomplate" {
+		diags.AddAttributeError(path.Root("skip_unchanged"), "Conflicting Skip Unchanged", "skip_unchanged can't be used with templating's gomplate engine, since its templates can read any environment variable, so changes to the values they render can't be detected.")
+	}
+	for k := range m.RateLimits.Elements() {
+		if _, ok := rateLimitVars[k]; !ok {
+			diags.AddAttributeError(path.Root("rate_limits"), "Invalid Rate Limit", fmt.Sprintf("rate_limits has unknown key %q, must be one of %s.", k, strings.Join(rateLimitKeys(), ", ")))
+		}
+	}
+	if p := m.Parallelism; !p.IsNull() && !p.IsUnknown() && p.ValueInt64() < 1 {
+		diags.AddAttributeError(path.Root("parallelism"), "Invalid Parallelism", fmt.Sprintf("parallelism must be positive, got %d.", p.ValueInt64()))
+	}
+	if b := m.MaxResources; !b.IsNull() && !b.IsUnknown() && b.ValueInt64() < 0 {
+		diags.AddAttributeError(path.Root("max_resources"), "Invalid Resource Budget", "max_resources can't be negative.")
+	}
+	if v := m.TerraformVersion; !v.IsNull() && !v.IsUnknown() {
+		if _, err := parseTerraformVersion(v.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("terraform_version"), "Invalid Terraform Version", err.Error())
+		}
+	}
+	if f := m.ApprovalFile; !f.IsNull() && !f.IsUnknown() {
+		if err := checkApprovalFile(f.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("approval_file"), "Insecure Approval File", err.Error())
+		}
+	}
+	if m.ResourceLimits != nil && !m.ResourceLimits.CPUNice.IsUnknown() && !m.ResourceLimits.MaxMemory.IsUnknown() {
+		if _, err := m.ResourceLimits.limits(); err != nil {
+			diags.AddAttributeError(path.Root("resource_limits"), "Invalid Resource Limits", err.Error())
+		}
+	}
+	if a := m.PlanArtifact; a != nil && !a.Destination.IsUnknown() && !isPlanArtifactDestination(a.Destination.ValueString()) {
+		diags.AddAttributeError(path.Root("plan_artifact").AtName("destination"), "Invalid Plan Artifact Destination", fmt.Sprintf("destination must be an https://, s3://, gs:// or oci:// reference without a digest, got %q.", a.Destination.ValueString()))
+	}
+	if d := m.CrashDestination; !d.IsNull() && !d.IsUnknown() && !isPlanArtifactDestination(d.ValueString()) {
+		diags.AddAttributeError(path.Root("crash_log_destination"), "Invalid Crash Log Destination", fmt.Sprintf("crash_log_destination must be an https://, s3://, gs:// or oci:// reference without a digest, got %q.", d.ValueString()))
+	}
+	if e := m.Expected; e != nil && !e.Match.IsNull() && !e.Match.IsUnknown() && e.Match.ValueString() != "at_least" && e.Match.ValueString() != "exact" {
+		diags.AddAttributeError(path.Root("expected_resources").AtName("match"), "Invalid Match", fmt.Sprintf("match must be at_least or exact, got %q.", e.Match.ValueString()))
+	}
+	if t := m.Templating; t != nil && !t.Engine.IsUnknown() && listKnown(t.Files) {
+		if _, err := t.templates(ctx); err != nil {
+			diags.AddAttributeError(path.Root("templating"), "Invalid Templating", err.Error())
+		}
+	}
+	return diags
 }
 
-func (m *ApplyResourceModel) ID() (string, error) {
-	f, err := os.Open(filepath.Join(m.WorkingDir.ValueString(), "terraform.tfstate"))
-	if err != nil {
-		return "", fmt.Errorf("Unable to open terraform.tfstate, got error: %s", err)
+// validateDurations returns errors for full_refresh_every, approval_timeout,
+// phase_timeouts, timeouts and wait_for_http.
+func (m *ApplyResourceModel) validateDurations() diag.Diagnostics {
+	var diags diag.Diagnostics
+	if e := m.FullRefreshEvery; !e.IsNull() && !e.IsUnknown() {
+		if d, err := time.ParseDuration(e.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("full_refresh_every"), "Invalid Full Refresh Interval", err.Error())
+		} else if d <= 0 {
+			diags.AddAttributeError(path.Root("full_refresh_every"), "Invalid Full Refresh Interval", fmt.Sprintf("full_refresh_every must be positive, got %q.", e.ValueString()))
+		}
 	}
-	defer f.Close()
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", fmt.Errorf("Unable to read terraform.tfstate, got error: %s", err)
+	if t := m.ApprovalTimeout; !t.IsNull() && !t.IsUnknown() {
+		if _, err := approvalTimeout(t.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("approval_timeout"), "Invalid Approval Timeout", err.Error())
+		}
 	}
-	return fmt.Sprintf("%x", h.Sum(nil)), nil
+	if t := m.PhaseTimeouts; t != nil && !t.Init.IsUnknown() && !t.Plan.IsUnknown() && !t.Apply.IsUnknown() {
+		if _, err := t.timeouts(); err != nil {
+			diags.AddAttributeError(path.Root("phase_timeouts"), "Invalid Phase Timeouts", err.Error())
+		}
+	}
+	if t := m.Timeouts; t != nil {
+		for _, op := range []string{"create", "update", "delete"} {
+			if _, err := t.timeout(op); err != nil {
+				diags.AddAttributeError(path.Root("timeouts").AtName(op), "Invalid Timeouts", err.Error())
+			}
+		}
+	}
+	if w := m.WaitForHTTP; w != nil && !w.Status.IsUnknown() && !w.Timeout.IsUnknown() && !w.Interval.IsUnknown() {
+		if _, err := w.wait(); err != nil {
+			diags.AddAttributeError(path.Root("wait_for_http"), "Invalid Wait For HTTP", err.Error())
+		}
+	}
+	return diags
 }
 
-func (r *ApplyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
-	resp.TypeName = req.ProviderTypeName + "_apply"
+// validateStages returns errors for stages.
+func (m *ApplyResourceModel) validateStages(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !m.Stages.IsNull() && !m.Stages.IsUnknown() {
+		var models []ApplyStageModel
+		diags.Append(m.Stages.ElementsAs(ctx, &models, false)...)
+		names := map[string]bool{}
+		for i, s := range models {
+			p := path.Root("stages").AtListIndex(i)
+			if n := s.Name; !n.IsUnknown() {
+				if names[n.ValueString()] {
+					diags.AddAttributeError(p.AtName("name"), "Duplicate Stage Name", fmt.Sprintf("Stage names must be unique, got %q more than once.", n.ValueString()))
+				}
+				names[n.ValueString()] = true
+			}
+			if f := s.OnFailure; !f.IsNull() && !f.IsUnknown() && f.ValueString() != "abort" && f.ValueString() != "continue" {
+				diags.AddAttributeError(p.AtName("on_failure"), "Invalid Stage Failure Policy", fmt.Sprintf("on_failure must be abort or continue, got %q.", f.ValueString()))
+			}
+			for j, t := range s.Targets.Elements() {
+				if t, ok := t.(types.String); ok && !t.IsUnknown() && !t.IsNull() {
+					if err := checkAddress(t.ValueString(), true); err != nil {
+						diags.AddAttributeError(p.AtName("targets").AtListIndex(j), "Invalid Stage Target", err.Error())
+					}
+				}
+			}
+		}
+		if !m.PlanFile.IsNull() {
+			diags.AddAttributeError(path.Root("stages"), "Conflicting Stages", "stages can't be used with plan_file, which is applied as it was planned.")
+		}
+	}
+	return diags
 }
 
-func (r *ApplyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
-	resp.Schema = schema.Schema{
-		// This description is used by the documentation generator and the language server.
-		MarkdownDescription: "Terraform Apply resource",
+// validateInputs returns errors for what's passed to terraform besides
+// args: variables, variables_json, passthrough_var_prefix, environment,
+// override_files and generated_provider_config.
+func (m *ApplyResourceModel) validateInputs() diag.Diagnostics {
+	var diags diag.Diagnostics
+	if p := m.PassthroughPrefix; !p.IsNull() && !p.IsUnknown() && !hclsyntax.ValidIdentifier(p.ValueString()) {
+		diags.AddAttributeError(path.Root("passthrough_var_prefix"), "Invalid Variable Prefix", fmt.Sprintf("passthrough_var_prefix must be the start of a variable name, like nested_, got %q.", p.ValueString()))
+	}
+	for name := range m.Variables.Elements() {
+		if !hclsyntax.ValidIdentifier(name) {
+			diags.AddAttributeError(path.Root("variables"), "Invalid Variable Name", fmt.Sprintf("%q is not a valid variable name.", name))
+		}
+	}
+	if j := m.VariablesJSON; !j.IsNull() && !j.IsUnknown() {
+		if typed, err := typedVarArgs(j.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("variables_json"), "Invalid Variables JSON", err.Error()+".")
+		} else {
+			for _, arg := range typed {
+				name, _, _ := strings.Cut(strings.TrimPrefix(arg, "-var="), "=")
+				if !hclsyntax.ValidIdentifier(name) {
+					diags.AddAttributeError(path.Root("variables_json"), "Invalid Variable Name", fmt.Sprintf("%q is not a valid variable name.", name))
+				} else if _, ok := m.Variables.Elements()[name]; ok {
+					diags.AddAttributeError(path.Root("variables_json"), "Conflicting Variables", fmt.Sprintf("variable %q is set by both variables and variables_json.", name))
+				}
+			}
+		}
+	}
+	for name := range m.Environment.Elements() {
+		if err := checkEnvironmentName(name); err != nil {
+			diags.AddAttributeError(path.Root("environment").AtMapKey(name), "Invalid Environment Variable", err.Error()+".")
+		}
+	}
+	for name := range m.OverrideFiles.Elements() {
+		if err := checkOverrideFileName(name); err != nil {
+			diags.AddAttributeError(path.Root("override_files").AtMapKey(name), "Invalid Override File Name", err.Error()+".")
+		}
+	}
+	if c := m.ProviderConfig; !c.IsNull() && !c.IsUnknown() {
+		if _, err := providersOverride(c.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("generated_provider_config"), "Invalid Generated Provider Config", err.Error()+".")
+		}
+	}
+	return diags
+}
 
-		Attributes: map[string]schema.Attribute{
-			"working_dir": schema.StringAttribute{
-				MarkdownDescription: "What directory to run `terraform apply` in.",
-				Required:            true,
-			},
-			"args": schema.ListAttribute{
-				MarkdownDescription: "Arguments to pass to `terraform apply`.",
-				ElementType:         basetypes.StringType{},
-				Optional:            true,
-			},
-			"id": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "Identifier of the resource.",
-				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
-			},
-		},
+// validateArgs returns errors for args, as checked by checkArgs, and for
+// flags in them that conflict with attributes that set them.
+func (m *ApplyResourceModel) validateArgs() diag.Diagnostics {
+	var diags diag.Diagnostics
+	args := make([]*string, len(m.Args.Elements()))
+	for i, a := range m.Args.Elements() {
+		if a, ok := a.(types.String); ok && !a.IsUnknown() && !a.IsNull() {
+			args[i] = a.ValueStringPointer()
+		}
 	}
+	errs := checkArgs(args, !m.PlanFile.IsNull())
+	for i := range args {
+		if err, ok := errs[i]; ok {
+			diags.AddAttributeError(path.Root("args").AtListIndex(i), "Invalid Argument", err.Error())
+		}
+		if a := args[i]; a != nil && !m.Parallelism.IsNull() && flagName(*a) == "-parallelism" {
+			diags.AddAttributeError(path.Root("args").AtListIndex(i), "Conflicting Parallelism", "-parallelism can't be set in args when parallelism is set.")
+		}
+	}
+	return diags
+}
+
+// validatePlanFile returns errors for plan_file_hash, and for settings that
+// can't be used with plan_file, since the plan is applied as it was saved.
+func (m *ApplyResourceModel) validatePlanFile() diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.Suspended.ValueBool() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("suspended"), "Conflicting Suspension", "suspended can't be set with plan_file, which is applied as it was planned.")
+	}
+	if m.SkipUnchanged.ValueBool() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("skip_unchanged"), "Conflicting Skip Unchanged", "skip_unchanged can't be used with plan_file, which is applied whenever it changes.")
+	}
+	if !m.ApprovalFile.IsNull() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("approval_file"), "Conflicting Approval File", "approval_file can't be used with plan_file, which is pinned by plan_file_hash instead.")
+	}
+	if !m.Variables.IsNull() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("variables"), "Conflicting Variables", "variables can't be used with plan_file, whose variables were set when it was planned.")
+	}
+	if !m.VarFiles.IsNull() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("var_files"), "Conflicting Variables", "var_files can't be used with plan_file, whose variables were set when it was planned.")
+	}
+	if !m.VariablesJSON.IsNull() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("variables_json"), "Conflicting Variables", "variables_json can't be used with plan_file, whose variables were set when it was planned.")
+	}
+	if m.ReadRunsPlan.ValueBool() && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("read_runs_plan"), "Conflicting Read Runs Plan", "read_runs_plan can't be used with plan_file, which is already planned.")
+	}
+	if !m.PlanFileHash.IsNull() && m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("plan_file_hash"), "Missing Plan File", "plan_file_hash can only be set with plan_file.")
+	}
+	if p := m.PlanFile; !p.IsNull() && !p.IsUnknown() {
+		if err := checkPlanFile(p.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("plan_file"), "Insecure Plan File", err.Error())
+		}
+	}
+	if p := m.PlanFile; !p.IsUnknown() && isRemotePlanFile(p.ValueString()) && m.PlanFileHash.IsNull() {
+		diags.AddAttributeError(path.Root("plan_file_hash"), "Missing Plan File Hash", "plan_file_hash is required when plan_file is a remote reference, so the downloaded plan can be verified.")
+	}
+	if a := m.PlanArtifact; a != nil && !m.PlanFile.IsNull() {
+		diags.AddAttributeError(path.Root("plan_artifact"), "Conflicting Plan Artifact", "plan_artifact can't be used with plan_file, which is already saved elsewhere.")
+	}
+	if h := m.PlanFileHash; !h.IsNull() && !h.IsUnknown() && !sha256Pattern.MatchString(h.ValueString()) {
+		diags.AddAttributeError(path.Root("plan_file_hash"), "Invalid Plan File Hash", fmt.Sprintf("plan_file_hash %q is not a hex-encoded SHA-256 digest.", h.ValueString()))
+	}
+	return diags
+}
+
+// validateOutputs returns errors for publish_outputs and for how terraform's
+// output is captured and reported.
+func (m *ApplyResourceModel) validateOutputs() diag.Diagnostics {
+	var diags diag.Diagnostics
+	for name := range m.PublishOutputs.Elements() {
+		if !hclsyntax.ValidIdentifier(name) {
+			diags.AddAttributeError(path.Root("publish_outputs"), "Invalid Registry Name", fmt.Sprintf("%q is not a valid registry name.", name))
+		}
+	}
+	if m.EventSink != nil && m.Capture.ValueString() == "human" {
+		diags.AddAttributeError(path.Root("event_sink"), "Conflicting Event Sink", "event_sink can't be used with capture = \"human\", since events are only reported in terraform's machine-readable output.")
+	}
+	if m.ChangeDiagram.ValueBool() && m.Capture.ValueString() == "human" {
+		diags.AddAttributeError(path.Root("change_diagram"), "Conflicting Change Diagram", "change_diagram can't be used with capture = \"human\", since changes are only reported in terraform's machine-readable output.")
+	}
+	if c := m.Capture; !c.IsNull() && !c.IsUnknown() && !slices.Contains(captureModes, c.ValueString()) {
+		diags.AddAttributeError(path.Root("capture"), "Invalid Capture", fmt.Sprintf("capture must be one of %s, got %q.", strings.Join(captureModes, ", "), c.ValueString()))
+	}
+	for i, p := range m.SuppressWarnings.Elements() {
+		if p, ok := p.(types.String); ok && !p.IsUnknown() && !p.IsNull() {
+			if _, err := regexp.Compile(p.ValueString()); err != nil {
+				diags.AddAttributeError(path.Root("suppress_warnings").AtListIndex(i), "Invalid Warning Pattern", err.Error())
+			}
+		}
+	}
+	return diags
+}
+
+// listKnown reports whether l and all of its elements are known.
+func listKnown(l types.List) bool {
+	if l.IsUnknown() {
+		return false
+	}
+	for _, e := range l.Elements() {
+		if e.IsUnknown() {
+			return false
+		}
+	}
+	return true
 }
 
-func (r *ApplyResource) Configure(context.Context, resource.ConfigureRequest, *resource.ConfigureResponse) {
+func mapKnown(m types.Map) bool {
+	if m.IsUnknown() {
+		return false
+	}
+	for _, e := range m.Elements() {
+		if e.IsUnknown() {
+			return false
+		}
+	}
+	return true
 }
 
-func (r *ApplyResource) doApply(ctx context.Context, data ApplyResourceModel) error {
-	var buf bytes.Buffer
+// doApply runs the nested apply, and returns the output of terraform apply
+// and any warnings about how it was run. It sets plan_artifact_url if the
+// plan is uploaded.
+func (r *ApplyResource) doApply(ctx context.Context, data *ApplyResourceModel) (output string, warnings diag.Diagnostics, err error) {
+	phase := "setup"
+	defer func() {
+		if err != nil {
+			err = &phaseError{Phase: phase, Err: err}
+		}
+	}()
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			err = data.crashed(ctx, http.DefaultClient, start, err)
+		}
+	}()
+	// The logs of the last failure are out of date once an apply succeeds.
+	defer func() {
+		if err == nil {
+			if err := os.RemoveAll(r.outputLogsDir(data)); err != nil {
+				tflog.Warn(ctx, "Unable to remove the logs of failed commands", map[string]interface{}{"error": err.Error()})
+			}
+		}
+	}()
+
+	dir := data.WorkingDir.ValueString()
+	var args []string
+	if diag := data.Args.ElementsAs(ctx, &args, false); diag.HasError() {
+		return "", nil, fmt.Errorf("errors getting args: %v", diag.Errors())
+	}
+	// Variables were set when a saved plan was created.
+	if data.PlanFile.IsNull() {
+		if args, err = r.nestedArgs(ctx, data, args); err != nil {
+			return "", nil, err
+		}
+	}
+
+	// Wait for terraform left running by a provider that died mid-apply, or
+	// by prefetch_providers.
+	if err := r.provider.prefetcher().wait(ctx, dir); err != nil {
+		return "", nil, err
+	}
+	if err := recoverOrphan(ctx, dir, data.WorkspaceName.ValueString()); err != nil {
+		return "", nil, err
+	}
+	if err := checkStateVersion(ctx, data); err != nil {
+		return "", nil, err
+	}
+	if err := writeProvidersOverride(dir, data.ProviderConfig.ValueString(), r.fileMode()); err != nil {
+		return "", nil, err
+	}
+	var overrides map[string]string
+	if diags := data.OverrideFiles.ElementsAs(ctx, &overrides, false); diags.HasError() {
+		return "", nil, fmt.Errorf("errors getting override_files: %v", diags.Errors())
+	}
+	removeOverrides, err := writeOverrideFiles(dir, overrides, r.fileMode())
+	if err != nil {
+		return "", nil, err
+	}
+	defer removeOverrides()
+	interrupted, err := readJournal(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	limits, err := data.ResourceLimits.limits()
+	if err != nil {
+		return "", nil, err
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	data.RunID = types.StringValue(runID)
+	data.commands = &commandLog{phase: &phase}
+	tf, err := r.runner(limits, data, runID)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		var diags diag.Diagnostics
+		data.EffectiveCommands, diags = data.commands.value(ctx)
+		warnings.Append(diags...)
+	}()
+	removeJournal(dir)
+	defer removeJournal(dir)
+	jr := &journal{dir: dir, runID: runID, phase: &phase, mode: r.fileMode()}
+	tf = journalRunner{runner: tf, journal: jr}
 
-	// terraform init
+	var expected *expectedResources
+	if data.Expected != nil {
+		e, diags := data.Expected.expected(ctx)
+		if diags.HasError() {
+			return "", nil, fmt.Errorf("errors getting expected_resources: %v", diags.Errors())
+		}
+		expected = &e
+	}
+	var checks []smokeCheck
 	{
-		cmd := exec.CommandContext(ctx, "terraform", "init")
-		cmd.Dir = data.WorkingDir.ValueString()
-		cmd.Stdout = &buf
-		cmd.Stderr = &buf
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("terraform init failed, got error: %s, output: %s", err, buf.String())
+		var models []ApplyCheckModel
+		if diags := data.Checks.ElementsAs(ctx, &models, false); diags.HasError() {
+			return "", nil, fmt.Errorf("errors getting checks: %v", diags.Errors())
+		}
+		for _, m := range models {
+			c := smokeCheck{Command: m.Command.ValueString()}
+			if diags := m.Interpreter.ElementsAs(ctx, &c.Interpreter, false); diags.HasError() {
+				return "", nil, fmt.Errorf("errors getting checks: %v", diags.Errors())
+			}
+			if len(c.Interpreter) == 0 {
+				c.Interpreter = defaultInterpreter(runtime.GOOS)
+			}
+			checks = append(checks, c)
+		}
+	}
+	var wait *httpWait
+	if data.WaitForHTTP != nil {
+		w, err := data.WaitForHTTP.wait()
+		if err != nil {
+			return "", nil, err
 		}
-		buf.Reset()
+		wait = &w
 	}
 
-	// terraform apply -auto-approve
+	// verify checks that the applied nested state contains the expected
+	// resources, waits for the nested service, and checks that the checks
+	// pass.
+	verify := func() error {
+		if data.absent() {
+			return nil
+		}
+		if expected != nil {
+			phase = "expected_resources"
+			list, err := tf.run(ctx, dir, "state", "list")
+			if err != nil {
+				return err
+			}
+			if err := expected.check(list); err != nil {
+				return err
+			}
+		}
+		if wait == nil && len(checks) == 0 {
+			return nil
+		}
+		phase = "checks"
+		if wait != nil {
+			phase = "wait_for_http"
+		}
+		out, err := tf.run(ctx, dir, "output", "-json")
+		if err != nil {
+			return err
+		}
+		values, sensitive, err := stateOutputs(out)
+		if err != nil {
+			return err
+		}
+		for name, v := range sensitive {
+			values[name] = v
+		}
+		env := outputEnv(values)
+		if wait != nil {
+			phase = "wait_for_http"
+			if err := wait.wait(ctx, http.DefaultClient, expandOutputs(wait.URL, env)); err != nil {
+				return err
+			}
+		}
+		if len(checks) > 0 {
+			phase = "checks"
+			for _, c := range checks {
+				if err := c.run(ctx, dir, env, r.outputLogsDir(data), r.fileMode()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// applyOnly runs terraform apply -auto-approve with args, with -json if
+	// any events are to be captured, and the output options. apply also
+	// verifies the result.
+	var sinkHeaders map[string]string
+	if data.EventSink != nil {
+		if diags := data.EventSink.Headers.ElementsAs(ctx, &sinkHeaders, false); diags.HasError() {
+			return "", nil, fmt.Errorf("errors getting event_sink headers: %v", diags.Errors())
+		}
+		if sinkHeaders == nil {
+			sinkHeaders = map[string]string{}
+		}
+		sinkHeaders[runIDHeader] = runID
+	}
+	applyOnly := func(args ...string) (string, error) {
+		cmd := []string{"apply", "-auto-approve"}
+		if captureJSON(data.Capture.ValueString()) || data.EventSink != nil || data.ChangeDiagram.ValueBool() {
+			cmd = append(cmd, "-json")
+		}
+		if data.CompactWarnings.ValueBool() {
+			cmd = append(cmd, "-compact-warnings")
+		}
+		if data.Concise.ValueBool() {
+			cmd = append(cmd, "-concise")
+		}
+		if p := data.Parallelism; !p.IsNull() {
+			cmd = append(cmd, fmt.Sprintf("-parallelism=%d", p.ValueInt64()))
+		}
+		var out string
+		var err error
+		if s, ok := tf.(streamer); ok && data.EventSink != nil {
+			sink := newEventSink(ctx, http.DefaultClient, data.EventSink.URL.ValueString(), sinkHeaders)
+			out, err = s.stream(ctx, dir, sink, append(cmd, args...)...)
+			sink.Close()
+		} else {
+			out, err = tf.run(ctx, dir, append(cmd, args...)...)
+		}
+		return out, err
+	}
+	apply := func(args ...string) (string, error) {
+		out, err := applyOnly(args...)
+		if err != nil {
+			return out, err
+		}
+		return out, verify()
+	}
+
+	var att *attestation
+	if data.Attestation != nil {
+		att = &attestation{
+			Path:           data.Attestation.Path.ValueString(),
+			Workspace:      data.WorkspaceName.ValueString(),
+			RunID:          runID,
+			SigningKeyFile: data.Attestation.SigningKeyFile.ValueString(),
+			Mode:           r.fileMode(),
+			startedOn:      time.Now(),
+		}
+		// Record the outcome whether or not the apply succeeds.
+		defer func() {
+			if werr := att.write(dir, args, phase, err); werr != nil && err == nil {
+				phase, err = "attestation", werr
+			}
+		}()
+	}
+
+	phase = "templating"
+	if rendered, err := data.Templating.render(ctx, dir, r.nestedEnviron(data), modeOr(r.fileMode(), secretFileMode)); err != nil {
+		return "", nil, err
+	} else if len(rendered) > 0 {
+		tflog.Debug(ctx, "Rendered nested templates", map[string]interface{}{"files": rendered})
+	}
+
+	phase = "init"
+	backend, err := data.backendArgs(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	// terraform init, retrying if the registry rate-limits downloads.
+	initWithRetry := func() (string, error) {
+		return retryRateLimited(ctx, registryBackoff, func() (string, error) {
+			return tf.run(ctx, dir, append([]string{"init"}, backend...)...)
+		})
+	}
+	// With modules_only_update, only update modules if providers are
+	// already installed for the lock file, and the backend is configured
+	// the same way.
+	modulesOnly := data.ModulesOnlyUpdate.ValueBool() && initCurrent(dir, backend)
+	if modulesOnly {
+		if _, err := tf.run(ctx, dir, "get", "-update"); err != nil {
+			return "", nil, err
+		}
+	} else if out, err := initWithRetry(); err != nil {
+		if !data.RepairLockfile.ValueBool() || !lockFileErrorPattern.MatchString(out) {
+			return "", nil, err
+		}
+		// Regenerate the lock file, once.
+		if err := os.Remove(filepath.Join(dir, lockFileName)); err != nil && !os.IsNotExist(err) {
+			return "", nil, fmt.Errorf("Unable to remove %s, got error: %s", lockFileName, err)
+		}
+		if _, err := initWithRetry(); err != nil {
+			return "", nil, err
+		}
+		warnings.AddAttributeWarning(path.Root("repair_lockfile"), "Nested Lock File Regenerated",
+			fmt.Sprintf("terraform init failed because of a problem with %s in %s, so it was deleted and regenerated. Commit the new lock file to keep provider versions pinned.\n\n%s", lockFileName, dir, err))
+	}
+	if !modulesOnly && data.ModulesOnlyUpdate.ValueBool() {
+		if err := recordInit(dir, backend, r.fileMode()); err != nil {
+			tflog.Warn(ctx, "Unable to record terraform init, the next apply will run it again", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	if err := ensureWorkspace(ctx, tf, dir, data.WorkspaceName.ValueString()); err != nil {
+		return "", nil, err
+	}
+
+	// Check the plugin policy after init, so that remote modules have been
+	// downloaded and can be inspected too.
+	phase = "policy"
 	{
-		var args []string
-		if diag := data.Args.ElementsAs(ctx, &args, false); diag.HasError() {
-			return fmt.Errorf("errors getting args: %v", diag.Errors())
+		var policy pluginPolicy
+		if diag := data.AllowedProviders.ElementsAs(ctx, &policy.AllowedProviders, false); diag.HasError() {
+			return "", nil, fmt.Errorf("errors getting allowed_providers: %v", diag.Errors())
+		}
+		if diag := data.DeniedProvisioners.ElementsAs(ctx, &policy.DeniedProvisioners, false); diag.HasError() {
+			return "", nil, fmt.Errorf("errors getting denied_provisioners: %v", diag.Errors())
+		}
+		if err := policy.check(dir); err != nil {
+			return "", nil, err
+		}
+		if !data.AllowRecursion.ValueBool() {
+			if err := checkRecursion(dir); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	// Resume an apply the provider died during by applying the plan it saved,
+	// if it's still there and current. Otherwise, or if the state has changed
+	// since, reconcile with a full refresh, since the nested state may be
+	// partially applied.
+	if interrupted != nil && data.PlanFile.IsNull() {
+		warnings.AddWarning("Resuming Interrupted Nested Apply",
+			fmt.Sprintf("The provider stopped during the %s phase of the nested apply %s in %s, before it finished.", interrupted.Phase, interrupted.RunID, dir))
+		if planFile, planPath := interrupted.resumablePlan(dir); planFile != "" {
+			defer os.Remove(planPath)
+			if att != nil {
+				att.planDigest = interrupted.PlanDigest
+			}
+			phase = "apply"
+			jr.write(ctx, journalEntry{Event: "planned", PlanFile: planFile, PlanDigest: interrupted.PlanDigest})
+			output, err = apply(planFile)
+			if err == nil || !stalePlanPattern.MatchString(output) {
+				return output, warnings, err
+			}
+		}
+		data.skipRefresh = false
+	}
+
+	// terraform apply -auto-approve the given saved plan, after downloading
+	// it if needed and checking it's the one that was expected.
+	if !data.PlanFile.IsNull() {
+		phase = "plan_file"
+		planFile, digest, cleanup, err := resolvePlanFile(ctx, http.DefaultClient, dir, r.provider.tempDir(), data.PlanFile.ValueString(), data.PlanFileHash.ValueString(), r.fileMode())
+		if err != nil {
+			return "", nil, err
+		}
+		defer cleanup()
+		// A saved plan can't be applied twice, so an update for another
+		// reason leaves the nested stack as the plan left it.
+		if digest == data.appliedPlan {
+			warnings.AddWarning("Saved Plan Already Applied",
+				fmt.Sprintf("plan_file %s, with SHA-256 digest %s, was already applied, so it wasn't applied again. Save a new plan to change the nested resources.", data.PlanFile.ValueString(), digest))
+			return "", warnings, nil
+		}
+		data.appliedPlan = digest
+		if att != nil {
+			att.planDigest = digest
+		}
+		if m := data.MaxResources; !m.IsNull() {
+			phase = "max_resources"
+			if err := checkMaxResources(ctx, tf, dir, planFile, m.ValueInt64()); err != nil {
+				return "", nil, err
+			}
+		}
+		phase = "apply"
+		output, err = apply(append(args, planFile)...)
+		return output, warnings, err
+	}
+
+	if data.skipRefresh {
+		args = append([]string{"-refresh=false"}, args...)
+	}
+
+	// Apply each stage's targets in order before the full apply.
+	data.StageResults = types.ListNull(types.ObjectType{AttrTypes: applyStageResultAttrTypes})
+	if !data.Stages.IsNull() {
+		phase = "stages"
+		ss, err := stages(ctx, data.Stages)
+		if err != nil {
+			return "", nil, err
+		}
+		results, err := runStages(ss, func(targets ...string) (string, error) {
+			return applyOnly(append(targets, args...)...)
+		})
+		var diags diag.Diagnostics
+		data.StageResults, diags = stageResultsValue(ctx, results)
+		warnings.Append(diags...)
+		if err != nil {
+			return "", warnings, err
+		}
+	}
+
+	// terraform plan -out, then terraform apply the saved plan, so that the
+	// attestation records, the plan artifact is, the approval is for, and
+	// max_resources is checked against, exactly what was applied.
+	if att != nil || data.PlanArtifact != nil || !data.ApprovalFile.IsNull() || !data.MaxResources.IsNull() {
+		phase = "plan"
+		planFile, err := newPlanFile(dir, r.provider.tempDir(), "pteraform-*.tfplan")
+		if err != nil {
+			return "", nil, fmt.Errorf("Unable to create saved plan, got error: %s", err)
+		}
+		planPath := planFile
+		defer os.Remove(planPath)
+		if _, err := tf.run(ctx, dir, append([]string{"plan", "-out=" + planFile}, args...)...); err != nil {
+			return "", nil, err
+		}
+		if err := chmodFile(planPath, r.fileMode()); err != nil {
+			return "", nil, fmt.Errorf("Unable to set permissions of saved plan, got error: %s", err)
 		}
-		cmd := exec.CommandContext(ctx, "terraform", append([]string{"apply", "-auto-approve"}, args...)...)
-		cmd.Dir = data.WorkingDir.ValueString()
-		cmd.Stdout = &buf
-		cmd.Stderr = &buf
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("terraform apply failed, got error: %s, output: %s", err, buf.String())
+		digest, err := fileDigest(planPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("Unable to read saved plan, got error: %s", err)
 		}
-		buf.Reset()
+		if att != nil {
+			att.planDigest = digest
+		}
+		jr.write(ctx, journalEntry{Event: "planned", PlanFile: planFile, PlanDigest: digest})
+		if m := data.MaxResources; !m.IsNull() {
+			phase = "max_resources"
+			if err := checkMaxResources(ctx, tf, dir, planFile, m.ValueInt64()); err != nil {
+				return "", nil, err
+			}
+		}
+		if data.PlanArtifact != nil {
+			phase = "plan_artifact"
+			url, err := uploadPlan(ctx, http.DefaultClient, planPath, digest, data.PlanArtifact.Destination.ValueString())
+			if err != nil {
+				return "", nil, fmt.Errorf("Unable to upload plan to %s, got error: %s", data.PlanArtifact.Destination.ValueString(), err)
+			}
+			data.PlanArtifactURL = types.StringValue(url)
+		}
+		if !data.ApprovalFile.IsNull() {
+			phase = "approval"
+			timeout, err := approvalTimeout(data.ApprovalTimeout.ValueString())
+			if err != nil {
+				return "", nil, err
+			}
+			ref, err := approvalRef(dir, data.ApprovalFile.ValueString())
+			if err != nil {
+				return "", nil, err
+			}
+			if err := awaitApproval(ctx, http.DefaultClient, ref, pendingApprovalFile(dir, ref), digest, timeout, r.fileMode()); err != nil {
+				return "", nil, err
+			}
+		}
+		phase = "apply"
+		output, err = apply(planFile)
+		return output, warnings, err
 	}
-	return nil
+
+	// terraform apply -auto-approve
+	phase = "apply"
+	output, err = apply(args...)
+	return output, warnings, err
 }
 
 func (r *ApplyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -123,16 +2044,94 @@ func (r *ApplyResource) Create(ctx context.Context, req resource.CreateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	ctx, cancel, diags := data.Timeouts.withTimeout(ctx, "create")
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if err := r.doApply(ctx, data); err != nil {
+	if data.WorkspaceName.IsUnknown() {
+		ws, err := workspaceName(data.Workspace, types.StringNull())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to name workspace, got error: %s", err))
+			return
+		}
+		data.WorkspaceName = types.StringValue(ws)
+	}
+	if data.WorkingDir.IsUnknown() {
+		data.resolveWorkingDir()
+	}
+	data.PlanArtifactURL = types.StringNull()
+	data.Skipped = types.BoolValue(false)
+	resp.Diagnostics.Append(r.setFingerprint(&data)...)
+	output, warnings, err := r.doApply(ctx, &data)
+	resp.Diagnostics.Append(warnings...)
+	resp.Diagnostics.Append(r.maintainCache(ctx, &data)...)
+	resp.Diagnostics.Append(r.recordFingerprint(ctx, &data, resp.Private, err)...)
+	if timedOut := data.Timeouts.timedOut(ctx, "create", err); timedOut.HasError() {
+		resp.Diagnostics.Append(timedOut...)
+	} else if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to run terraform apply, got error: %s", err))
+	} else {
+		resp.Diagnostics.Append(recordHost(ctx, resp.Private, data.WorkingDir.ValueString())...)
+		resp.Diagnostics.Append(recordModuleProviders(ctx, nil, resp.Private, data.WorkingDir.ValueString())...)
+		resp.Diagnostics.Append(r.recordOutputs(ctx, &data, resp.Private)...)
+		resp.Diagnostics.Append(r.recordOutputChanges(ctx, &data, nil, resp.Private)...)
+		resp.Diagnostics.Append(r.publishOutputs(ctx, &data)...)
+		resp.Diagnostics.Append(r.exportState(ctx, &data)...)
+		resp.Diagnostics.Append(recordCLIVersion(ctx, &data, resp.Private)...)
+		resp.Diagnostics.Append(r.setInputManifest(ctx, &data)...)
+		if !data.FullRefreshEvery.IsNull() {
+			resp.Diagnostics.Append(recordFullRefresh(ctx, resp.Private, time.Now())...)
+		}
+		applied := ""
+		if !data.PlanFile.IsNull() {
+			applied = data.appliedPlan
+		}
+		resp.Diagnostics.Append(recordAppliedPlan(ctx, resp.Private, applied)...)
 	}
-
-	id, err := data.ID()
+	if data.PlanHash.IsUnknown() {
+		data.PlanHash = types.StringNull()
+	}
+	if data.InputManifest.IsUnknown() {
+		data.InputManifest = types.StringNull()
+	}
+	if data.RunID.IsUnknown() {
+		data.RunID = types.StringNull()
+	}
+	if data.EffectiveCommands.IsUnknown() {
+		data.EffectiveCommands = types.ListNull(types.ObjectType{AttrTypes: applyEffectiveCommandAttrTypes})
+	}
+	if data.StageResults.IsUnknown() {
+		data.StageResults = types.ListNull(types.ObjectType{AttrTypes: applyStageResultAttrTypes})
+	}
+	if data.OutputsChanged.IsUnknown() {
+		data.OutputsChanged = types.MapNull(types.ObjectType{AttrTypes: applyOutputChangeAttrTypes})
+	}
+	// Nothing is pending after a successful apply; otherwise it's unknown
+	// until the next refresh.
+	data.setPending(planSummary{})
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to get ID, got error: %s", err))
+		data.PendingAdd, data.PendingChange, data.PendingDestroy = types.Int64Null(), types.Int64Null(), types.Int64Null()
+	}
+	data.ConsoleURL = types.StringNull()
+	if u := consoleURL(output); u != "" {
+		data.ConsoleURL = types.StringValue(u)
+	}
+	data.Output = types.StringNull()
+	if c := data.Capture.ValueString(); c != "" && c != "none" {
+		data.Output = types.StringValue(captureEvents(output, c))
+	}
+	data.DiagramMermaid = types.StringNull()
+	if data.ChangeDiagram.ValueBool() {
+		data.DiagramMermaid = types.StringValue(changeDiagram(appliedChanges(output)))
 	}
-	data.Id = basetypes.NewStringValue(id)
+	resp.Diagnostics.Append(data.warn(ctx, output)...)
+	resp.Diagnostics.Append(data.setLastError(ctx, resp.Private, err)...)
+
+	data.states = r.provider.states()
+	resp.Diagnostics.Append(data.refresh(ctx)...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -143,12 +2142,23 @@ func (r *ApplyResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	resp.Diagnostics.Append(data.hostAffinity(ctx, req.Private)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	id, err := data.ID()
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to get ID, got error: %s", err))
+	data.states = r.provider.states()
+	resp.Diagnostics.Append(data.refresh(ctx)...)
+	if data.ReadRunsPlan.ValueBool() {
+		if _, err := os.Stat(data.WorkingDir.ValueString()); err == nil {
+			resp.Diagnostics.Append(r.pendingChanges(ctx, &data)...)
+		}
+	} else {
+		data.setPending(planSummary{})
+	}
+	if _, err := os.Stat(data.WorkingDir.ValueString()); err == nil {
+		resp.Diagnostics.Append(r.checkOutputs(ctx, &data, req.Private, resp.Private)...)
 	}
-	data.Id = basetypes.NewStringValue(id)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -159,16 +2169,127 @@ func (r *ApplyResource) Update(ctx context.Context, req resource.UpdateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	ctx, cancel, diags := data.Timeouts.withTimeout(ctx, "update")
+	defer cancel()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	if err := r.doApply(ctx, data); err != nil {
+	if data.WorkspaceName.IsUnknown() {
+		var state ApplyResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		ws, err := workspaceName(data.Workspace, state.WorkspaceName)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to name workspace, got error: %s", err))
+			return
+		}
+		data.WorkspaceName = types.StringValue(ws)
+	}
+	if data.WorkingDir.IsUnknown() {
+		data.resolveWorkingDir()
+	}
+	resp.Diagnostics.Append(data.hostAffinity(ctx, req.Private)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if e := data.FullRefreshEvery; !e.IsNull() {
+		every, err := time.ParseDuration(e.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("full_refresh_every"), "Invalid Full Refresh Interval", err.Error())
+			return
+		}
+		last, diags := req.Private.GetKey(ctx, lastFullRefreshKey)
+		resp.Diagnostics.Append(diags...)
+		data.skipRefresh = !fullRefreshDue(last, every, time.Now())
+	}
+	if !data.PlanFile.IsNull() {
+		applied, diags := appliedPlan(ctx, req.Private)
+		resp.Diagnostics.Append(diags...)
+		data.appliedPlan = applied
+	}
+	data.PlanArtifactURL = types.StringNull()
+	resp.Diagnostics.Append(r.setFingerprint(&data)...)
+	skipped, diags := r.unchanged(ctx, &data, req.Private)
+	resp.Diagnostics.Append(diags...)
+	data.Skipped = types.BoolValue(skipped)
+	var output string
+	var err error
+	if skipped {
+		tflog.Info(ctx, "Skipping nested apply, nothing it depends on changed since the last apply")
+		data.OutputsChanged = types.MapValueMust(types.ObjectType{AttrTypes: applyOutputChangeAttrTypes}, map[string]attr.Value{})
+	} else {
+		var warnings diag.Diagnostics
+		output, warnings, err = r.doApply(ctx, &data)
+		resp.Diagnostics.Append(warnings...)
+		resp.Diagnostics.Append(r.maintainCache(ctx, &data)...)
+		resp.Diagnostics.Append(r.recordFingerprint(ctx, &data, resp.Private, err)...)
+	}
+	if err == nil {
+		resp.Diagnostics.Append(r.setInputManifest(ctx, &data)...)
+	}
+	if timedOut := data.Timeouts.timedOut(ctx, "update", err); timedOut.HasError() {
+		resp.Diagnostics.Append(timedOut...)
+	} else if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to run terraform apply, got error: %s", err))
+	} else if !skipped {
+		resp.Diagnostics.Append(recordHost(ctx, resp.Private, data.WorkingDir.ValueString())...)
+		resp.Diagnostics.Append(recordModuleProviders(ctx, req.Private, resp.Private, data.WorkingDir.ValueString())...)
+		resp.Diagnostics.Append(r.recordOutputs(ctx, &data, resp.Private)...)
+		resp.Diagnostics.Append(r.recordOutputChanges(ctx, &data, req.Private, resp.Private)...)
+		resp.Diagnostics.Append(r.publishOutputs(ctx, &data)...)
+		resp.Diagnostics.Append(r.exportState(ctx, &data)...)
+		resp.Diagnostics.Append(recordCLIVersion(ctx, &data, resp.Private)...)
+		if !data.FullRefreshEvery.IsNull() && !data.skipRefresh {
+			resp.Diagnostics.Append(recordFullRefresh(ctx, resp.Private, time.Now())...)
+		}
+		applied := ""
+		if !data.PlanFile.IsNull() {
+			applied = data.appliedPlan
+		}
+		resp.Diagnostics.Append(recordAppliedPlan(ctx, resp.Private, applied)...)
 	}
-
-	id, err := data.ID()
+	if data.PlanHash.IsUnknown() {
+		data.PlanHash = types.StringNull()
+	}
+	if data.InputManifest.IsUnknown() {
+		data.InputManifest = types.StringNull()
+	}
+	if data.RunID.IsUnknown() {
+		data.RunID = types.StringNull()
+	}
+	if data.EffectiveCommands.IsUnknown() {
+		data.EffectiveCommands = types.ListNull(types.ObjectType{AttrTypes: applyEffectiveCommandAttrTypes})
+	}
+	if data.StageResults.IsUnknown() {
+		data.StageResults = types.ListNull(types.ObjectType{AttrTypes: applyStageResultAttrTypes})
+	}
+	if data.OutputsChanged.IsUnknown() {
+		data.OutputsChanged = types.MapNull(types.ObjectType{AttrTypes: applyOutputChangeAttrTypes})
+	}
+	// Nothing is pending after a successful apply; otherwise it's unknown
+	// until the next refresh.
+	data.setPending(planSummary{})
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to get ID, got error: %s", err))
+		data.PendingAdd, data.PendingChange, data.PendingDestroy = types.Int64Null(), types.Int64Null(), types.Int64Null()
+	}
+	data.ConsoleURL = types.StringNull()
+	if u := consoleURL(output); u != "" {
+		data.ConsoleURL = types.StringValue(u)
+	}
+	data.Output = types.StringNull()
+	if c := data.Capture.ValueString(); c != "" && c != "none" {
+		data.Output = types.StringValue(captureEvents(output, c))
 	}
-	data.Id = basetypes.NewStringValue(id)
+	data.DiagramMermaid = types.StringNull()
+	if data.ChangeDiagram.ValueBool() {
+		data.DiagramMermaid = types.StringValue(changeDiagram(appliedChanges(output)))
+	}
+	resp.Diagnostics.Append(data.warn(ctx, output)...)
+	resp.Diagnostics.Append(data.setLastError(ctx, resp.Private, err)...)
+
+	data.states = r.provider.states()
+	resp.Diagnostics.Append(data.refresh(ctx)...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -180,6 +2301,25 @@ func (r *ApplyResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 	// Nothing to delete. Run `terraform destroy`? 🤷‍♂️
+
+	if err := os.RemoveAll(r.outputLogsDir(&data)); err != nil {
+		tflog.Warn(ctx, "Unable to remove the logs of failed commands", map[string]interface{}{"error": err.Error()})
+	}
+
+	if data.CleanupOnDelete.ValueBool() {
+		ctx, cancel, diags := data.Timeouts.withTimeout(ctx, "delete")
+		defer cancel()
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		removed, err := cleanup(ctx, data.WorkingDir.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up working directory, got error: %s", err))
+			return
+		}
+		tflog.Debug(ctx, "Cleaned up working directory", map[string]interface{}{"removed": removed})
+	}
 }
 
 func (r *ApplyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
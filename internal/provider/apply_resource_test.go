@@ -6,9 +6,12 @@ package provider
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 )
 
 func TestAccExampleResource(t *testing.T) {
@@ -26,7 +29,8 @@ resource "pteraform_apply" "second" {
 }
 `,
 			Check: resource.ComposeAggregateTestCheckFunc(
-			// TODO: check stuff?
+				resource.TestCheckResourceAttr("pteraform_apply.first", "modules.#", "0"),
+				resource.TestCheckNoResourceAttr("pteraform_apply.first", "last_error.phase"),
 			),
 		}},
 	})
@@ -37,3 +41,174 @@ resource "pteraform_apply" "second" {
 		}
 	}
 }
+
+func TestAccApplyResource_planHash(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			// The test fails if the plan after applying isn't empty, so this
+			// also checks that plan_hash is kept once there are no changes.
+			Config: `
+resource "pteraform_apply" "second" {
+	working_dir       = "testdata/second"
+	args              = ["-var=value=cool"]
+	compute_plan_hash = true
+}
+`,
+			Check: resource.TestMatchResourceAttr("pteraform_apply.second", "plan_hash", regexp.MustCompile(`^[0-9a-f]{64}$`)),
+		}},
+	})
+}
+
+func TestAccApplyResource_autoWorkspace(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+resource "pteraform_apply" "second" {
+	for_each = toset(["a", "b"])
+
+	working_dir = "testdata/second"
+	args        = ["-var=value=${each.key}"]
+	workspace   = "auto"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestMatchResourceAttr(`pteraform_apply.second["a"]`, "workspace_name", regexp.MustCompile(`^pteraform-[0-9a-f]{8}$`)),
+				resource.TestMatchResourceAttr(`pteraform_apply.second["b"]`, "workspace_name", regexp.MustCompile(`^pteraform-[0-9a-f]{8}$`)),
+			),
+		}},
+	})
+}
+
+func TestAccApplyResource_triggers(t *testing.T) {
+	config := func(digest string) string {
+		return `
+resource "pteraform_apply" "second" {
+	working_dir = "testdata/second"
+	args        = ["-var=value=cool"]
+	triggers    = { image_digest = "` + digest + `" }
+}
+`
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: config("sha256:aaaa"),
+		}, {
+			Config: config("sha256:bbbb"),
+			ConfigPlanChecks: resource.ConfigPlanChecks{
+				PreApply: []plancheck.PlanCheck{
+					plancheck.ExpectResourceAction("pteraform_apply.second", plancheck.ResourceActionUpdate),
+				},
+			},
+			Check: resource.TestCheckResourceAttr("pteraform_apply.second", "triggers.image_digest", "sha256:bbbb"),
+		}},
+	})
+}
+
+func TestAccApplyResource_deniedProvisioners(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+resource "pteraform_apply" "first" {
+	working_dir         = "testdata/first"
+	denied_provisioners = ["local-exec"]
+}
+`,
+			ExpectError: regexp.MustCompile(`uses the "local-exec"\s+provisioner`),
+		}},
+	})
+}
+
+func TestAccApplyResource_missingVariable(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+resource "pteraform_apply" "required" {
+	working_dir = "testdata/required"
+}
+`,
+			PlanOnly:    true,
+			ExpectError: regexp.MustCompile(`Missing Nested Variable`),
+		}},
+	})
+}
+
+func TestAccApplyResource_planFileHashMismatch(t *testing.T) {
+	planFile := filepath.Join("testdata", "first", "test.tfplan")
+	t.Cleanup(func() { os.Remove(planFile) })
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+resource "pteraform_apply" "first" {
+	working_dir    = "testdata/first"
+	plan_file      = "test.tfplan"
+	plan_file_hash = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+}
+`,
+			PreConfig: func() {
+				if err := os.WriteFile(planFile, []byte("not the reviewed plan"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ExpectError: regexp.MustCompile(`does not match\s+plan_file_hash`),
+		}, {
+			Config: `
+resource "pteraform_apply" "first" {
+	working_dir    = "testdata/first"
+	plan_file_hash = "abc"
+}
+`,
+			ExpectError: regexp.MustCompile(`Missing Plan File`),
+		}, {
+			Config: `
+resource "pteraform_apply" "first" {
+	working_dir    = "testdata/first"
+	plan_file      = "http://example.com/plans/prod.tfplan"
+	plan_file_hash = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+}
+`,
+			ExpectError: regexp.MustCompile(`Insecure Plan File`),
+		}},
+	})
+}
+
+func TestApplyResourceID(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"terraform.tfstate": `{"version": 4, "serial": 2, "lineage": "abc-123"}`})
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := fileDigest(filepath.Join(dir, "terraform.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for strategy, want := range map[string]string{"": digest, "state_hash": digest, "lineage": "abc-123", "workdir": abs} {
+		m := testApplyModel(dir)
+		m.WorkspaceName = types.StringValue("default")
+		m.Id = types.StringUnknown()
+		if strategy != "" {
+			m.IDStrategy = types.StringValue(strategy)
+		}
+		if got, err := m.ID(); err != nil || got != want {
+			t.Errorf("ID with id_strategy %q = %q, %v, want %q", strategy, got, err, want)
+		}
+	}
+
+	m := testApplyModel(dir)
+	m.IDStrategy = types.StringValue("uuid")
+	m.Id = types.StringUnknown()
+	id, err := m.ID()
+	if err != nil || id == "" {
+		t.Fatalf("ID with id_strategy uuid = %q, %v", id, err)
+	}
+	m.Id = types.StringValue(id)
+	if got, err := m.ID(); err != nil || got != id {
+		t.Errorf("ID with id_strategy uuid changed from %q to %q, %v", id, got, err)
+	}
+}
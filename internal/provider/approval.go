@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultApprovalTimeout is how long an apply waits for approval_file if
+// approval_timeout isn't set.
+const defaultApprovalTimeout = time.Hour
+
+// approvalPollInterval is how often approval_file is checked.
+var approvalPollInterval = 10 * time.Second
+
+// approvalTimeout returns how long to wait for approval, given the
+// approval_timeout attribute, which may be "".
+func approvalTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultApprovalTimeout, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to parse approval_timeout, got error: %s", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("approval_timeout must be positive, got %s", s)
+	}
+	return d, nil
+}
+
+// checkApprovalFile returns an error if the approval_file ref is an http://
+// URL, which anyone on the network could answer with an approval.
+func checkApprovalFile(ref string) error {
+	if strings.HasPrefix(ref, "http://") {
+		return fmt.Errorf("approval_file must be a local path or an https:// URL, got %q", ref)
+	}
+	return nil
+}
+
+// approvalRef returns the approval_file ref of an apply in dir as it's read:
+// URLs as they are, and local paths relative to dir. http:// URLs are
+// rejected, rather than taken for relative paths.
+func approvalRef(dir, ref string) (string, error) {
+	if err := checkApprovalFile(ref); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(ref, "https://") || filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(dir, ref), nil
+}
+
+// pendingApprovalFile returns where the digest of the plan that's waiting
+// for the approval at ref, as returned by approvalRef, is written while it
+// waits: next to a local approval file, with a .pending suffix, or in dir's
+// .terraform directory for a URL.
+func pendingApprovalFile(dir, ref string) string {
+	if strings.HasPrefix(ref, "https://") {
+		return filepath.Join(dir, ".terraform", "pteraform-approval.pending")
+	}
+	return ref + ".pending"
+}
+
+// readApproval returns the contents of the approval at ref, a local path or
+// an https:// URL, or "" if it doesn't exist yet.
+func readApproval(ctx context.Context, client *http.Client, ref string) (string, error) {
+	if err := checkApprovalFile(ref); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(ref, "https://") {
+		b, err := os.ReadFile(ref)
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return string(b), err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	} else if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("got status: %s", resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+// awaitApproval polls the approval at ref until it contains digest, the
+// digest of the saved plan to apply, returning an error if it hasn't within
+// timeout. Errors reading it are retried until then. While it waits, digest
+// is written to pending with permissions mode, so whoever approves the plan
+// can see what to approve.
+func awaitApproval(ctx context.Context, client *http.Client, ref, pending, digest string, timeout time.Duration, mode os.FileMode) error {
+	if err := checkApprovalFile(ref); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := os.MkdirAll(filepath.Dir(pending), 0o755); err != nil {
+		return fmt.Errorf("Unable to write %s, got error: %s", pending, err)
+	}
+	if err := writeFile(pending, []byte(digest+"\n"), modeOr(mode, defaultFileMode)); err != nil {
+		return fmt.Errorf("Unable to write %s, got error: %s", pending, err)
+	}
+	defer os.Remove(pending)
+
+	tflog.Info(ctx, "Waiting for the nested plan to be approved", map[string]interface{}{"approval_file": ref, "pending": pending, "plan_digest": digest})
+	var last string
+	for {
+		content, err := readApproval(ctx, client, ref)
+		switch {
+		case err != nil:
+			last = err.Error()
+		case strings.Contains(content, digest):
+			return nil
+		case content == "":
+			last = "it doesn't exist"
+		default:
+			last = "it doesn't contain the plan digest"
+		}
+		tflog.Debug(ctx, "Waiting for approval", map[string]interface{}{"approval_file": ref, "got": last})
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the plan with digest %s wasn't approved in %s within %s, last %s", digest, ref, timeout, last)
+		case <-time.After(approvalPollInterval):
+		}
+	}
+}
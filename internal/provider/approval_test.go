@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestAwaitApproval(t *testing.T) {
+	defer func(d time.Duration) { approvalPollInterval = d }(approvalPollInterval)
+	approvalPollInterval = time.Millisecond
+
+	t.Run("file", func(t *testing.T) {
+		fn := filepath.Join(t.TempDir(), "approved")
+		pending := pendingApprovalFile(t.TempDir(), fn)
+		go func() {
+			// Approve the digest waiting for approval.
+			for {
+				if b, err := os.ReadFile(pending); err == nil {
+					os.WriteFile(fn, []byte("approved: "+string(b)), 0o644)
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+		if err := awaitApproval(context.Background(), http.DefaultClient, fn, pending, "abc123", time.Second, 0); err != nil {
+			t.Fatal(err)
+		}
+		if pending != fn+".pending" {
+			t.Errorf("pendingApprovalFile(%s) = %s, want %s.pending", fn, pending, fn)
+		}
+		if _, err := os.Stat(pending); !os.IsNotExist(err) {
+			t.Errorf("%s after approval: %v, want it removed", pending, err)
+		}
+	})
+
+	t.Run("url", func(t *testing.T) {
+		polls := 0
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			polls++
+			if polls < 3 {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte("abc123"))
+		}))
+		defer srv.Close()
+		dir := t.TempDir()
+		if err := awaitApproval(context.Background(), srv.Client(), srv.URL, pendingApprovalFile(dir, srv.URL), "abc123", time.Second, 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("insecure url", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("abc123"))
+		}))
+		defer srv.Close()
+		pending := filepath.Join(t.TempDir(), "approval.pending")
+		if err := awaitApproval(context.Background(), srv.Client(), srv.URL, pending, "abc123", 10*time.Millisecond, 0); err == nil || !strings.Contains(err.Error(), "https://") {
+			t.Errorf("awaitApproval of an http:// URL = %v, want error", err)
+		}
+		if _, err := os.Stat(pending); !os.IsNotExist(err) {
+			t.Errorf("%s for an http:// URL: %v, want it not written", pending, err)
+		}
+	})
+
+	t.Run("other plan", func(t *testing.T) {
+		fn := filepath.Join(t.TempDir(), "approved")
+		if err := os.WriteFile(fn, []byte("def456"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := awaitApproval(context.Background(), http.DefaultClient, fn, fn+".pending", "abc123", 10*time.Millisecond, 0)
+		if err == nil || !strings.Contains(err.Error(), "doesn't contain the plan digest") {
+			t.Errorf("got error %v, want one saying the plan wasn't approved", err)
+		}
+	})
+}
+
+func TestApprovalRef(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "approved")
+	for ref, want := range map[string]string{
+		"approved":                          filepath.Join(dir, "approved"),
+		abs:                                 abs,
+		"https://example.com/plan/approved": "https://example.com/plan/approved",
+	} {
+		if got, err := approvalRef(dir, ref); err != nil || got != want {
+			t.Errorf("approvalRef(%q) = %q, %v, want %q", ref, got, err, want)
+		}
+	}
+	if got, err := approvalRef(dir, "http://example.com/plan/approved"); err == nil {
+		t.Errorf("approvalRef of an http:// URL = %q, want error", got)
+	}
+}
+
+func TestDoApplyApproval(t *testing.T) {
+	defer func(d time.Duration) { approvalPollInterval = d }(approvalPollInterval)
+	approvalPollInterval = time.Millisecond
+
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	m := testApplyModel(dir)
+	m.ApprovalFile = types.StringValue(filepath.Join(t.TempDir(), "approved"))
+	m.ApprovalTimeout = types.StringValue("10ms")
+
+	fake := &fakeRunner{}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	_, _, err := r.doApply(context.Background(), &m)
+	if err == nil || newLastError(err).Phase != "approval" {
+		t.Errorf("got error %v, want an approval failure", err)
+	}
+	if len(fake.commands) != 2 || !strings.HasPrefix(fake.commands[1], "plan -out=") {
+		t.Errorf("got commands %q, want the plan saved and not applied", fake.commands)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// managedFlags are the terraform apply options pteraform sets itself, mapped
+// to the attribute that controls each, if any.
+var managedFlags = map[string]string{
+	"-auto-approve":     "",
+	"-chdir":            "root_dir and relative_path",
+	"-compact-warnings": "compact_warnings",
+	"-concise":          "concise",
+	"-destroy":          "desired_state",
+	"-json":             "capture",
+}
+
+// flagName returns the name of the option arg, like -parallelism for
+// --parallelism=4, or "" if it isn't an option.
+func flagName(arg string) string {
+	if !strings.HasPrefix(arg, "-") {
+		return ""
+	}
+	name, _, _ := strings.Cut(arg, "=")
+	return "-" + strings.TrimLeft(name, "-")
+}
+
+// checkArgs checks the args that will be passed to terraform apply, or to
+// terraform plan -out, and returns an error for each that's invalid, keyed
+// by its index. Elements that aren't known yet are given as nil. savedPlan
+// is whether a saved plan is being applied, which can't be given variables.
+func checkArgs(args []*string, savedPlan bool) map[int]error {
+	errs := map[int]error{}
+	refreshOnly := false
+	for _, a := range args {
+		if a != nil && flagName(*a) == "-refresh-only" && !strings.HasSuffix(*a, "=false") {
+			refreshOnly = true
+		}
+	}
+	for i := 0; i < len(args); i++ {
+		if args[i] == nil || flagName(*args[i]) == "" {
+			continue
+		}
+		name := flagName(*args[i])
+		_, value, hasValue := strings.Cut(*args[i], "=")
+		if attr, ok := managedFlags[name]; ok {
+			if attr == "" {
+				errs[i] = fmt.Errorf("%s is always passed by pteraform, so it can't be set in args", name)
+			} else {
+				errs[i] = fmt.Errorf("%s is set by pteraform, so it can't be set in args; use %s instead", name, attr)
+			}
+			continue
+		}
+		// terraform rejects these in refresh-only mode.
+		if refreshOnly && (name == "-replace" || *args[i] == "-refresh=false") {
+			errs[i] = fmt.Errorf("%s can't be used with -refresh-only", *args[i])
+			continue
+		}
+		switch name {
+		case "-var", "-var-file":
+			if savedPlan {
+				errs[i] = fmt.Errorf("%s can't be used with plan_file, whose variables were set when it was planned", name)
+			}
+		case "-target", "-replace":
+			if !hasValue {
+				// terraform also accepts the address as the next argument.
+				if i+1 == len(args) {
+					errs[i] = fmt.Errorf("%s requires a resource address", name)
+					continue
+				}
+				i++
+				if args[i] == nil {
+					continue
+				}
+				value = *args[i]
+			}
+			if err := checkAddress(value, name == "-target"); err != nil {
+				errs[i] = fmt.Errorf("%s: %s", name, err)
+			}
+		}
+	}
+	return errs
+}
+
+// checkAddress checks that addr is a resource instance address, like
+// module.network["a"].aws_vpc.main, or if module is true, that it's either
+// that or a module instance address, like module.network.
+func checkAddress(addr string, module bool) error {
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(addr), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("invalid address %q: %s", addr, diags.Error())
+	}
+	type step struct {
+		name    string
+		indexed bool
+	}
+	var steps []step
+	for _, t := range traversal {
+		switch t := t.(type) {
+		case hcl.TraverseRoot:
+			steps = append(steps, step{name: t.Name})
+		case hcl.TraverseAttr:
+			steps = append(steps, step{name: t.Name})
+		case hcl.TraverseIndex:
+			if steps[len(steps)-1].indexed {
+				return fmt.Errorf("invalid address %q: unexpected index", addr)
+			}
+			steps[len(steps)-1].indexed = true
+		default:
+			return fmt.Errorf("invalid address %q", addr)
+		}
+	}
+
+	for len(steps) >= 2 && steps[0].name == "module" && !steps[0].indexed {
+		steps = steps[2:]
+	}
+	if len(steps) == 0 && module {
+		return nil
+	}
+	if len(steps) == 3 && steps[0].name == "data" && !steps[0].indexed {
+		steps = steps[1:]
+	}
+	if len(steps) == 2 && !steps[0].indexed {
+		return nil
+	}
+	return fmt.Errorf("invalid address %q: expected a resource address like aws_instance.web or module.app.aws_instance.web", addr)
+}
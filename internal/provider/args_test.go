@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckArgs(t *testing.T) {
+	str := func(s string) *string { return &s }
+	for _, c := range []struct {
+		desc      string
+		args      []*string
+		savedPlan bool
+		want      map[int]string
+	}{{
+		desc: "valid",
+		args: []*string{str("-parallelism=4"), str("-target=module.app"), str("-replace"), str(`module.app["a"].aws_instance.web[0]`), str("-var=a=b")},
+	}, {
+		desc: "managed flags",
+		args: []*string{str("-json"), str("--auto-approve"), str("-chdir=sub")},
+		want: map[int]string{0: "use capture instead", 1: "always passed", 2: "use root_dir and relative_path instead"},
+	}, {
+		desc:      "variables with saved plan",
+		args:      []*string{str("-var-file=prod.tfvars")},
+		savedPlan: true,
+		want:      map[int]string{0: "can't be used with plan_file"},
+	}, {
+		desc: "invalid addresses",
+		args: []*string{str("-target=aws_instance"), str("-replace=module.app"), str("-target=data.http[0].x"), str("-target")},
+		want: map[int]string{0: `invalid address "aws_instance"`, 1: `invalid address "module.app"`, 2: "invalid address", 3: "requires a resource address"},
+	}, {
+		desc: "conflicting flags",
+		args: []*string{str("-refresh-only"), str("-replace=aws_instance.web"), str("-refresh=false"), str("-refresh=true")},
+		want: map[int]string{1: "-replace=aws_instance.web can't be used with -refresh-only", 2: "-refresh=false can't be used with -refresh-only"},
+	}, {
+		desc: "refresh-only disabled",
+		args: []*string{str("-refresh-only=false"), str("-replace=aws_instance.web")},
+	}, {
+		desc: "unknown",
+		args: []*string{str("-target"), nil, nil},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			got := checkArgs(c.args, c.savedPlan)
+			if len(got) != len(c.want) {
+				t.Errorf("got %d errors, want %d: %v", len(got), len(c.want), got)
+			}
+			for i, want := range c.want {
+				if err := got[i]; err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("args[%d]: got error %v, want %q", i, err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	inTotoPayloadType   = "application/vnd.in-toto+json"
+	applyPredicateType  = "https://github.com/imjasonh/terraform-provider-pteraform/apply/v1"
+)
+
+// inTotoStatement is an in-toto attestation statement.
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     applyPredicate  `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// applyPredicate records what a nested apply ran and what it produced.
+type applyPredicate struct {
+	WorkingDir string                `json:"workingDir"`
+	RunID      string                `json:"runId,omitempty"`
+	Args       []string              `json:"args,omitempty"`
+	Modules    []moduleManifestEntry `json:"modules"`
+	Providers  []attestedProvider    `json:"providers"`
+	PlanDigest map[string]string     `json:"planDigest,omitempty"`
+	Outcome    string                `json:"outcome"`
+	Failure    *attestedFailure      `json:"failure,omitempty"`
+	StartedOn  time.Time             `json:"startedOn"`
+	FinishedOn time.Time             `json:"finishedOn"`
+}
+
+// attestedFailure is why an apply failed, like last_error but without the
+// diagnostics, which can quote terraform's output and so sensitive values.
+type attestedFailure struct {
+	Phase    string `json:"phase"`
+	ExitCode int    `json:"exitCode"`
+	Class    string `json:"class"`
+}
+
+type attestedProvider struct {
+	Source  string   `json:"source"`
+	Version string   `json:"version"`
+	Hashes  []string `json:"hashes,omitempty"`
+}
+
+// dsseEnvelope is a signed DSSE envelope wrapping an in-toto statement.
+type dsseEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+type dsseSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// attestation collects the details of a nested apply as it runs, and writes
+// them as an in-toto statement when it finishes.
+type attestation struct {
+	Path           string
+	SigningKeyFile string
+	// Workspace is the nested workspace, whose state is recorded.
+	Workspace string
+	// RunID identifies the nested apply.
+	RunID string
+	// Mode is the permissions the attestation is written with, or 0 for
+	// defaultFileMode.
+	Mode os.FileMode
+
+	startedOn  time.Time
+	planDigest string
+}
+
+// write writes an attestation for the apply that ran in dir, whose outcome
+// is described by applyErr, which failed in phase if it isn't nil.
+func (a *attestation) write(dir string, args []string, phase string, applyErr error) error {
+	stmt := inTotoStatement{
+		Type:          inTotoStatementType,
+		PredicateType: applyPredicateType,
+		Subject:       []inTotoSubject{},
+		Predicate: applyPredicate{
+			WorkingDir: dir,
+			RunID:      a.RunID,
+			Args:       args,
+			Modules:    []moduleManifestEntry{},
+			Providers:  []attestedProvider{},
+			Outcome:    "success",
+			StartedOn:  a.startedOn.UTC(),
+			FinishedOn: time.Now().UTC(),
+		},
+	}
+	if applyErr != nil {
+		le := newLastError(&phaseError{Phase: phase, Err: applyErr})
+		stmt.Predicate.Outcome = "failure"
+		stmt.Predicate.Failure = &attestedFailure{Phase: le.Phase, ExitCode: le.ExitCode, Class: le.Class}
+	}
+	if a.planDigest != "" {
+		stmt.Predicate.PlanDigest = map[string]string{"sha256": a.planDigest}
+	}
+	state := statePath(a.Workspace)
+	if digest, err := fileDigest(filepath.Join(dir, state)); err == nil {
+		stmt.Subject = append(stmt.Subject, inTotoSubject{Name: filepath.ToSlash(state), Digest: map[string]string{"sha256": digest}})
+	}
+
+	modules, err := readModulesManifest(dir)
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		if m.Key != "" {
+			stmt.Predicate.Modules = append(stmt.Predicate.Modules, m)
+		}
+	}
+	providers, err := readLockFile(dir)
+	if err != nil {
+		return err
+	}
+	for _, p := range providers {
+		stmt.Predicate.Providers = append(stmt.Predicate.Providers, attestedProvider{Source: p.Source, Version: p.Version, Hashes: p.Hashes})
+	}
+
+	out, err := json.MarshalIndent(stmt, "", "  ")
+	if err != nil {
+		return err
+	}
+	if a.SigningKeyFile != "" {
+		env, err := signStatement(out, a.SigningKeyFile)
+		if err != nil {
+			return err
+		}
+		if out, err = json.MarshalIndent(env, "", "  "); err != nil {
+			return err
+		}
+	}
+	mode := a.Mode
+	if mode == 0 {
+		mode = defaultFileMode
+	}
+	if err := os.WriteFile(a.Path, out, mode); err != nil {
+		return fmt.Errorf("Unable to write attestation, got error: %s", err)
+	}
+	// WriteFile only sets the permissions of new files.
+	if err := chmodFile(a.Path, a.Mode); err != nil {
+		return fmt.Errorf("Unable to set permissions of attestation, got error: %s", err)
+	}
+	return nil
+}
+
+// signStatement wraps payload in a DSSE envelope signed with the PEM-encoded
+// Ed25519 or ECDSA private key in keyFile.
+func signStatement(payload []byte, keyFile string) (*dsseEnvelope, error) {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read signing key, got error: %s", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM-encoded", keyFile)
+	}
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse signing key, got error: %s", err)
+	}
+
+	// DSSE signs the pre-authentication encoding of the payload.
+	pae := []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(inTotoPayloadType), inTotoPayloadType, len(payload), payload))
+	var sig []byte
+	var pub any
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, pae)
+		pub = k.Public()
+	case *ecdsa.PrivateKey:
+		h := sha256.Sum256(pae)
+		if sig, err = k.Sign(rand.Reader, h[:], crypto.SHA256); err != nil {
+			return nil, fmt.Errorf("Unable to sign attestation, got error: %s", err)
+		}
+		pub = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T; use an Ed25519 or ECDSA key", key)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	keyID := sha256.Sum256(der)
+
+	return &dsseEnvelope{
+		PayloadType: inTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []dsseSignature{{
+			KeyID: fmt.Sprintf("%x", keyID),
+			Sig:   base64.StdEncoding.EncodeToString(sig),
+		}},
+	}, nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttestation(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"terraform.tfstate":               `{}`,
+		".terraform/modules/modules.json": `{"Modules":[{"Key":"","Source":"","Dir":"."},{"Key":"child","Source":"./child","Dir":"child"}]}`,
+		lockFileName: `
+provider "registry.terraform.io/hashicorp/null" {
+  version     = "3.2.1"
+  constraints = "~> 3.0"
+  hashes = [
+    "h1:ydA0/SNRVB1o95btfshvYsmxA+jZFRZcvKzZSB+4S1M=",
+  ]
+}
+`,
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		a := &attestation{Path: filepath.Join(t.TempDir(), "att.json"), startedOn: time.Now(), planDigest: "abc"}
+		applyErr := &runError{Command: "apply", ExitCode: 1, Class: failureDiagnostics, Output: "Error: secret value hunter2", Err: errors.New("exit status 1")}
+		if err := a.write(dir, []string{"-var=x=y"}, "apply", applyErr); err != nil {
+			t.Fatal(err)
+		}
+		var stmt inTotoStatement
+		b, err := os.ReadFile(a.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &stmt); err != nil {
+			t.Fatal(err)
+		}
+		if stmt.Type != inTotoStatementType || stmt.PredicateType != applyPredicateType {
+			t.Errorf("unexpected statement types: %s, %s", stmt.Type, stmt.PredicateType)
+		}
+		if len(stmt.Subject) != 1 || stmt.Subject[0].Name != "terraform.tfstate" {
+			t.Errorf("unexpected subject: %+v", stmt.Subject)
+		}
+		p := stmt.Predicate
+		if p.Outcome != "failure" || p.PlanDigest["sha256"] != "abc" {
+			t.Errorf("unexpected outcome: %+v", p)
+		}
+		if want := (attestedFailure{Phase: "apply", ExitCode: 1, Class: string(failureDiagnostics)}); p.Failure == nil || *p.Failure != want {
+			t.Errorf("failure = %+v, want %+v", p.Failure, want)
+		}
+		if strings.Contains(string(b), "hunter2") {
+			t.Errorf("attestation includes terraform's output: %s", b)
+		}
+		if len(p.Modules) != 1 || p.Modules[0].Key != "child" {
+			t.Errorf("unexpected modules: %+v", p.Modules)
+		}
+		if len(p.Providers) != 1 || p.Providers[0].Source != "registry.terraform.io/hashicorp/null" || p.Providers[0].Version != "3.2.1" {
+			t.Errorf("unexpected providers: %+v", p.Providers)
+		}
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyFile := filepath.Join(t.TempDir(), "key.pem")
+		if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		a := &attestation{Path: filepath.Join(t.TempDir(), "att.json"), SigningKeyFile: keyFile, startedOn: time.Now()}
+		if err := a.write(dir, nil, "", nil); err != nil {
+			t.Fatal(err)
+		}
+		var env dsseEnvelope
+		b, err := os.ReadFile(a.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &env); err != nil {
+			t.Fatal(err)
+		}
+		payload, err := base64.StdEncoding.DecodeString(env.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(env.PayloadType), env.PayloadType, len(payload), payload)
+		if !ed25519.Verify(pub, []byte(pae), sig) {
+			t.Error("signature does not verify")
+		}
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// backendConfigArgs returns the terraform init arguments that set the
+// backend configuration from files, in order, and then config, in key
+// order, so settings take precedence over files.
+func backendConfigArgs(files []string, config map[string]string) []string {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	args := make([]string, 0, len(files)+len(keys))
+	for _, fn := range files {
+		args = append(args, "-backend-config="+fn)
+	}
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("-backend-config=%s=%s", k, config[k]))
+	}
+	return args
+}
+
+// backendArgs returns the terraform init arguments that set m's
+// backend_config_files and backend_config.
+func (m *ApplyResourceModel) backendArgs(ctx context.Context) ([]string, error) {
+	var files []string
+	if diags := m.BackendConfigFiles.ElementsAs(ctx, &files, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting backend_config_files: %v", diags.Errors())
+	}
+	var config map[string]string
+	if diags := m.BackendConfig.ElementsAs(ctx, &config, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting backend_config: %v", diags.Errors())
+	}
+	return backendConfigArgs(files, config), nil
+}
+
+// backendDigest returns the hex-encoded SHA-256 digest of m's backend
+// arguments and the contents of its backend_config_files, or "" if it has
+// neither.
+func (m *ApplyResourceModel) backendDigest(ctx context.Context) (string, error) {
+	args, err := m.backendArgs(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", nil
+	}
+	var files []string
+	if diags := m.BackendConfigFiles.ElementsAs(ctx, &files, false); diags.HasError() {
+		return "", fmt.Errorf("errors getting backend_config_files: %v", diags.Errors())
+	}
+	h := sha256.New()
+	for _, a := range args {
+		fmt.Fprintf(h, "arg %s\x00", a)
+	}
+	dir := m.WorkingDir.ValueString()
+	for _, fn := range files {
+		p := fn
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		digest, err := fileDigest(p)
+		if err != nil {
+			return "", fmt.Errorf("unable to read backend configuration file %s, got error: %s", fn, err)
+		}
+		fmt.Fprintf(h, "file %s  %s\x00", digest, fn)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// checkBackendConfigFiles returns an error for each of m's
+// backend_config_files that doesn't exist.
+func checkBackendConfigFiles(m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	dir := m.WorkingDir.ValueString()
+	for i, fn := range m.BackendConfigFiles.Elements() {
+		fn, ok := fn.(types.String)
+		if !ok || fn.IsNull() || fn.IsUnknown() {
+			continue
+		}
+		p := fn.ValueString()
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if _, err := os.Stat(p); err != nil {
+			diags.AddAttributeError(path.Root("backend_config_files").AtListIndex(i), "Missing Backend Configuration File",
+				fmt.Sprintf("Unable to read backend configuration file %s in %s, got error: %s", fn.ValueString(), dir, err))
+		}
+	}
+	return diags
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestBackendConfigArgs(t *testing.T) {
+	got := backendConfigArgs([]string{"b.tfbackend", "a.tfbackend"}, map[string]string{
+		"region": "us-east-1",
+		"bucket": "state",
+	})
+	want := []string{
+		"-backend-config=b.tfbackend",
+		"-backend-config=a.tfbackend",
+		"-backend-config=bucket=state",
+		"-backend-config=region=us-east-1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("backendConfigArgs() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckBackendConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prod.tfbackend"), []byte(`key = "prod"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := &ApplyResourceModel{
+		WorkingDir: types.StringValue(dir),
+		BackendConfigFiles: types.ListValueMust(types.StringType, []attr.Value{
+			types.StringValue("prod.tfbackend"),
+			types.StringUnknown(),
+			types.StringValue("staging.tfbackend"),
+		}),
+	}
+	diags := checkBackendConfigFiles(m)
+	if len(diags) != 1 {
+		t.Fatalf("checkBackendConfigFiles() = %v, want one error", diags)
+	}
+	if got, want := diags[0].Summary(), "Missing Backend Configuration File"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &BackendStateDataSource{}
+var _ datasource.DataSourceWithConfigure = &BackendStateDataSource{}
+
+func NewBackendStateDataSource() datasource.DataSource {
+	return &BackendStateDataSource{}
+}
+
+// BackendStateDataSource defines the data source implementation.
+type BackendStateDataSource struct {
+	provider *providerData
+}
+
+// BackendStateDataSourceModel describes the data source data model.
+type BackendStateDataSourceModel struct {
+	Backend          types.String      `tfsdk:"backend"`
+	Config           map[string]string `tfsdk:"config"`
+	Workspace        types.String      `tfsdk:"workspace"`
+	Outputs          types.Map         `tfsdk:"outputs"`
+	SensitiveOutputs types.Map         `tfsdk:"sensitive_outputs"`
+}
+
+func (d *BackendStateDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+	d.provider = data
+}
+
+func (d *BackendStateDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_backend_state"
+}
+
+func (d *BackendStateDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reads the outputs of a nested configuration's state directly from its backend, such as `s3`, `gcs`, `azurerm` or `http`, without its working directory. `terraform` is run in an empty temporary directory configured with only the backend.",
+
+		Attributes: map[string]schema.Attribute{
+			"backend": schema.StringAttribute{
+				MarkdownDescription: "Backend type, like `s3`.",
+				Required:            true,
+			},
+			"config": schema.MapAttribute{
+				MarkdownDescription: "Backend configuration, passed to `terraform init` as `-backend-config` arguments.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"workspace": schema.StringAttribute{
+				MarkdownDescription: "Workspace to read. Defaults to `default`.",
+				Optional:            true,
+			},
+			"outputs": schema.MapAttribute{
+				MarkdownDescription: "Values of outputs not marked sensitive, keyed by name. Each is JSON-encoded; use `jsondecode` to get its value.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+			"sensitive_outputs": schema.MapAttribute{
+				MarkdownDescription: "Values of outputs marked sensitive, keyed by name and JSON-encoded like `outputs`.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+				Sensitive:           true,
+			},
+		},
+	}
+}
+
+// backendConfig returns a configuration with only a backend of the given
+// type, configured entirely with -backend-config arguments.
+func backendConfig(backend string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"terraform": map[string]interface{}{
+			"backend": map[string]interface{}{backend: map[string]interface{}{}},
+		},
+	})
+}
+
+// backendInitArgs returns the arguments to terraform init with config, in a
+// stable order.
+func backendInitArgs(config map[string]string) []string {
+	return append([]string{"init", "-input=false"}, backendConfigArgs(nil, config)...)
+}
+
+// stateOutputs returns the JSON-encoded values of the outputs in the output
+// of terraform output -json, split by whether they're sensitive.
+func stateOutputs(out string) (map[string]string, map[string]string, error) {
+	var outputs map[string]struct {
+		Sensitive bool            `json:"sensitive"`
+		Value     json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(out), &outputs); err != nil {
+		return nil, nil, fmt.Errorf("Unable to parse terraform output, got error: %s", err)
+	}
+	values, sensitive := map[string]string{}, map[string]string{}
+	for name, o := range outputs {
+		if o.Sensitive {
+			sensitive[name] = string(o.Value)
+		} else {
+			values[name] = string(o.Value)
+		}
+	}
+	return values, sensitive, nil
+}
+
+func (d *BackendStateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data BackendStateDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir, err := os.MkdirTemp(d.provider.tempDir(), "pteraform-backend-")
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create temporary directory, got error: %s", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+	b, err := backendConfig(data.Backend.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to write backend configuration, got error: %s", err))
+		return
+	}
+	if err := writeFile(filepath.Join(dir, "backend.tf.json"), b, modeOr(d.provider.fileMode(), defaultFileMode)); err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to write backend configuration, got error: %s", err))
+		return
+	}
+
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+	tf := terraformRunner{inherit: d.provider.inherit(), env: d.provider.runEnv(runID), fileMode: d.provider.fileMode()}
+	if _, err := tf.run(ctx, dir, backendInitArgs(data.Config)...); err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to initialize %s backend, got error: %s", data.Backend.ValueString(), err))
+		return
+	}
+	if ws := data.Workspace.ValueString(); ws != "" && ws != "default" {
+		if _, err := tf.run(ctx, dir, "workspace", "select", ws); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to select workspace %q, got error: %s", ws, err))
+			return
+		}
+	}
+	out, err := tf.run(ctx, dir, "output", "-json")
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read outputs, got error: %s", err))
+		return
+	}
+	values, sensitive, err := stateOutputs(out)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+
+	var diags diag.Diagnostics
+	data.Outputs, diags = types.MapValueFrom(ctx, types.StringType, values)
+	resp.Diagnostics.Append(diags...)
+	data.SensitiveOutputs, diags = types.MapValueFrom(ctx, types.StringType, sensitive)
+	resp.Diagnostics.Append(diags...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestBackendInitArgs(t *testing.T) {
+	got := backendInitArgs(map[string]string{"region": "us-east-1", "bucket": "state", "key": "nested/terraform.tfstate"})
+	want := []string{"init", "-input=false", "-backend-config=bucket=state", "-backend-config=key=nested/terraform.tfstate", "-backend-config=region=us-east-1"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("backendInitArgs (-want,+got): %s", diff)
+	}
+}
+
+func TestStateOutputs(t *testing.T) {
+	values, sensitive, err := stateOutputs(`{
+  "name": {"sensitive": false, "type": "string", "value": "cool"},
+  "ids": {"sensitive": false, "type": ["list", "string"], "value": ["a", "b"]},
+  "password": {"sensitive": true, "type": "string", "value": "hunter2"}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"name": `"cool"`, "ids": `["a", "b"]`}, values); diff != "" {
+		t.Errorf("outputs (-want,+got): %s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"password": `"hunter2"`}, sensitive); diff != "" {
+		t.Errorf("sensitive_outputs (-want,+got): %s", diff)
+	}
+
+	if _, _, err := stateOutputs("Error: no state"); err == nil {
+		t.Error("expected error parsing invalid output")
+	}
+}
+
+func TestAccBackendStateDataSource(t *testing.T) {
+	state, err := filepath.Abs(filepath.Join("testdata", "backend", "terraform.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: fmt.Sprintf(`
+data "pteraform_backend_state" "local" {
+	backend = "local"
+	config  = { path = %q }
+}
+`, state),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("data.pteraform_backend_state.local", "outputs.name", `"cool"`),
+				resource.TestCheckResourceAttr("data.pteraform_backend_state.local", "sensitive_outputs.password", `"hunter2"`),
+			),
+		}},
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// cacheEntry is a provider version in the plugin cache.
+type cacheEntry struct {
+	// Provider is the provider's source address, like
+	// registry.terraform.io/hashicorp/null.
+	Provider string
+	Version  string
+	Dir      string
+	Size     int64
+	// LastUsed is when a nested run last used the entry, or when it was
+	// installed.
+	LastUsed time.Time
+}
+
+// readCache returns the provider versions in the plugin cache dir, least
+// recently used first. The cache is laid out like
+// <hostname>/<namespace>/<type>/<version>/<os>_<arch>.
+func readCache(dir string) ([]cacheEntry, error) {
+	dirs, err := filepath.Glob(filepath.Join(dir, "*", "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []cacheEntry
+	for _, d := range dirs {
+		fi, err := os.Stat(d)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			continue
+		}
+		rel, err := filepath.Rel(dir, d)
+		if err != nil {
+			return nil, err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		size, err := dirSize(d)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, cacheEntry{
+			Provider: strings.Join(parts[:3], "/"),
+			Version:  parts[3],
+			Dir:      d,
+			Size:     size,
+			LastUsed: fi.ModTime(),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].LastUsed.Before(entries[j].LastUsed) })
+	return entries, nil
+}
+
+// dirSize returns the total size of the files in dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(fn string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// touchCache marks the provider versions locked in the nested configuration
+// in dir as used just now, so they're evicted last.
+func touchCache(cacheDir, dir string, now time.Time) error {
+	locked, err := readLockFile(dir)
+	if err != nil {
+		return err
+	}
+	for _, p := range locked {
+		d := filepath.Join(cacheDir, filepath.FromSlash(p.Source), p.Version)
+		if err := os.Chtimes(d, now, now); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// evictCache removes the least recently used provider versions from the
+// plugin cache dir until it's no larger than max bytes, and returns the
+// entries it removed.
+func evictCache(dir string, max int64) ([]cacheEntry, error) {
+	entries, err := readCache(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read plugin cache, got error: %s", err)
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	var evicted []cacheEntry
+	for _, e := range entries {
+		if size <= max {
+			break
+		}
+		if err := os.RemoveAll(e.Dir); err != nil {
+			return evicted, fmt.Errorf("Unable to evict %s %s from plugin cache, got error: %s", e.Provider, e.Version, err)
+		}
+		size -= e.Size
+		evicted = append(evicted, e)
+	}
+	return evicted, nil
+}
+
+// maintainCache marks the provider versions m's nested configuration uses
+// as used, and evicts others if the plugin cache is too large. Problems are
+// only warned about, since the apply itself has already succeeded or failed.
+func (r *ApplyResource) maintainCache(ctx context.Context, m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if r.provider == nil || r.provider.PluginCacheDir == "" {
+		return diags
+	}
+	if err := touchCache(r.provider.PluginCacheDir, m.WorkingDir.ValueString(), time.Now()); err != nil {
+		diags.AddWarning("Unable to Update Plugin Cache", err.Error())
+	}
+	if r.provider.CacheMaxSize == 0 {
+		return diags
+	}
+	evicted, err := evictCache(r.provider.PluginCacheDir, r.provider.CacheMaxSize)
+	if err != nil {
+		diags.AddWarning("Unable to Evict From Plugin Cache", err.Error())
+	}
+	for _, e := range evicted {
+		tflog.Debug(ctx, "Evicted provider from plugin cache", map[string]interface{}{"provider": e.Provider, "version": e.Version, "size": e.Size})
+	}
+	return diags
+}
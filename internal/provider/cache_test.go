@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvictCache(t *testing.T) {
+	cache := writeFiles(t, map[string]string{
+		"registry.terraform.io/hashicorp/null/3.2.1/linux_amd64/terraform-provider-null":     strings.Repeat("x", 100),
+		"registry.terraform.io/hashicorp/null/3.2.2/linux_amd64/terraform-provider-null":     strings.Repeat("x", 100),
+		"registry.terraform.io/hashicorp/random/3.6.0/linux_amd64/terraform-provider-random": strings.Repeat("x", 100),
+	})
+	base := time.Now().Add(-time.Hour)
+	for i, v := range []string{"null/3.2.1", "null/3.2.2", "random/3.6.0"} {
+		d := filepath.Join(cache, "registry.terraform.io", "hashicorp", filepath.FromSlash(v))
+		if err := os.Chtimes(d, base.Add(time.Duration(i)*time.Minute), base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Using null 3.2.1 makes null 3.2.2 the least recently used.
+	dir := writeFiles(t, map[string]string{lockFileName: `
+provider "registry.terraform.io/hashicorp/null" {
+  version = "3.2.1"
+}
+`})
+	if err := touchCache(cache, dir, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readCache(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Provider+" "+e.Version)
+		if e.Size != 100 {
+			t.Errorf("%s %s size = %d, want 100", e.Provider, e.Version, e.Size)
+		}
+	}
+	if diff := cmp.Diff([]string{
+		"registry.terraform.io/hashicorp/null 3.2.2",
+		"registry.terraform.io/hashicorp/random 3.6.0",
+		"registry.terraform.io/hashicorp/null 3.2.1",
+	}, got); diff != "" {
+		t.Errorf("entries (-want,+got): %s", diff)
+	}
+
+	evicted, err := evictCache(cache, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 1 || evicted[0].Version != "3.2.2" {
+		t.Errorf("evicted %+v, want null 3.2.2", evicted)
+	}
+	if _, err := os.Stat(evicted[0].Dir); !os.IsNotExist(err) {
+		t.Errorf("%s not removed: %v", evicted[0].Dir, err)
+	}
+	if evicted, err := evictCache(cache, 250); err != nil || len(evicted) != 0 {
+		t.Errorf("evictCache under the limit = %+v, %v, want nothing evicted", evicted, err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &CacheUsageDataSource{}
+var _ datasource.DataSourceWithConfigure = &CacheUsageDataSource{}
+
+func NewCacheUsageDataSource() datasource.DataSource {
+	return &CacheUsageDataSource{}
+}
+
+// CacheUsageDataSource defines the data source implementation.
+type CacheUsageDataSource struct {
+	provider *providerData
+}
+
+// CacheUsageDataSourceModel describes the data source data model.
+type CacheUsageDataSourceModel struct {
+	PluginCacheDir types.String           `tfsdk:"plugin_cache_dir"`
+	Size           types.Int64            `tfsdk:"size"`
+	MaxSize        types.Int64            `tfsdk:"max_size"`
+	Providers      []CacheUsageEntryModel `tfsdk:"providers"`
+}
+
+// CacheUsageEntryModel describes a provider version in the plugin cache.
+type CacheUsageEntryModel struct {
+	Provider types.String `tfsdk:"provider"`
+	Version  types.String `tfsdk:"version"`
+	Size     types.Int64  `tfsdk:"size"`
+	LastUsed types.String `tfsdk:"last_used"`
+}
+
+func (d *CacheUsageDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cache_usage"
+}
+
+func (d *CacheUsageDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reports how much the provider's `plugin_cache_dir` holds. Everything is null or empty if it isn't set.",
+
+		Attributes: map[string]schema.Attribute{
+			"plugin_cache_dir": schema.StringAttribute{
+				MarkdownDescription: "The plugin cache directory.",
+				Computed:            true,
+			},
+			"size": schema.Int64Attribute{
+				MarkdownDescription: "Bytes the plugin cache holds.",
+				Computed:            true,
+			},
+			"max_size": schema.Int64Attribute{
+				MarkdownDescription: "Bytes the plugin cache may hold, from the provider's `cache_max_size`, or null if it's unbounded.",
+				Computed:            true,
+			},
+			"providers": schema.ListNestedAttribute{
+				MarkdownDescription: "Provider versions in the plugin cache, least recently used first.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"provider": schema.StringAttribute{
+							MarkdownDescription: "Provider source address, like `registry.terraform.io/hashicorp/null`.",
+							Computed:            true,
+						},
+						"version": schema.StringAttribute{
+							MarkdownDescription: "Provider version.",
+							Computed:            true,
+						},
+						"size": schema.Int64Attribute{
+							MarkdownDescription: "Bytes the version takes up, for all platforms.",
+							Computed:            true,
+						},
+						"last_used": schema.StringAttribute{
+							MarkdownDescription: "When a nested apply last used the version, or it was installed, in RFC 3339 format.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *CacheUsageDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+	d.provider = data
+}
+
+func (d *CacheUsageDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data CacheUsageDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.PluginCacheDir = types.StringNull()
+	data.Size = types.Int64Null()
+	data.MaxSize = types.Int64Null()
+	data.Providers = []CacheUsageEntryModel{}
+	if d.provider != nil && d.provider.PluginCacheDir != "" {
+		entries, err := readCache(d.provider.PluginCacheDir)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read plugin cache, got error: %s", err))
+			return
+		}
+		var size int64
+		for _, e := range entries {
+			size += e.Size
+			data.Providers = append(data.Providers, CacheUsageEntryModel{
+				Provider: types.StringValue(e.Provider),
+				Version:  types.StringValue(e.Version),
+				Size:     types.Int64Value(e.Size),
+				LastUsed: types.StringValue(e.LastUsed.UTC().Format(time.RFC3339)),
+			})
+		}
+		data.PluginCacheDir = types.StringValue(d.provider.PluginCacheDir)
+		data.Size = types.Int64Value(size)
+		if d.provider.CacheMaxSize != 0 {
+			data.MaxSize = types.Int64Value(d.provider.CacheMaxSize)
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccCacheUsageDataSource(t *testing.T) {
+	cache := writeFiles(t, map[string]string{
+		"registry.terraform.io/hashicorp/null/3.2.1/linux_amd64/terraform-provider-null": "provider",
+	})
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: fmt.Sprintf(`
+provider "pteraform" {
+	plugin_cache_dir = %q
+	cache_max_size   = "1MiB"
+}
+
+data "pteraform_cache_usage" "this" {}
+`, cache),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("data.pteraform_cache_usage.this", "size", "8"),
+				resource.TestCheckResourceAttr("data.pteraform_cache_usage.this", "max_size", "1048576"),
+				resource.TestCheckResourceAttr("data.pteraform_cache_usage.this", "providers.#", "1"),
+				resource.TestCheckResourceAttr("data.pteraform_cache_usage.this", "providers.0.provider", "registry.terraform.io/hashicorp/null"),
+				resource.TestCheckResourceAttr("data.pteraform_cache_usage.this", "providers.0.version", "3.2.1"),
+			),
+		}},
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// captureModes are the valid values of capture.
+var captureModes = []string{"none", "human", "errors", "warnings", "all"}
+
+// captureJSON reports whether capturing in mode needs terraform's JSON
+// output.
+func captureJSON(mode string) bool {
+	return mode != "" && mode != "none" && mode != "human"
+}
+
+// event is a line of terraform's machine-readable UI output, as written with
+// -json.
+type event struct {
+	Level      string `json:"@level"`
+	Type       string `json:"type"`
+	Diagnostic struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+	} `json:"diagnostic"`
+
+	// line is the event as terraform wrote it.
+	line string
+}
+
+// parseEvents parses the JSON events in terraform output, skipping any lines
+// that aren't events.
+func parseEvents(output string) []event {
+	var events []event
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal([]byte(line), &e); err != nil || e.Level == "" {
+			continue
+		}
+		e.line = line
+		events = append(events, e)
+	}
+	return events
+}
+
+// captureEvents returns the events in terraform output retained by mode, one
+// per line, or the whole output for human.
+func captureEvents(output, mode string) string {
+	var keep func(level string) bool
+	switch mode {
+	case "human":
+		return output
+	case "errors":
+		keep = func(level string) bool { return level == "error" }
+	case "warnings":
+		keep = func(level string) bool { return level == "error" || level == "warn" }
+	case "all":
+		keep = func(string) bool { return true }
+	default:
+		return ""
+	}
+	var b strings.Builder
+	for _, e := range parseEvents(output) {
+		if keep(e.Level) {
+			b.WriteString(e.line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
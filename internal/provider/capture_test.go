@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "testing"
+
+func TestCaptureEvents(t *testing.T) {
+	output := `{"@level":"info","@message":"Terraform 1.6.0","type":"version"}
+{"@level":"warn","@message":"Warning: Deprecated","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated"}}
+not an event
+{"@level":"error","@message":"Error: Invalid reference","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid reference"}}
+`
+	info := `{"@level":"info","@message":"Terraform 1.6.0","type":"version"}` + "\n"
+	warn := `{"@level":"warn","@message":"Warning: Deprecated","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated"}}` + "\n"
+	errs := `{"@level":"error","@message":"Error: Invalid reference","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid reference"}}` + "\n"
+
+	for mode, want := range map[string]string{
+		"":         "",
+		"none":     "",
+		"human":    output,
+		"errors":   errs,
+		"warnings": warn + errs,
+		"all":      info + warn + errs,
+	} {
+		if got := captureEvents(output, mode); got != want {
+			t.Errorf("captureEvents(%q) = %q, want %q", mode, got, want)
+		}
+	}
+}
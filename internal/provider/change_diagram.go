@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// appliedChange is a resource instance changed by terraform apply.
+type appliedChange struct {
+	Addr   string
+	Module string
+	Action string
+}
+
+// appliedChanges returns the resource instances changed in terraform apply
+// -json output, from its apply_complete events, in the order they finished.
+func appliedChanges(output string) []appliedChange {
+	var changes []appliedChange
+	for _, e := range parseEvents(output) {
+		if e.Type != "apply_complete" {
+			continue
+		}
+		var complete struct {
+			Hook struct {
+				Resource struct {
+					Addr   string `json:"addr"`
+					Module string `json:"module"`
+				} `json:"resource"`
+				Action string `json:"action"`
+			} `json:"hook"`
+		}
+		if json.Unmarshal([]byte(e.line), &complete) != nil || complete.Hook.Resource.Addr == "" {
+			continue
+		}
+		changes = append(changes, appliedChange{
+			Addr:   complete.Hook.Resource.Addr,
+			Module: complete.Hook.Resource.Module,
+			Action: complete.Hook.Action,
+		})
+	}
+	return changes
+}
+
+// diagramActionStyles are the Mermaid styles of the nodes of resources
+// changed by each action.
+var diagramActionStyles = []struct{ action, style string }{
+	{"create", "fill:#d3f9d8,stroke:#2b8a3e"},
+	{"update", "fill:#fff3bf,stroke:#e67700"},
+	{"replace", "fill:#ffe8cc,stroke:#d9480f"},
+	{"delete", "fill:#ffe3e3,stroke:#c92a2a"},
+}
+
+// mermaidLabel quotes s as a Mermaid node label. Quotes, like those of
+// for_each keys, are written as entities.
+func mermaidLabel(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
+
+// changeDiagram renders changes as a Mermaid flowchart, with a subgraph for
+// each module and nodes styled by the action applied to them.
+func changeDiagram(changes []appliedChange) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	if len(changes) == 0 {
+		b.WriteString("  none[\"No changes\"]\n")
+		return b.String()
+	}
+
+	byModule := map[string][]int{}
+	var modules []string
+	for i, c := range changes {
+		if _, ok := byModule[c.Module]; !ok {
+			modules = append(modules, c.Module)
+		}
+		byModule[c.Module] = append(byModule[c.Module], i)
+	}
+	// The root module, "", sorts first.
+	sort.Strings(modules)
+	for m, module := range modules {
+		indent := "  "
+		if module != "" {
+			fmt.Fprintf(&b, "  subgraph m%d[%s]\n", m, mermaidLabel(module))
+			indent = "    "
+		}
+		for _, i := range byModule[module] {
+			c := changes[i]
+			addr := strings.TrimPrefix(c.Addr, module+".")
+			fmt.Fprintf(&b, "%sr%d[%s]", indent, i, mermaidLabel(addr))
+			for _, s := range diagramActionStyles {
+				if s.action == c.Action {
+					fmt.Fprintf(&b, ":::%s", c.Action)
+				}
+			}
+			b.WriteByte('\n')
+		}
+		if module != "" {
+			b.WriteString("  end\n")
+		}
+	}
+	for _, s := range diagramActionStyles {
+		fmt.Fprintf(&b, "  classDef %s %s\n", s.action, s.style)
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangeDiagram(t *testing.T) {
+	output := `{"@level":"info","@message":"Apply complete","type":"apply_start","hook":{"resource":{"addr":"null_resource.a","module":""},"action":"create"}}
+{"@level":"info","@message":"null_resource.a: Creation complete","type":"apply_complete","hook":{"resource":{"addr":"null_resource.a","module":""},"action":"create"}}
+{"@level":"info","@message":"module.vpc.aws_subnet.s[\"a\"]: Destruction complete","type":"apply_complete","hook":{"resource":{"addr":"module.vpc.aws_subnet.s[\"a\"]","module":"module.vpc"},"action":"delete"}}
+{"@level":"info","@message":"data.x.y: Read complete","type":"apply_complete","hook":{"resource":{"addr":"data.x.y","module":""},"action":"read"}}
+not json
+{"@level":"info","@message":"Apply complete! Resources: 1 added, 0 changed, 1 destroyed.","type":"change_summary"}
+`
+	want := `flowchart LR
+  r0["null_resource.a"]:::create
+  r2["data.x.y"]
+  subgraph m1["module.vpc"]
+    r1["aws_subnet.s[#quot;a#quot;]"]:::delete
+  end
+  classDef create fill:#d3f9d8,stroke:#2b8a3e
+  classDef update fill:#fff3bf,stroke:#e67700
+  classDef replace fill:#ffe8cc,stroke:#d9480f
+  classDef delete fill:#ffe3e3,stroke:#c92a2a
+`
+	if diff := cmp.Diff(want, changeDiagram(appliedChanges(output))); diff != "" {
+		t.Errorf("changeDiagram (-want +got):\n%s", diff)
+	}
+
+	if got, want := changeDiagram(nil), "flowchart LR\n  none[\"No changes\"]\n"; got != want {
+		t.Errorf("changeDiagram(nil) = %q, want %q", got, want)
+	}
+}
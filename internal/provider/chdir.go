@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// chdirArgs returns the directory to run terraform in and the arguments to
+// run it with, so that it runs the configuration in dir. If root is set,
+// terraform is run in root with -chdir pointing at dir, which must be inside
+// it; otherwise it's run in dir.
+func chdirArgs(root, dir string, args []string) (string, []string, error) {
+	if root == "" {
+		return dir, args, nil
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", nil, fmt.Errorf("%s is not inside %s", dir, root)
+	}
+	return root, append([]string{"-chdir=" + rel}, args...), nil
+}
+
+// resolveWorkingDir sets working_dir from root_dir and relative_path if they
+// are set and known.
+func (m *ApplyResourceModel) resolveWorkingDir() {
+	if m.RootDir.IsNull() || m.RootDir.IsUnknown() || m.RelativePath.IsUnknown() {
+		return
+	}
+	m.WorkingDir = types.StringValue(filepath.Join(m.RootDir.ValueString(), m.RelativePath.ValueString()))
+}
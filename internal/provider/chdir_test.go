@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestChdirArgs(t *testing.T) {
+	root := filepath.Join("repo")
+	for _, c := range []struct {
+		root, dir string
+		wantDir   string
+		wantArgs  []string
+		wantErr   bool
+	}{
+		{"", filepath.Join(root, "stacks", "prod"), filepath.Join(root, "stacks", "prod"), []string{"apply"}, false},
+		{root, filepath.Join(root, "stacks", "prod"), root, []string{"-chdir=" + filepath.Join("stacks", "prod"), "apply"}, false},
+		{root, filepath.Join("elsewhere"), "", nil, true},
+	} {
+		dir, args, err := chdirArgs(c.root, c.dir, []string{"apply"})
+		if (err != nil) != c.wantErr {
+			t.Errorf("chdirArgs(%q, %q) error = %v, want error %t", c.root, c.dir, err, c.wantErr)
+			continue
+		}
+		if dir != c.wantDir {
+			t.Errorf("chdirArgs(%q, %q) dir = %q, want %q", c.root, c.dir, dir, c.wantDir)
+		}
+		if diff := cmp.Diff(c.wantArgs, args); diff != "" {
+			t.Errorf("chdirArgs(%q, %q) args (-want,+got): %s", c.root, c.dir, diff)
+		}
+	}
+}
+
+func TestResolveWorkingDir(t *testing.T) {
+	m := ApplyResourceModel{
+		WorkingDir:   types.StringUnknown(),
+		RootDir:      types.StringValue("repo"),
+		RelativePath: types.StringValue("stacks/prod"),
+	}
+	m.resolveWorkingDir()
+	if want := filepath.Join("repo", "stacks", "prod"); m.WorkingDir.ValueString() != want {
+		t.Errorf("working_dir = %s, want %q", m.WorkingDir, want)
+	}
+
+	m = ApplyResourceModel{
+		WorkingDir:   types.StringUnknown(),
+		RootDir:      types.StringUnknown(),
+		RelativePath: types.StringValue("stacks/prod"),
+	}
+	m.resolveWorkingDir()
+	if !m.WorkingDir.IsUnknown() {
+		t.Errorf("working_dir = %s, want unknown", m.WorkingDir)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cleanupPatterns match the artifacts nested runs leave in their working
+// directory, relative to it: the data directory, with installed modules and
+// providers and saved plans, and crash logs. State is never included.
+var cleanupPatterns = []string{".terraform", "crash.log", "crash.*.log"}
+
+// cleanup removes the artifacts nested runs left in dir, after waiting for
+// any still running in any workspace, and returns what it removed.
+func cleanup(ctx context.Context, dir string) ([]string, error) {
+	if err := recoverOrphans(ctx, dir); err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, p := range cleanupPatterns {
+		matches, err := filepath.Glob(filepath.Join(dir, p))
+		if err != nil {
+			return removed, err
+		}
+		for _, fn := range matches {
+			if err := os.RemoveAll(fn); err != nil {
+				return removed, fmt.Errorf("Unable to remove %s, got error: %s", fn, err)
+			}
+			removed = append(removed, fn)
+		}
+	}
+	return removed, nil
+}
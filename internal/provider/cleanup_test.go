@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCleanup(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf":           "",
+		"terraform.tfstate": "{}",
+		"terraform.tfstate.d/staging/terraform.tfstate": "{}",
+		".terraform.lock.hcl":                           "",
+		".terraform/modules/modules.json":               "{}",
+		".terraform/pteraform.tfplan":                   "",
+		"crash.log":                                     "",
+		"crash.20240102.log":                            "",
+	})
+
+	removed, err := cleanup(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, ".terraform"), filepath.Join(dir, "crash.log"), filepath.Join(dir, "crash.20240102.log")}
+	if diff := cmp.Diff(want, removed); diff != "" {
+		t.Errorf("removed (-want,+got): %s", diff)
+	}
+	for _, fn := range []string{"main.tf", "terraform.tfstate", "terraform.tfstate.d/staging/terraform.tfstate", ".terraform.lock.hcl"} {
+		if _, err := os.Stat(filepath.Join(dir, fn)); err != nil {
+			t.Errorf("%s: %v", fn, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// terraformCLI describes the terraform binary that runs in a directory.
+type terraformCLI struct {
+	// Path is where terraform was found on PATH.
+	Path string
+	// ResolvedPath is the binary that actually runs, after following
+	// symlinks and any version manager shim.
+	ResolvedPath string
+	// Shim is the version manager whose shim Path is, if any.
+	Shim string
+	// Version is the version reported by the resolved binary.
+	Version string
+}
+
+// shimDirs maps path fragments of version manager shim directories to the
+// name of the version manager.
+var shimDirs = map[string]string{
+	"/.asdf/shims/": "asdf",
+	"/mise/shims/":  "mise",
+	"/.tfenv/bin/":  "tfenv",
+}
+
+// detectShim returns the version manager whose shim is at path, or "".
+func detectShim(path string) string {
+	p := filepath.ToSlash(path)
+	for frag, name := range shimDirs {
+		if strings.Contains(p, frag) {
+			return name
+		}
+	}
+	return ""
+}
+
+// resolveTerraformCLI finds the terraform binary that would run in dir.
+//
+// Version managers pick the version from files like .tool-versions in the
+// working directory, so the shim is asked which binary it would run there
+// where it supports that.
+func resolveTerraformCLI(ctx context.Context, dir string) (*terraformCLI, error) {
+	path, err := exec.LookPath(terraformBinary(runtime.GOOS))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find terraform, got error: %s", err)
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	cli := &terraformCLI{Path: path, ResolvedPath: path, Shim: detectShim(path)}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		cli.ResolvedPath = resolved
+		if cli.Shim == "" {
+			cli.Shim = detectShim(resolved)
+		}
+	}
+
+	switch cli.Shim {
+	case "asdf", "mise":
+		cmd := exec.CommandContext(ctx, cli.Shim, "which", "terraform")
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to resolve terraform with %s, got error: %s", cli.Shim, err)
+		}
+		cli.ResolvedPath = strings.TrimSpace(string(out))
+	}
+
+	cmd := exec.CommandContext(ctx, cli.ResolvedPath, "version", "-json")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to run %s version, got error: %s", cli.ResolvedPath, err)
+	}
+	var v struct {
+		TerraformVersion string `json:"terraform_version"`
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s version, got error: %s", cli.ResolvedPath, err)
+	}
+	cli.Version = v.TerraformVersion
+	return cli, nil
+}
@@ -0,0 +1,20 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "testing"
+
+func TestDetectShim(t *testing.T) {
+	for path, want := range map[string]string{
+		"/home/me/.asdf/shims/terraform":              "asdf",
+		"/home/me/.local/share/mise/shims/terraform":  "mise",
+		"/home/me/.tfenv/bin/terraform":               "tfenv",
+		"/usr/local/bin/terraform":                    "",
+		"/home/me/.asdf/installs/terraform/1.6.0/bin": "",
+	} {
+		if got := detectShim(path); got != want {
+			t.Errorf("detectShim(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
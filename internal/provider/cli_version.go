@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// cliVersionKey is the private state key the version of terraform that ran
+// the last successful apply is stored under.
+const cliVersionKey = "cli_version"
+
+// cliVersion returns the version of terraform that runs m's nested
+// configuration: its terraform_version, or the version of the terraform
+// found on PATH in its working directory.
+func (m *ApplyResourceModel) cliVersion(ctx context.Context) (string, error) {
+	if v := m.TerraformVersion.ValueString(); v != "" {
+		return v, nil
+	}
+	cli, err := resolveTerraformCLI(ctx, m.WorkingDir.ValueString())
+	if err != nil {
+		return "", err
+	}
+	return cli.Version, nil
+}
+
+// minorVersionChanged reports whether the major or minor versions of last
+// and now differ. Versions that can't be parsed are never different.
+func minorVersionChanged(last, now string) bool {
+	l, err := version.NewVersion(last)
+	if err != nil {
+		return false
+	}
+	n, err := version.NewVersion(now)
+	if err != nil {
+		return false
+	}
+	ls, ns := l.Segments(), n.Segments()
+	return ls[0] != ns[0] || ls[1] != ns[1]
+}
+
+// cliVersionDiags returns the diagnostics of planning with terraform now
+// when last was used for the last apply: a warning if the minor version
+// changed, unless allow_version_change is set, or an error if it's false.
+func cliVersionDiags(last, now string, allow types.Bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if last == "" || !minorVersionChanged(last, now) || allow.ValueBool() {
+		return diags
+	}
+	detail := fmt.Sprintf("The nested configuration was last applied with terraform %s, but terraform %s would apply it now. A new minor version may upgrade the nested state so older versions can't read it, or change how the configuration is planned.", last, now)
+	if !allow.IsNull() {
+		diags.AddAttributeError(path.Root("allow_version_change"), "Terraform Version Changed", detail+" Set allow_version_change to true to apply it anyway.")
+		return diags
+	}
+	diags.AddWarning("Terraform Version Changed", detail+" Set allow_version_change to true to silence this warning, or false to refuse such changes.")
+	return diags
+}
+
+// checkCLIVersion compares the version of terraform that would apply m with
+// that of the last apply.
+func checkCLIVersion(ctx context.Context, m *ApplyResourceModel, prior privateStateReader) diag.Diagnostics {
+	if m.AllowVersionChange.ValueBool() {
+		return nil
+	}
+	b, diags := prior.GetKey(ctx, cliVersionKey)
+	var last string
+	if diags.HasError() || len(b) == 0 || json.Unmarshal(b, &last) != nil || last == "" {
+		return diags
+	}
+	now, err := m.cliVersion(ctx)
+	if err != nil {
+		// Reported when applying.
+		return diags
+	}
+	diags.Append(cliVersionDiags(last, now, m.AllowVersionChange)...)
+	return diags
+}
+
+// recordCLIVersion stores the version of terraform that ran an apply.
+func recordCLIVersion(ctx context.Context, m *ApplyResourceModel, private privateState) diag.Diagnostics {
+	var diags diag.Diagnostics
+	v, err := m.cliVersion(ctx)
+	if err != nil {
+		diags.AddWarning("Unable to Record Terraform Version", fmt.Sprintf("Changes to the version of terraform won't be detected until the next apply: %s", err))
+		return diags
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record terraform version, got error: %s", err))
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, cliVersionKey, b)...)
+	return diags
+}
@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCLIVersionDiags(t *testing.T) {
+	for _, c := range []struct {
+		last, now        string
+		allow            types.Bool
+		warnings, errors int
+	}{
+		{last: "1.6.0", now: "1.6.5", allow: types.BoolNull()},
+		{last: "1.6.0", now: "1.7.0", allow: types.BoolNull(), warnings: 1},
+		{last: "1.9.8", now: "2.0.0", allow: types.BoolNull(), warnings: 1},
+		{last: "1.7.0", now: "1.6.0", allow: types.BoolNull(), warnings: 1},
+		{last: "1.6.0", now: "1.7.0", allow: types.BoolValue(false), errors: 1},
+		{last: "1.6.0", now: "1.7.0", allow: types.BoolValue(true)},
+		{last: "", now: "1.7.0", allow: types.BoolNull()},
+		{last: "1.6.0", now: "not a version", allow: types.BoolNull()},
+	} {
+		diags := cliVersionDiags(c.last, c.now, c.allow)
+		if diags.WarningsCount() != c.warnings || diags.ErrorsCount() != c.errors {
+			t.Errorf("cliVersionDiags(%q, %q, %v) = %v, want %d warnings and %d errors", c.last, c.now, c.allow, diags, c.warnings, c.errors)
+		}
+	}
+}
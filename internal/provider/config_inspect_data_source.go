@@ -0,0 +1,193 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ConfigInspectDataSource{}
+
+func NewConfigInspectDataSource() datasource.DataSource {
+	return &ConfigInspectDataSource{}
+}
+
+// ConfigInspectDataSource defines the data source implementation.
+type ConfigInspectDataSource struct{}
+
+// ConfigInspectDataSourceModel describes the data source data model.
+type ConfigInspectDataSourceModel struct {
+	WorkingDir        types.String                          `tfsdk:"working_dir"`
+	RequiredVersion   []string                              `tfsdk:"required_version"`
+	RequiredProviders map[string]ConfigInspectProviderModel `tfsdk:"required_providers"`
+	Variables         map[string]ConfigInspectVariableModel `tfsdk:"variables"`
+	RequiredVariables []string                              `tfsdk:"required_variables"`
+	Outputs           map[string]ConfigInspectOutputModel   `tfsdk:"outputs"`
+}
+
+// ConfigInspectProviderModel describes an entry in required_providers.
+type ConfigInspectProviderModel struct {
+	Source             types.String `tfsdk:"source"`
+	VersionConstraints []string     `tfsdk:"version_constraints"`
+}
+
+// ConfigInspectVariableModel describes a declared variable.
+type ConfigInspectVariableModel struct {
+	Type        types.String `tfsdk:"type"`
+	Description types.String `tfsdk:"description"`
+	Required    types.Bool   `tfsdk:"required"`
+	Sensitive   types.Bool   `tfsdk:"sensitive"`
+}
+
+// ConfigInspectOutputModel describes a declared output.
+type ConfigInspectOutputModel struct {
+	Description types.String `tfsdk:"description"`
+	Sensitive   types.Bool   `tfsdk:"sensitive"`
+}
+
+func (d *ConfigInspectDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_config_inspect"
+}
+
+func (d *ConfigInspectDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Statically inspects a Terraform module directory without running `terraform`, exposing its declared variables, outputs, and requirements.",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Module directory to inspect.",
+				Required:            true,
+			},
+			"required_version": schema.ListAttribute{
+				MarkdownDescription: "Terraform version constraints from `required_version` settings.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+			"required_providers": schema.MapNestedAttribute{
+				MarkdownDescription: "Entries in `required_providers`, keyed by local name.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"source": schema.StringAttribute{
+							MarkdownDescription: "Provider source address, or null if not given.",
+							Computed:            true,
+						},
+						"version_constraints": schema.ListAttribute{
+							MarkdownDescription: "Provider version constraints.",
+							ElementType:         basetypes.StringType{},
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"variables": schema.MapNestedAttribute{
+				MarkdownDescription: "Declared input variables, keyed by name.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"type": schema.StringAttribute{
+							MarkdownDescription: "Type constraint as written, or null if none.",
+							Computed:            true,
+						},
+						"description": schema.StringAttribute{
+							MarkdownDescription: "Variable description.",
+							Computed:            true,
+						},
+						"required": schema.BoolAttribute{
+							MarkdownDescription: "Whether the variable has no default and must be supplied.",
+							Computed:            true,
+						},
+						"sensitive": schema.BoolAttribute{
+							MarkdownDescription: "Whether the variable is marked sensitive.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"required_variables": schema.ListAttribute{
+				MarkdownDescription: "Sorted names of variables that have no default and must be supplied.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+			"outputs": schema.MapNestedAttribute{
+				MarkdownDescription: "Declared outputs, keyed by name.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"description": schema.StringAttribute{
+							MarkdownDescription: "Output description.",
+							Computed:            true,
+						},
+						"sensitive": schema.BoolAttribute{
+							MarkdownDescription: "Whether the output is marked sensitive.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *ConfigInspectDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ConfigInspectDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	mod, err := loadModule(data.WorkingDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+
+	data.RequiredVersion = append([]string{}, mod.RequiredVersion...)
+	data.RequiredProviders = map[string]ConfigInspectProviderModel{}
+	for name, req := range mod.RequiredProviders {
+		p := ConfigInspectProviderModel{
+			Source:             types.StringNull(),
+			VersionConstraints: append([]string{}, req.VersionConstraints...),
+		}
+		if req.Source != "" {
+			p.Source = types.StringValue(req.Source)
+		}
+		data.RequiredProviders[name] = p
+	}
+	data.Variables = map[string]ConfigInspectVariableModel{}
+	data.RequiredVariables = []string{}
+	for name, v := range mod.Variables {
+		m := ConfigInspectVariableModel{
+			Type:        types.StringNull(),
+			Description: types.StringValue(v.Description),
+			Required:    types.BoolValue(v.Required()),
+			Sensitive:   types.BoolValue(v.Sensitive),
+		}
+		if v.Type != "" {
+			m.Type = types.StringValue(v.Type)
+		}
+		data.Variables[name] = m
+		if v.Required() {
+			data.RequiredVariables = append(data.RequiredVariables, name)
+		}
+	}
+	sort.Strings(data.RequiredVariables)
+	data.Outputs = map[string]ConfigInspectOutputModel{}
+	for name, o := range mod.Outputs {
+		data.Outputs[name] = ConfigInspectOutputModel{
+			Description: types.StringValue(o.Description),
+			Sensitive:   types.BoolValue(o.Sensitive),
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
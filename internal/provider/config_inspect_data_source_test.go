@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccConfigInspectDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+data "pteraform_config_inspect" "second" {
+	working_dir = "testdata/second"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("data.pteraform_config_inspect.second", "variables.value.type", "string"),
+				resource.TestCheckResourceAttr("data.pteraform_config_inspect.second", "variables.value.required", "false"),
+				resource.TestCheckResourceAttr("data.pteraform_config_inspect.second", "required_variables.#", "0"),
+				resource.TestCheckResourceAttr("data.pteraform_config_inspect.second", "outputs.%", "0"),
+			),
+		}},
+	})
+}
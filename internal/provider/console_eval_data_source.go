@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ConsoleEvalDataSource{}
+
+func NewConsoleEvalDataSource() datasource.DataSource {
+	return &ConsoleEvalDataSource{}
+}
+
+// ConsoleEvalDataSource defines the data source implementation.
+type ConsoleEvalDataSource struct {
+	provider *providerData
+}
+
+// ConsoleEvalDataSourceModel describes the data source data model.
+type ConsoleEvalDataSourceModel struct {
+	WorkingDir types.String      `tfsdk:"working_dir"`
+	Expression types.String      `tfsdk:"expression"`
+	Variables  map[string]string `tfsdk:"variables"`
+	Workspace  types.String      `tfsdk:"workspace"`
+	Result     types.String      `tfsdk:"result"`
+}
+
+func (d *ConsoleEvalDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+	d.provider = data
+}
+
+func (d *ConsoleEvalDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_console_eval"
+}
+
+func (d *ConsoleEvalDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Evaluates an expression against a nested configuration and its state with `terraform console`, such as a local value a wrapped module computes but doesn't output. The working directory must already be initialized, for example by a `pteraform_apply`.",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory of the nested configuration.",
+				Required:            true,
+			},
+			"expression": schema.StringAttribute{
+				MarkdownDescription: "Expression to evaluate, like `local.subnet_cidrs` or `module.vpc.id`. Sensitive values must be wrapped in `nonsensitive` to be returned.",
+				Required:            true,
+			},
+			"variables": schema.MapAttribute{
+				MarkdownDescription: "Values of the nested configuration's variables, passed to `terraform console` as `-var` arguments.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"workspace": schema.StringAttribute{
+				MarkdownDescription: "Workspace whose state is used. Defaults to the selected workspace.",
+				Optional:            true,
+			},
+			"result": schema.StringAttribute{
+				MarkdownDescription: "The JSON-encoded value of the expression; use `jsondecode` to get its value.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// consoleInput returns the input to terraform console that prints the
+// JSON encoding of expr.
+func consoleInput(expr string) string {
+	return "jsonencode(" + strings.TrimSpace(expr) + ")\n"
+}
+
+// consoleResult returns the JSON printed by terraform console for the input
+// from consoleInput, which is the last line it printed, quoted as an HCL
+// string.
+func consoleResult(out string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "(sensitive value)" {
+		return "", fmt.Errorf("the value is sensitive; wrap the expression in nonsensitive() to return it")
+	}
+	expr, diags := hclsyntax.ParseExpression([]byte(last), "console", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("unexpected output: %s", strings.TrimSpace(out))
+	}
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return "", fmt.Errorf("unexpected output: %s", strings.TrimSpace(out))
+	}
+	return v.AsString(), nil
+}
+
+func (d *ConsoleEvalDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ConsoleEvalDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+	env := d.provider.runEnv(runID)
+	if ws := data.Workspace.ValueString(); ws != "" {
+		env = append(env, "TF_WORKSPACE="+ws)
+	}
+	tf := terraformRunner{inherit: d.provider.inherit(), env: env, stdin: consoleInput(data.Expression.ValueString()), fileMode: d.provider.fileMode()}
+	args := append([]string{"console"}, varArgs(data.Variables)...)
+	out, err := tf.run(ctx, data.WorkingDir.ValueString(), args...)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to evaluate %s, got error: %s", data.Expression.ValueString(), err))
+		return
+	}
+	result, err := consoleResult(out)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to evaluate %s, got error: %s", data.Expression.ValueString(), err))
+		return
+	}
+	data.Result = types.StringValue(result)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsoleInput(t *testing.T) {
+	if got, want := consoleInput(" local.cidrs\n"), "jsonencode(local.cidrs)\n"; got != want {
+		t.Errorf("consoleInput = %q, want %q", got, want)
+	}
+}
+
+func TestConsoleResult(t *testing.T) {
+	for _, c := range []struct {
+		desc    string
+		out     string
+		want    string
+		wantErr string
+	}{{
+		desc: "object",
+		out:  `"{\"a\":[\"10.0.0.0/24\"],\"b\":1}"` + "\n",
+		want: `{"a":["10.0.0.0/24"],"b":1}`,
+	}, {
+		desc: "after warnings",
+		out:  "╷\n│ Warning: Deprecated attribute\n╵\n\"\\\"cool\\\"\"\n",
+		want: `"cool"`,
+	}, {
+		desc:    "sensitive",
+		out:     "(sensitive value)\n",
+		wantErr: "nonsensitive",
+	}, {
+		desc:    "unexpected",
+		out:     "nope nope\n",
+		wantErr: "unexpected output",
+	}} {
+		got, err := consoleResult(c.out)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: got error %v, want %q", c.desc, err, c.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.desc, err)
+		} else if got != c.want {
+			t.Errorf("%s: consoleResult = %s, want %s", c.desc, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "regexp"
+
+// consoleURLPattern matches the link to a run that terraform prints when the
+// nested configuration runs remotely, with a cloud block or the remote
+// backend, like https://app.terraform.io/app/example/network/runs/run-abc123.
+var consoleURLPattern = regexp.MustCompile(`https://[^\s"\\]+/runs/run-[0-9A-Za-z]+`)
+
+// consoleURL returns the last link to a remote run in terraform's output, or
+// "" if it ran locally.
+func consoleURL(output string) string {
+	urls := consoleURLPattern.FindAllString(output, -1)
+	if len(urls) == 0 {
+		return ""
+	}
+	return urls[len(urls)-1]
+}
@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "testing"
+
+func TestConsoleURL(t *testing.T) {
+	for _, c := range []struct {
+		desc, output, want string
+	}{{
+		desc:   "local",
+		output: "Apply complete! Resources: 1 added, 0 changed, 0 destroyed.",
+	}, {
+		desc: "human",
+		output: `Running apply in HCP Terraform. Output will stream here. Pressing Ctrl-C
+will cancel the remote apply if it's still pending. If the apply started it
+will stop streaming the logs, but will not stop the apply running remotely.
+
+To view this run in a browser, visit:
+https://app.terraform.io/app/example/network/runs/run-CZcmD7eagjhyX0vN
+
+Apply complete! Resources: 1 added, 0 changed, 0 destroyed.`,
+		want: "https://app.terraform.io/app/example/network/runs/run-CZcmD7eagjhyX0vN",
+	}, {
+		desc:   "json",
+		output: `{"@level":"info","@message":"To view this run in a browser, visit:\nhttps://tfe.example.com/app/example/network/runs/run-abc123","type":"log"}`,
+		want:   "https://tfe.example.com/app/example/network/runs/run-abc123",
+	}} {
+		if got := consoleURL(c.output); got != c.want {
+			t.Errorf("%s: consoleURL = %q, want %q", c.desc, got, c.want)
+		}
+	}
+}
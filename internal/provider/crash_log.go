@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// crashLogName is the file terraform writes when it panics, in the
+// directory it's run in.
+const crashLogName = "crash.log"
+
+// crashLogHeadLines is how many lines from the start of a crash log are
+// included in the error, which is where the panic and its stack are.
+const crashLogHeadLines = 40
+
+// newCrashLog returns the path of a crash log in one of dirs written since
+// since, or "" if there is none.
+func newCrashLog(since time.Time, dirs ...string) string {
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		fn := filepath.Join(dir, crashLogName)
+		if fi, err := os.Stat(fn); err == nil && !fi.ModTime().Before(since) {
+			return fn
+		}
+	}
+	return ""
+}
+
+// crashLogHead returns the first crashLogHeadLines lines of the crash log fn.
+func crashLogHead(fn string) (string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for len(lines) < crashLogHeadLines && s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	if s.Scan() {
+		lines = append(lines, "...")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// crashed returns err with the start of the crash log attached, if terraform
+// wrote one since start, after uploading it to crash_log_destination if
+// that's set. It returns err unchanged otherwise.
+func (m *ApplyResourceModel) crashed(ctx context.Context, client *http.Client, start time.Time, err error) error {
+	fn := newCrashLog(start, m.WorkingDir.ValueString(), m.RootDir.ValueString())
+	if fn == "" {
+		return err
+	}
+	head, herr := crashLogHead(fn)
+	if herr != nil {
+		return fmt.Errorf("%w\n\nterraform crashed, and %s couldn't be read: %s", err, fn, herr)
+	}
+	where := fn
+	if dest := m.CrashDestination.ValueString(); dest != "" {
+		digest, derr := fileDigest(fn)
+		if derr == nil {
+			where, derr = uploadPlan(ctx, client, fn, digest, dest)
+		}
+		if derr != nil {
+			where = fmt.Sprintf("%s (unable to upload it to %s, got error: %s)", fn, dest, derr)
+		}
+	}
+	return fmt.Errorf("%w\n\nterraform crashed, writing %s, which starts:\n%s", err, where, head)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCrashed(t *testing.T) {
+	var log strings.Builder
+	log.WriteString("panic: runtime error: invalid memory address or nil pointer dereference\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&log, "goroutine frame %d\n", i)
+	}
+	dir := writeFiles(t, map[string]string{crashLogName: log.String()})
+	fn := filepath.Join(dir, crashLogName)
+	m := testApplyModel(dir)
+	failed := errors.New("exit status 11")
+
+	// A crash log left by an earlier run isn't reported.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(fn, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.crashed(context.Background(), http.DefaultClient, time.Now().Add(-time.Minute), failed); err != failed {
+		t.Errorf("crashed with an old crash log = %v, want it unchanged", err)
+	}
+
+	var uploaded bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploaded = r.Method == http.MethodPut
+	}))
+	defer srv.Close()
+	m.CrashDestination = types.StringValue(srv.URL + "/crash.log")
+	err := m.crashed(context.Background(), srv.Client(), old, failed)
+	if !errors.Is(err, failed) {
+		t.Errorf("crashed = %v, want it to wrap %v", err, failed)
+	}
+	for _, want := range []string{"writing " + srv.URL + "/crash.log", "panic: runtime error", "goroutine frame 38\n..."} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("crashed = %v, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "goroutine frame 39") {
+		t.Errorf("crashed = %v, want only the first %d lines", err, crashLogHeadLines)
+	}
+	if !uploaded {
+		t.Error("crash log wasn't uploaded")
+	}
+}
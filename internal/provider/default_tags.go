@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// defaultTagsVariable is the nested variable default_tags are merged into
+// unless default_tags_variable is set.
+const defaultTagsVariable = "tags"
+
+// defaultTagsArgs returns the arguments that set the nested variable name in
+// dir to tags merged with the variable's value, as supplied in args or
+// environ or else its default. Where both set a key, the variable's value
+// wins over tags. It returns nothing if there are no tags or the nested
+// configuration doesn't declare the variable.
+func defaultTagsArgs(dir string, args, environ []string, name string, tags map[string]string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	mod, err := loadModule(dir)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := mod.Variables[name]
+	if !ok {
+		return nil, nil
+	}
+	vars, err := collectVariables(dir, args, environ)
+	if err != nil {
+		return nil, err
+	}
+
+	value := v.Default
+	if val, ok := vars[name]; ok {
+		if value, err = v.parse(val); err != nil {
+			return nil, err
+		}
+	}
+	merged := map[string]string{}
+	for k, t := range tags {
+		merged[k] = t
+	}
+	if value != cty.NilVal && !value.IsNull() {
+		if !value.Type().IsMapType() && !value.Type().IsObjectType() {
+			return nil, fmt.Errorf("variable %q must be a map to merge default_tags into, got %s", name, value.Type().FriendlyName())
+		}
+		for k, t := range value.AsValueMap() {
+			s, err := convert.Convert(t, cty.String)
+			if err != nil || s.IsNull() || !s.IsKnown() {
+				return nil, fmt.Errorf("variable %q must be a map of strings to merge default_tags into, but %q isn't a string", name, k)
+			}
+			merged[k] = s.AsString()
+		}
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("-var=%s=%s", name, b)}, nil
+}
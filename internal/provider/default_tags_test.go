@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDefaultTagsArgs(t *testing.T) {
+	tags := map[string]string{"owner": "platform", "env": "prod"}
+
+	for _, c := range []struct {
+		desc    string
+		main    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{{
+		desc: "undeclared",
+		main: `variable "other" {}`,
+	}, {
+		desc: "no default",
+		main: `variable "tags" { type = map(string) }`,
+		want: []string{`-var=tags={"env":"prod","owner":"platform"}`},
+	}, {
+		desc: "default",
+		main: `variable "tags" {
+  type    = map(string)
+  default = { owner = "app", app = "web" }
+}`,
+		want: []string{`-var=tags={"app":"web","env":"prod","owner":"app"}`},
+	}, {
+		desc: "supplied",
+		main: `variable "tags" {
+  type    = map(string)
+  default = { app = "web" }
+}`,
+		args: []string{`-var=tags={ env = "dev" }`},
+		want: []string{`-var=tags={"env":"dev","owner":"platform"}`},
+	}, {
+		desc:    "not a map",
+		main:    `variable "tags" { default = "x" }`,
+		wantErr: true,
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"main.tf": c.main})
+			got, err := defaultTagsArgs(dir, c.args, nil, "tags", tags)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("defaultTagsArgs (-want,+got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// previewDestroy returns a warning, when the resource m is planned to be
+// destroyed, saying how many nested resources a nested destroy would remove.
+// Destroying the resource leaves them in place, so this is the blast radius
+// of destroying them, and how many are orphaned if they aren't. Failing to
+// plan is only a warning, so it never blocks destroying the resource.
+func (r *ApplyResource) previewDestroy(ctx context.Context, m ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	warn := func(err error) diag.Diagnostics {
+		diags.AddWarning("Unable to Preview Nested Destroy", fmt.Sprintf("Unable to plan destroying the nested resources in %s, got error: %s", m.WorkingDir.ValueString(), err))
+		return diags
+	}
+	var args []string
+	if d := m.Args.ElementsAs(ctx, &args, false); d.HasError() {
+		return warn(fmt.Errorf("errors getting args: %v", d.Errors()))
+	}
+	limits, err := m.ResourceLimits.limits()
+	if err != nil {
+		return warn(err)
+	}
+	m.DesiredState = types.StringValue("absent")
+	if args, err = r.nestedArgs(ctx, &m, args); err != nil {
+		return warn(err)
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		return warn(err)
+	}
+	tf, err := r.runner(limits, &m, runID)
+	if err != nil {
+		return warn(err)
+	}
+	backend, err := m.backendArgs(ctx)
+	if err != nil {
+		return warn(err)
+	}
+	planJSON, err := nestedPlanJSON(ctx, tf, m.WorkingDir.ValueString(), r.provider.tempDir(), m.WorkspaceName.ValueString(), backend, args, "")
+	if err != nil {
+		return warn(err)
+	}
+	summary, err := summarizePlan(planJSON)
+	if err != nil {
+		return warn(err)
+	}
+	diags.AddWarning("Nested Resources Not Destroyed",
+		fmt.Sprintf("Destroying this resource leaves the nested resources in %s in place. Destroying them with terraform destroy would remove %d nested resources. To destroy them too, set desired_state to absent and apply before removing this resource.", m.WorkingDir.ValueString(), summary.Destroy))
+	return diags
+}
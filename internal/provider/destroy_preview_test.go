@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPreviewDestroy(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+	m := testApplyModel(dir)
+	m.PreviewDestroy = types.BoolValue(true)
+
+	fake := &fakeRunner{outputs: map[string]string{
+		"show -json PLAN": `{"resource_changes": [{"change": {"actions": ["delete"]}}, {"change": {"actions": ["delete"]}}, {"change": {"actions": ["no-op"]}}]}`,
+	}}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	diags := r.previewDestroy(context.Background(), m)
+	if diags.WarningsCount() != 1 || diags.ErrorsCount() != 0 {
+		t.Fatalf("got %v, want one warning", diags)
+	}
+	if detail := diags[0].Detail(); !strings.Contains(detail, "would remove 2 nested resources") {
+		t.Errorf("warning = %q, want the number of nested resources destroyed", detail)
+	}
+	plan := "plan -input=false -out=PLAN -destroy"
+	if !slices.Contains(fake.commands, plan) {
+		t.Errorf("commands = %q, want %q", fake.commands, plan)
+	}
+	// The resource's own desired_state is left alone.
+	if m.DesiredState.ValueString() == "absent" {
+		t.Error("desired_state changed")
+	}
+
+	fake.failures = map[string]int{plan: 1}
+	if diags := r.previewDestroy(context.Background(), m); diags.HasError() || diags.WarningsCount() != 1 || diags[0].Summary() != "Unable to Preview Nested Destroy" {
+		t.Errorf("failed plan: got %v, want a warning", diags)
+	}
+}
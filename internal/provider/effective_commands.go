@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maskedValue replaces values in effective_commands that may be secret.
+const maskedValue = "(sensitive)"
+
+// ApplyEffectiveCommandModel describes a terraform command the nested
+// apply ran.
+type ApplyEffectiveCommandModel struct {
+	Phase       types.String `tfsdk:"phase"`
+	WorkingDir  types.String `tfsdk:"working_dir"`
+	Command     types.List   `tfsdk:"command"`
+	Environment types.List   `tfsdk:"environment"`
+}
+
+var applyEffectiveCommandAttrTypes = map[string]attr.Type{
+	"phase":       types.StringType,
+	"working_dir": types.StringType,
+	"command":     types.ListType{ElemType: types.StringType},
+	"environment": types.ListType{ElemType: types.StringType},
+}
+
+// effectiveCommand is a terraform command the nested apply ran.
+type effectiveCommand struct {
+	phase string
+	dir   string
+	argv  []string
+	env   []string
+}
+
+// commandLog records the terraform commands an apply runs, and the phase
+// each is run in.
+type commandLog struct {
+	// phase is the phase of the apply currently running.
+	phase *string
+	// env are the names of the variables in terraform's environment.
+	env []string
+	// workspace is the nested workspace commands run in, with TF_WORKSPACE.
+	workspace string
+
+	mu       sync.Mutex
+	commands []effectiveCommand
+}
+
+func (l *commandLog) record(dir string, args []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	env := envNames(append(append([]string{}, l.env...), workspaceEnv(l.workspace, args[0])...))
+	l.commands = append(l.commands, effectiveCommand{phase: *l.phase, dir: dir, argv: append([]string{"terraform"}, maskArgs(args)...), env: env})
+}
+
+// value returns the effective_commands attribute for the commands recorded.
+func (l *commandLog) value(ctx context.Context) (types.List, diag.Diagnostics) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var diags diag.Diagnostics
+	commands := []ApplyEffectiveCommandModel{}
+	for _, c := range l.commands {
+		argv, d := types.ListValueFrom(ctx, types.StringType, c.argv)
+		diags.Append(d...)
+		env, d := types.ListValueFrom(ctx, types.StringType, c.env)
+		diags.Append(d...)
+		commands = append(commands, ApplyEffectiveCommandModel{
+			Phase:       types.StringValue(c.phase),
+			WorkingDir:  types.StringValue(c.dir),
+			Command:     argv,
+			Environment: env,
+		})
+	}
+	list, d := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: applyEffectiveCommandAttrTypes}, commands)
+	diags.Append(d...)
+	return list, diags
+}
+
+// envNames returns the sorted, unique names of the variables in environ.
+func envNames(environ []string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// childEnvironNames returns the names of the variables in the environment
+// of nested terraform run with the additional variables env.
+func (r *ApplyResource) childEnvironNames(env []string) []string {
+	return envNames(append(r.provider.inherit().filter(os.Environ()), env...))
+}
+
+// maskArgs returns args with the values of variables and backend settings
+// masked, since they may be secret. Files of variables and backend
+// settings are left, since they're only paths.
+func maskArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = a
+		if i > 0 && args[i-1] == "-var" {
+			out[i] = maskSetting(a)
+			continue
+		}
+		for _, flag := range []string{"-var=", "-backend-config="} {
+			if v, ok := strings.CutPrefix(a, flag); ok && strings.Contains(v, "=") {
+				out[i] = flag + maskSetting(v)
+			}
+		}
+	}
+	return out
+}
+
+// maskSetting masks the value of the setting name=value.
+func maskSetting(s string) string {
+	name, _, ok := strings.Cut(s, "=")
+	if !ok {
+		return s
+	}
+	return name + "=" + maskedValue
+}
+
+// recordingRunner records the commands it runs in log.
+type recordingRunner struct {
+	runner
+	log *commandLog
+}
+
+func (r recordingRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	r.log.record(dir, args)
+	return r.runner.run(ctx, dir, args...)
+}
+
+func (r recordingRunner) stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error) {
+	if s, ok := r.runner.(streamer); ok && w != nil {
+		r.log.record(dir, args)
+		return s.stream(ctx, dir, w, args...)
+	}
+	return r.run(ctx, dir, args...)
+}
+
+func (r recordingRunner) stdout(ctx context.Context, dir string, args ...string) (string, error) {
+	r.log.record(dir, args)
+	return runStdout(ctx, r.runner, dir, args...)
+}
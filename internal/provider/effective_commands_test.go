@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestMaskArgs(t *testing.T) {
+	got := maskArgs([]string{"plan", "-var=password=hunter2", "-var", "token=abc", "-var-file=prod.tfvars", "-backend-config=key=secret", "-backend-config=backend.hcl", "-out=plan"})
+	want := []string{"plan", "-var=password=(sensitive)", "-var", "token=(sensitive)", "-var-file=prod.tfvars", "-backend-config=key=(sensitive)", "-backend-config=backend.hcl", "-out=plan"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("maskArgs (-want,+got): %s", diff)
+	}
+}
+
+func TestDoApplyEffectiveCommands(t *testing.T) {
+	t.Setenv("TF_WORKSPACE", "outer")
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	m := testApplyModel(dir)
+	m.Args = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("-var=password=hunter2")})
+
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return &fakeRunner{} }}
+	if _, _, err := r.doApply(context.Background(), &m); err != nil {
+		t.Fatal(err)
+	}
+	var commands []ApplyEffectiveCommandModel
+	if diags := m.EffectiveCommands.ElementsAs(context.Background(), &commands, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	var got [][]string
+	for _, c := range commands {
+		var argv, env []string
+		c.Command.ElementsAs(context.Background(), &argv, false)
+		c.Environment.ElementsAs(context.Background(), &env, false)
+		got = append(got, append([]string{c.Phase.ValueString(), c.WorkingDir.ValueString()}, argv...))
+		// Only the nested workspace is selected, and not for init.
+		for _, name := range env {
+			if name == "TF_WORKSPACE" && c.Phase.ValueString() == "init" {
+				t.Errorf("%s environment includes TF_WORKSPACE", c.Phase.ValueString())
+			}
+		}
+	}
+	want := [][]string{
+		{"init", dir, "terraform", "init"},
+		{"apply", dir, "terraform", "apply", "-auto-approve", "-var=password=(sensitive)"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("effective_commands (-want,+got): %s", diff)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// envCheck is the outcome of one preflight check of the environment.
+type envCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// registryTimeout bounds how long the registry reachability check waits.
+const registryTimeout = 10 * time.Second
+
+// checkTerraform checks that terraform can be run in dir, and that its
+// version satisfies constraint if it's not empty.
+func checkTerraform(ctx context.Context, dir, constraint string) envCheck {
+	c := envCheck{Name: "terraform"}
+	cli, err := resolveTerraformCLI(ctx, dir)
+	if err != nil {
+		c.Detail = err.Error()
+		return c
+	}
+	c.Detail = fmt.Sprintf("terraform %s at %s", cli.Version, cli.ResolvedPath)
+	if constraint == "" {
+		c.Passed = true
+		return c
+	}
+	cs, err := version.NewConstraint(constraint)
+	if err != nil {
+		c.Detail = fmt.Sprintf("invalid version constraint %q: %s", constraint, err)
+		return c
+	}
+	v, err := version.NewVersion(cli.Version)
+	if err != nil {
+		c.Detail = fmt.Sprintf("Unable to parse terraform version %q, got error: %s", cli.Version, err)
+		return c
+	}
+	c.Passed = cs.Check(v)
+	if !c.Passed {
+		c.Detail += fmt.Sprintf(", which does not satisfy %q", constraint)
+	}
+	return c
+}
+
+// checkEnv checks that the environment variable name is set and not empty.
+// The value is never included in the result, since it may be a secret.
+func checkEnv(name string) envCheck {
+	c := envCheck{Name: "env:" + name, Detail: "not set"}
+	if os.Getenv(name) != "" {
+		c.Passed, c.Detail = true, "set"
+	}
+	return c
+}
+
+// checkRegistry checks that the registry at host serves its service
+// discovery document, as terraform init needs it to.
+func checkRegistry(ctx context.Context, client *http.Client, host string) envCheck {
+	c := envCheck{Name: "registry:" + host}
+	ctx, cancel := context.WithTimeout(ctx, registryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/.well-known/terraform.json", host), nil)
+	if err != nil {
+		c.Detail = err.Error()
+		return c
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.Detail = err.Error()
+		return c
+	}
+	resp.Body.Close()
+	c.Passed = resp.StatusCode == http.StatusOK
+	c.Detail = resp.Status
+	return c
+}
+
+// checkDisk checks that the filesystem containing dir has at least min bytes
+// available.
+func checkDisk(dir string, min int64) envCheck {
+	c := envCheck{Name: "disk"}
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		c.Detail = fmt.Sprintf("Unable to check free disk space, got error: %s", err)
+		return c
+	}
+	c.Passed = free >= uint64(min)
+	c.Detail = fmt.Sprintf("%d bytes available, %d required", free, min)
+	return c
+}
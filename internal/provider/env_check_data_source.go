@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &EnvCheckDataSource{}
+var _ datasource.DataSourceWithValidateConfig = &EnvCheckDataSource{}
+
+func NewEnvCheckDataSource() datasource.DataSource {
+	return &EnvCheckDataSource{}
+}
+
+// EnvCheckDataSource defines the data source implementation.
+type EnvCheckDataSource struct{}
+
+// EnvCheckDataSourceModel describes the data source data model.
+type EnvCheckDataSourceModel struct {
+	WorkingDir       types.String    `tfsdk:"working_dir"`
+	TerraformVersion types.String    `tfsdk:"terraform_version"`
+	RequiredEnv      types.List      `tfsdk:"required_env"`
+	RegistryHost     types.String    `tfsdk:"registry_host"`
+	MinFreeDisk      types.String    `tfsdk:"min_free_disk"`
+	Passed           types.Bool      `tfsdk:"passed"`
+	Checks           []EnvCheckModel `tfsdk:"checks"`
+}
+
+// EnvCheckModel describes the outcome of one check.
+type EnvCheckModel struct {
+	Name   types.String `tfsdk:"name"`
+	Passed types.Bool   `tfsdk:"passed"`
+	Detail types.String `tfsdk:"detail"`
+}
+
+func (d *EnvCheckDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_env_check"
+}
+
+func (d *EnvCheckDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Checks that the environment can run nested applies, for use in preconditions. Failed checks are reported in `checks` rather than as errors.",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory nested applies will run in. Defaults to the current directory.",
+				Optional:            true,
+			},
+			"terraform_version": schema.StringAttribute{
+				MarkdownDescription: "Version constraint the resolved `terraform` must satisfy, like `>= 1.5, < 2.0`. `terraform` must be runnable regardless.",
+				Optional:            true,
+			},
+			"required_env": schema.ListAttribute{
+				MarkdownDescription: "Environment variables that must be set and not empty. Their values are never exposed.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"registry_host": schema.StringAttribute{
+				MarkdownDescription: "Registry hostname, like `registry.terraform.io`, that must serve its service discovery document over HTTPS.",
+				Optional:            true,
+			},
+			"min_free_disk": schema.StringAttribute{
+				MarkdownDescription: "Disk space that must be available in `working_dir`, like `1GiB`.",
+				Optional:            true,
+			},
+			"passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether every check passed.",
+				Computed:            true,
+			},
+			"checks": schema.ListNestedAttribute{
+				MarkdownDescription: "Outcome of each check that was run.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Check name: `terraform`, `env:<name>`, `registry:<host>` or `disk`.",
+							Computed:            true,
+						},
+						"passed": schema.BoolAttribute{
+							MarkdownDescription: "Whether the check passed.",
+							Computed:            true,
+						},
+						"detail": schema.StringAttribute{
+							MarkdownDescription: "What was found, or why the check failed.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *EnvCheckDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data EnvCheckDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if v := data.TerraformVersion; !v.IsNull() && !v.IsUnknown() {
+		if _, err := version.NewConstraint(v.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("terraform_version"), "Invalid Version Constraint", err.Error())
+		}
+	}
+	if v := data.MinFreeDisk; !v.IsNull() && !v.IsUnknown() {
+		if _, err := parseSize(v.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("min_free_disk"), "Invalid Disk Size", err.Error())
+		}
+	}
+}
+
+func (d *EnvCheckDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data EnvCheckDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var env []string
+	resp.Diagnostics.Append(data.RequiredEnv.ElementsAs(ctx, &env, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.WorkingDir.ValueString()
+	checks := []envCheck{checkTerraform(ctx, dir, data.TerraformVersion.ValueString())}
+	for _, name := range env {
+		checks = append(checks, checkEnv(name))
+	}
+	if host := data.RegistryHost.ValueString(); host != "" {
+		checks = append(checks, checkRegistry(ctx, http.DefaultClient, host))
+	}
+	if !data.MinFreeDisk.IsNull() {
+		min, err := parseSize(data.MinFreeDisk.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("min_free_disk"), "Invalid Disk Size", err.Error())
+			return
+		}
+		if dir == "" {
+			dir = "."
+		}
+		checks = append(checks, checkDisk(dir, min))
+	}
+
+	data.Passed = types.BoolValue(true)
+	data.Checks = []EnvCheckModel{}
+	for _, c := range checks {
+		if !c.Passed {
+			data.Passed = types.BoolValue(false)
+		}
+		data.Checks = append(data.Checks, EnvCheckModel{
+			Name:   types.StringValue(c.Name),
+			Passed: types.BoolValue(c.Passed),
+			Detail: types.StringValue(c.Detail),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
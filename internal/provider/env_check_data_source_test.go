@@ -0,0 +1,36 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccEnvCheckDataSource(t *testing.T) {
+	t.Setenv("PTERAFORM_TEST_SET", "yes")
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+data "pteraform_env_check" "this" {
+	terraform_version = ">= 1.0"
+	required_env      = ["PTERAFORM_TEST_SET", "PTERAFORM_TEST_UNSET"]
+	min_free_disk     = "1MiB"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "passed", "false"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.#", "4"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.0.name", "terraform"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.0.passed", "true"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.1.passed", "true"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.2.name", "env:PTERAFORM_TEST_UNSET"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.2.passed", "false"),
+				resource.TestCheckResourceAttr("data.pteraform_env_check.this", "checks.3.name", "disk"),
+			),
+		}},
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckEnv(t *testing.T) {
+	t.Setenv("PTERAFORM_TEST_SET", "secret")
+	t.Setenv("PTERAFORM_TEST_EMPTY", "")
+
+	if c := checkEnv("PTERAFORM_TEST_SET"); !c.Passed || strings.Contains(c.Detail, "secret") {
+		t.Errorf("checkEnv(set) = %+v", c)
+	}
+	for _, name := range []string{"PTERAFORM_TEST_EMPTY", "PTERAFORM_TEST_UNSET"} {
+		if c := checkEnv(name); c.Passed {
+			t.Errorf("checkEnv(%q) passed", name)
+		}
+	}
+}
+
+func TestCheckRegistry(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/terraform.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"modules.v1":"/v1/modules/","providers.v1":"/v1/providers/"}`))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	if c := checkRegistry(context.Background(), srv.Client(), host); !c.Passed {
+		t.Errorf("checkRegistry(reachable) = %+v", c)
+	}
+	if c := checkRegistry(context.Background(), srv.Client(), host+"/missing"); c.Passed {
+		t.Errorf("checkRegistry(not found) passed")
+	}
+	srv.Close()
+	if c := checkRegistry(context.Background(), srv.Client(), host); c.Passed {
+		t.Errorf("checkRegistry(closed) passed")
+	}
+}
+
+func TestCheckDisk(t *testing.T) {
+	dir := t.TempDir()
+	if c := checkDisk(dir, 1); !c.Passed {
+		t.Errorf("checkDisk(1) = %+v", c)
+	}
+	if c := checkDisk(dir, 1<<62); c.Passed {
+		t.Errorf("checkDisk(4EiB) passed")
+	}
+	if c := checkDisk(dir+"/missing", 1); c.Passed {
+		t.Errorf("checkDisk(missing) passed")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// checkEnvironmentName returns an error if name can't be the name of an
+// environment variable.
+func checkEnvironmentName(name string) error {
+	if name == "" || strings.ContainsAny(name, "=\x00") {
+		return fmt.Errorf("%q is not a valid environment variable name", name)
+	}
+	return nil
+}
+
+// environmentEnv returns the variables of m's environment, sorted by name,
+// to add to the environment of nested runs.
+func (m *ApplyResourceModel) environmentEnv() []string {
+	elements := m.Environment.Elements()
+	names := make([]string, 0, len(elements))
+	for k := range elements {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var env []string
+	for _, k := range names {
+		v, ok := elements[k].(types.String)
+		if !ok || v.IsNull() || v.IsUnknown() {
+			continue
+		}
+		env = append(env, k+"="+v.ValueString())
+	}
+	return env
+}
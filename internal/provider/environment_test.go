@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEnvironmentEnv(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "outer")
+	m := testApplyModel(t.TempDir())
+	if got := m.environmentEnv(); got != nil {
+		t.Errorf("no environment = %q", got)
+	}
+
+	m.Environment = types.MapValueMust(types.StringType, map[string]attr.Value{
+		"TF_VAR_region": types.StringValue("us-east-1"),
+		"AWS_PROFILE":   types.StringValue("nested"),
+	})
+	want := []string{"AWS_PROFILE=nested", "TF_VAR_region=us-east-1"}
+	if diff := cmp.Diff(want, m.environmentEnv()); diff != "" {
+		t.Errorf("environmentEnv (-want,+got): %s", diff)
+	}
+	// The nested environment's values come last, so they take precedence.
+	environ := (&ApplyResource{}).nestedEnviron(&m)
+	if diff := cmp.Diff(want, environ[len(environ)-2:]); diff != "" {
+		t.Errorf("nestedEnviron (-want,+got): %s", diff)
+	}
+
+	for name, valid := range map[string]bool{"AWS_PROFILE": true, "": false, "A=B": false} {
+		if err := checkEnvironmentName(name); (err == nil) != valid {
+			t.Errorf("checkEnvironmentName(%q) = %v, want valid %t", name, err, valid)
+		}
+	}
+}
+
+func TestCheckVariablesEnvironment(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `variable "region" {}`})
+	m := testApplyModel(dir)
+	m.Environment = types.MapValueMust(types.StringType, map[string]attr.Value{"TF_VAR_region": types.StringValue("us-east-1")})
+	if diags := (&ApplyResource{}).checkVariables(context.Background(), &m); diags.HasError() {
+		t.Errorf("variable set in environment: %v", diags)
+	}
+}
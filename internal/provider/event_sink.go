@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// eventSinkTimeout bounds how long posting each event to an event sink
+// waits.
+const eventSinkTimeout = 10 * time.Second
+
+// eventSinkBuffer is how many events are queued for an event sink before
+// further events are dropped, so a slow sink can't hold up the apply.
+const eventSinkBuffer = 1000
+
+// ApplyEventSinkModel describes the event_sink block.
+type ApplyEventSinkModel struct {
+	URL     types.String `tfsdk:"url"`
+	Headers types.Map    `tfsdk:"headers"`
+}
+
+// streamer is implemented by runners that can also write terraform's output
+// to w as it's written.
+type streamer interface {
+	stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error)
+}
+
+// eventSink is an io.Writer that posts each JSON event written to it to a
+// URL, as terraform writes them with -json. Posting is done in the
+// background, and failures are logged rather than failing the apply.
+type eventSink struct {
+	ctx     context.Context
+	client  *http.Client
+	url     string
+	headers map[string]string
+
+	partial []byte
+	events  chan []byte
+	done    chan struct{}
+	dropped int
+}
+
+// newEventSink returns a sink posting events to url with headers. It must be
+// closed once terraform exits.
+func newEventSink(ctx context.Context, client *http.Client, url string, headers map[string]string) *eventSink {
+	s := &eventSink{
+		ctx:     ctx,
+		client:  client,
+		url:     url,
+		headers: headers,
+		events:  make(chan []byte, eventSinkBuffer),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		for e := range s.events {
+			if err := s.post(e); err != nil {
+				tflog.Warn(ctx, "Unable to post nested event", map[string]interface{}{"url": url, "error": err.Error()})
+			}
+		}
+	}()
+	return s
+}
+
+func (s *eventSink) Write(p []byte) (int, error) {
+	s.partial = append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(s.partial, '\n')
+		if i < 0 {
+			break
+		}
+		s.send(s.partial[:i])
+		s.partial = s.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// send queues line to be posted if it's an event.
+func (s *eventSink) send(line []byte) {
+	line = bytes.TrimSpace(line)
+	var e struct {
+		Level string `json:"@level"`
+	}
+	if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &e) != nil || e.Level == "" {
+		return
+	}
+	select {
+	case s.events <- append([]byte(nil), line...):
+	default:
+		s.dropped++
+	}
+}
+
+// Close posts any remaining events and waits for them to be posted.
+func (s *eventSink) Close() error {
+	s.send(s.partial)
+	s.partial = nil
+	close(s.events)
+	<-s.done
+	if s.dropped > 0 {
+		tflog.Warn(s.ctx, "Dropped nested events the event sink couldn't keep up with", map[string]interface{}{"url": s.url, "dropped": s.dropped})
+	}
+	return nil
+}
+
+func (s *eventSink) post(event []byte) error {
+	ctx, cancel := context.WithTimeout(s.ctx, eventSinkTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(event))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("got status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// runner runs terraform commands. terraformRunner runs them as child
+// processes; tests substitute a fake to check what would be run.
+type runner interface {
+	run(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// stdoutRunner is implemented by runners that can return only terraform's
+// stdout.
+type stdoutRunner interface {
+	stdout(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// runStdout runs terraform with tf, and returns only its stdout if tf can
+// tell it apart.
+func runStdout(ctx context.Context, tf runner, dir string, args ...string) (string, error) {
+	if s, ok := tf.(stdoutRunner); ok {
+		return s.stdout(ctx, dir, args...)
+	}
+	return tf.run(ctx, dir, args...)
+}
+
+// interruptGracePeriod is how long terraform has to exit after being
+// interrupted before it is killed. Terraform stops gracefully when
+// interrupted, persisting any state it has, so it's given a chance to.
+const interruptGracePeriod = 30 * time.Second
+
+// terraformBinary returns the name of the terraform executable on goos.
+func terraformBinary(goos string) string {
+	if goos == "windows" {
+		return "terraform.exe"
+	}
+	return "terraform"
+}
+
+// taskkillArgs returns the arguments to taskkill to kill the process pid and
+// all of its children on Windows, where processes can't be interrupted.
+func taskkillArgs(pid int) []string {
+	return []string{"/T", "/F", "/PID", strconv.Itoa(pid)}
+}
+
+// terraformRunner runs terraform commands as child processes.
+type terraformRunner struct {
+	limits resourceLimits
+	// inherit is which of the provider's environment variables terraform
+	// inherits.
+	inherit inheritEnvironment
+	// env is added to the environment terraform inherits.
+	env []string
+	// root, if set, is the directory terraform is run in, with -chdir
+	// pointing at the directory it's asked to run in.
+	root string
+	// stdin, if set, is terraform's input.
+	stdin string
+	// install, if set, is the version of terraform run instead of the one
+	// on PATH.
+	install *terraformInstall
+	// fileMode is the permissions of the files written alongside terraform,
+	// like its pid file, or 0 for defaultFileMode.
+	fileMode os.FileMode
+	// logDir, if set, is where the full output of failed commands is saved,
+	// instead of outputLogDir in the directory they ran in.
+	logDir string
+	// workspace, if set, is the nested workspace terraform runs in, with
+	// TF_WORKSPACE, and the one its pid file is kept for.
+	workspace string
+}
+
+// run runs terraform with args in dir, and returns its combined stdout and
+// stderr, normalized with normalizeOutput.
+func (t terraformRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	return t.stream(ctx, dir, nil, args...)
+}
+
+// stream is like run, but also writes terraform's output to w as it's
+// written, if w isn't nil.
+func (t terraformRunner) stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error) {
+	return t.exec(ctx, dir, w, nil, args...)
+}
+
+// stdout is like run, but returns only terraform's stdout, as it is, for
+// commands whose output is data, like state pull. If terraform fails, the
+// error has its stderr.
+func (t terraformRunner) stdout(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	if _, err := t.exec(ctx, dir, nil, &stdout, args...); err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// exec runs terraform with args in dir, writing its stdout to stdout if
+// it isn't nil, and returns the rest of its output, as stream does.
+func (t terraformRunner) exec(ctx context.Context, dir string, w, stdout io.Writer, args ...string) (string, error) {
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	if w != nil {
+		out = io.MultiWriter(&buf, w)
+	}
+	cmdDir, cmdArgs, err := chdirArgs(t.root, dir, args)
+	if err != nil {
+		return "", fmt.Errorf("terraform %s failed, got error: %s", args[0], err)
+	}
+	bin := terraformBinary(runtime.GOOS)
+	if t.install != nil {
+		if bin, err = t.install.path(ctx); err != nil {
+			return "", fmt.Errorf("terraform %s failed, got error: %s", args[0], err)
+		}
+	}
+	cmd := exec.CommandContext(ctx, bin, cmdArgs...)
+	cmd.Dir = cmdDir
+	if t.stdin != "" {
+		cmd.Stdin = strings.NewReader(t.stdin)
+	}
+	cmd.Env = append(append(t.inherit.filter(os.Environ()), t.env...), workspaceEnv(t.workspace, args[0])...)
+	// With the same writer, only one goroutine writes to it at a time.
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	cmd.Cancel = func() error { return interrupt(cmd) }
+	cmd.WaitDelay = interruptGracePeriod
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("terraform %s failed, got error: %s", args[0], err)
+	}
+	if err := writePIDFile(dir, t.workspace, cmd.Process.Pid, t.fileMode); err != nil {
+		tflog.Warn(ctx, "Unable to record terraform pid, it won't be waited for if the provider exits", map[string]interface{}{"error": err.Error()})
+	}
+	defer removePIDFile(dir, t.workspace)
+	cleanup, err := t.limits.apply(ctx, cmd.Process.Pid)
+	defer cleanup()
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return normalizeOutput(buf.String()), fmt.Errorf("terraform %s failed, got error: %s", args[0], err)
+	}
+	err = cmd.Wait()
+	output := normalizeOutput(buf.String())
+	if err != nil {
+		code := cmd.ProcessState.ExitCode()
+		return output, &runError{Command: args[0], ExitCode: code, Class: classifyFailure(ctx, code, output), Output: output, LogFile: saveOutput(t.outputLogsDir(dir), "terraform-"+args[0], output, t.fileMode), Err: err}
+	}
+	return output, nil
+}
+
+// outputLogsDir returns where the full output of failed commands run in dir
+// is saved.
+func (t terraformRunner) outputLogsDir(dir string) string {
+	if t.logDir != "" {
+		return t.logDir
+	}
+	return outputLogsDir("", dir)
+}
+
+// runError is returned when terraform runs but fails.
+type runError struct {
+	// Command is the terraform subcommand that failed, like "apply".
+	Command string
+	// ExitCode is terraform's exit code, or -1 if it was killed.
+	ExitCode int
+	// Class is why terraform failed, or "" if it wasn't classified.
+	Class  failureClass
+	Output string
+	// LogFile, if set, is where Output was saved because it's too long to
+	// include in the error in full.
+	LogFile string
+	Err     error
+}
+
+func (e *runError) Error() string {
+	if d := e.Class.describe(); d != "" {
+		return fmt.Sprintf("terraform %s failed: %s, got error: %s, output: %s", e.Command, d, e.Err, truncateOutput(e.Output, e.LogFile))
+	}
+	return fmt.Sprintf("terraform %s failed, got error: %s, output: %s", e.Command, e.Err, truncateOutput(e.Output, e.LogFile))
+}
+
+func (e *runError) Unwrap() error { return e.Err }
@@ -0,0 +1,519 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestTerraformBinary(t *testing.T) {
+	for goos, want := range map[string]string{
+		"linux":   "terraform",
+		"darwin":  "terraform",
+		"freebsd": "terraform",
+		"windows": "terraform.exe",
+	} {
+		if got := terraformBinary(goos); got != want {
+			t.Errorf("terraformBinary(%q) = %q, want %q", goos, got, want)
+		}
+	}
+	if diff := cmp.Diff([]string{"/T", "/F", "/PID", "1234"}, taskkillArgs(1234)); diff != "" {
+		t.Errorf("taskkillArgs (-want,+got): %s", diff)
+	}
+}
+
+// fakeRunner records the commands it is asked to run instead of running
+// them. Saved plans requested with -out are written as empty files.
+type fakeRunner struct {
+	// commands are the commands run, with the paths of plans made by
+	// newPlanFile as PLAN, and argv are the commands as they were run.
+	commands []string
+	argv     [][]string
+	// outputs are the outputs of commands, keyed by the command.
+	outputs map[string]string
+	// failures are how many times each command fails, keyed by the
+	// command, before it succeeds.
+	failures map[string]int
+}
+
+// planFilePattern matches the paths of plans made by newPlanFile, which are
+// recorded by fakeRunner as PLAN, since they're unique.
+var planFilePattern = regexp.MustCompile(`[^\s=]*pteraform-(remote-)?[0-9]+\.tfplan`)
+
+func (f *fakeRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	command := planFilePattern.ReplaceAllString(strings.Join(args, " "), "PLAN")
+	f.commands = append(f.commands, command)
+	f.argv = append(f.argv, args)
+	for _, a := range args {
+		if out, ok := strings.CutPrefix(a, "-out="); ok {
+			if !filepath.IsAbs(out) {
+				out = filepath.Join(dir, out)
+			}
+			if err := os.WriteFile(out, nil, 0o644); err != nil {
+				return "", err
+			}
+		}
+	}
+	if f.failures[command] > 0 {
+		f.failures[command]--
+		return f.outputs[command], &runError{Command: args[0], ExitCode: 1, Output: f.outputs[command], Err: errors.New("exit status 1")}
+	}
+	return f.outputs[command], nil
+}
+
+func (f *fakeRunner) stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error) {
+	out, err := f.run(ctx, dir, args...)
+	io.WriteString(w, out)
+	return out, err
+}
+
+// testApplyModel returns a model for an apply in dir with nothing else set.
+func testApplyModel(dir string) ApplyResourceModel {
+	return ApplyResourceModel{
+		WorkingDir:         types.StringValue(dir),
+		Triggers:           types.MapNull(types.StringType),
+		Variables:          types.MapNull(types.StringType),
+		VarFiles:           types.ListNull(types.StringType),
+		VariablesJSON:      types.StringNull(),
+		ProviderConfig:     types.StringNull(),
+		OverrideFiles:      types.MapNull(types.StringType),
+		Environment:        types.MapNull(types.StringType),
+		IDStrategy:         types.StringNull(),
+		PassthroughPrefix:  types.StringNull(),
+		TerraformVersion:   types.StringNull(),
+		AllowVersionChange: types.BoolNull(),
+		RateLimits:         types.MapNull(types.StringType),
+		BackendConfig:      types.MapNull(types.StringType),
+		BackendConfigFiles: types.ListNull(types.StringType),
+		ModulesOnlyUpdate:  types.BoolNull(),
+		PrefetchProviders:  types.BoolNull(),
+		ChangeDiagram:      types.BoolNull(),
+		MaxResources:       types.Int64Null(),
+		OutputsChanged:     types.MapNull(types.ObjectType{AttrTypes: applyOutputChangeAttrTypes}),
+		DiagramMermaid:     types.StringNull(),
+		SkipUnchanged:      types.BoolNull(),
+		Skipped:            types.BoolNull(),
+		DesiredState:       types.StringNull(),
+		Suspended:          types.BoolNull(),
+		ReadRunsPlan:       types.BoolNull(),
+		VerifyOutputs:      types.ListNull(types.StringType),
+		PublishOutputs:     types.MapNull(types.StringType),
+		PendingAdd:         types.Int64Null(),
+		PendingChange:      types.Int64Null(),
+		PendingDestroy:     types.Int64Null(),
+		RootDir:            types.StringNull(),
+		RepairLockfile:     types.BoolNull(),
+		CleanupOnDelete:    types.BoolNull(),
+		PreviewDestroy:     types.BoolNull(),
+		RelativePath:       types.StringNull(),
+		FullRefreshEvery:   types.StringNull(),
+		HostAffinity:       types.BoolNull(),
+		Offline:            types.BoolNull(),
+		Args:               types.ListNull(types.StringType),
+		Id:                 types.StringNull(),
+		Workspace:          types.StringNull(),
+		WorkspaceName:      types.StringValue("default"),
+		AllowedProviders:   types.ListNull(types.StringType),
+		DeniedProvisioners: types.ListNull(types.StringType),
+		AllowRecursion:     types.BoolNull(),
+		Modules:            types.ListNull(types.ObjectType{AttrTypes: applyModuleAttrTypes}),
+		PlanFile:           types.StringNull(),
+		PlanFileHash:       types.StringNull(),
+		ApprovalFile:       types.StringNull(),
+		ApprovalTimeout:    types.StringNull(),
+		PlanArtifactURL:    types.StringNull(),
+		ConsoleURL:         types.StringNull(),
+		RunID:              types.StringNull(),
+		EffectiveCommands:  types.ListNull(types.ObjectType{AttrTypes: applyEffectiveCommandAttrTypes}),
+		CrashDestination:   types.StringNull(),
+		WorkingDirAbs:      types.StringNull(),
+		Host:               types.StringNull(),
+		EnvironmentHash:    types.StringNull(),
+		InputManifest:      types.StringNull(),
+		ComputePlanHash:    types.BoolNull(),
+		PlanHash:           types.StringNull(),
+		SuppressWarnings:   types.ListNull(types.StringType),
+		Checks:             types.ListNull(types.ObjectType{AttrTypes: applyCheckAttrTypes}),
+		Stages:             types.ListNull(types.ObjectType{AttrTypes: applyStageAttrTypes}),
+		StageResults:       types.ListNull(types.ObjectType{AttrTypes: applyStageResultAttrTypes}),
+		Capture:            types.StringNull(),
+		CompactWarnings:    types.BoolNull(),
+		Concise:            types.BoolNull(),
+		Parallelism:        types.Int64Null(),
+		Output:             types.StringNull(),
+		LastError:          types.ObjectNull(applyLastErrorAttrTypes),
+	}
+}
+
+func TestDoApplyMaxResources(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	m := testApplyModel(dir)
+	m.MaxResources = types.Int64Value(2)
+	plan := `{"planned_values":{"root_module":{"resources":[{"mode":"managed"},{"mode":"data"}],"child_modules":[{"resources":[{"mode":"managed"}%s]}]}}}`
+
+	for extra, ok := range map[string]bool{
+		"":                    true,
+		`,{"mode":"managed"}`: false,
+	} {
+		show := "show -json PLAN"
+		fake := &fakeRunner{outputs: map[string]string{show: fmt.Sprintf(plan, extra)}}
+		r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+		_, _, err := r.doApply(context.Background(), &m)
+		applied := slices.Contains(fake.commands, "apply -auto-approve PLAN")
+		if ok && (err != nil || !applied) {
+			t.Errorf("within budget: doApply = %v, applied %t, want applied", err, applied)
+		}
+		var pe *phaseError
+		if !ok && (!errors.As(err, &pe) || pe.Phase != "max_resources" || applied) {
+			t.Errorf("over budget: doApply = %v, applied %t, want a max_resources error", err, applied)
+		}
+	}
+}
+
+func TestDoApplyExpectedResources(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	m := testApplyModel(dir)
+	m.Expected = &ApplyExpectedModel{
+		Addresses:  types.ListValueMust(types.StringType, []attr.Value{types.StringValue("null_resource.a")}),
+		TypeCounts: types.MapNull(types.Int64Type),
+		Match:      types.StringNull(),
+	}
+
+	for state, ok := range map[string]bool{
+		"null_resource.a\nnull_resource.b\n": true,
+		"null_resource.b\n":                  false,
+	} {
+		fake := &fakeRunner{outputs: map[string]string{"state list": state}}
+		r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+		_, _, err := r.doApply(context.Background(), &m)
+		if ok && err != nil {
+			t.Errorf("state %q: %v", state, err)
+		} else if !ok && (err == nil || newLastError(err).Phase != "expected_resources") {
+			t.Errorf("state %q: expected expected_resources failure, got %v", state, err)
+		}
+		if diff := cmp.Diff([]string{"init", "apply -auto-approve", "state list"}, fake.commands); diff != "" {
+			t.Errorf("commands (-want,+got): %s", diff)
+		}
+	}
+}
+
+func TestDoApplyChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks use /bin/sh")
+	}
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	m := testApplyModel(dir)
+	m.Checks = types.ListValueMust(types.ObjectType{AttrTypes: applyCheckAttrTypes}, []attr.Value{
+		types.ObjectValueMust(applyCheckAttrTypes, map[string]attr.Value{
+			"command":     types.StringValue(`test "$PTERAFORM_OUTPUT_value" = cool`),
+			"interpreter": types.ListNull(types.StringType),
+		}),
+	})
+
+	for value, ok := range map[string]bool{
+		"cool":   true,
+		"uncool": false,
+	} {
+		fake := &fakeRunner{outputs: map[string]string{
+			"output -json": fmt.Sprintf(`{"value":{"sensitive":false,"type":"string","value":%q}}`, value),
+		}}
+		r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+		_, _, err := r.doApply(context.Background(), &m)
+		if ok && err != nil {
+			t.Errorf("value %q: %v", value, err)
+		} else if !ok && (err == nil || newLastError(err).Phase != "checks") {
+			t.Errorf("value %q: expected checks failure, got %v", value, err)
+		}
+		if diff := cmp.Diff([]string{"init", "apply -auto-approve", "output -json"}, fake.commands); diff != "" {
+			t.Errorf("commands (-want,+got): %s", diff)
+		}
+	}
+}
+
+func TestDoApplyEventSink(t *testing.T) {
+	var mu sync.Mutex
+	var got, runIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, string(b))
+		runIDs = append(runIDs, r.Header.Get(runIDHeader))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+	m := testApplyModel(dir)
+	m.EventSink = &ApplyEventSinkModel{
+		URL:     types.StringValue(srv.URL),
+		Headers: types.MapValueMust(types.StringType, map[string]attr.Value{"Authorization": types.StringValue("Bearer token")}),
+	}
+	events := `{"@level":"info","@message":"Apply complete!","type":"change_summary"}`
+	fake := &fakeRunner{outputs: map[string]string{"apply -auto-approve -json": "not an event\n" + events}}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	if _, _, err := r.doApply(context.Background(), &m); err != nil {
+		t.Fatalf("doApply: %v", err)
+	}
+	if diff := cmp.Diff([]string{events}, got); diff != "" {
+		t.Errorf("posted events (-want,+got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{m.RunID.ValueString()}, runIDs); diff != "" || m.RunID.ValueString() == "" {
+		t.Errorf("posted run IDs (-want,+got): %s", diff)
+	}
+}
+
+func TestDoApplyRepairLockfile(t *testing.T) {
+	for _, c := range []struct {
+		desc    string
+		repair  bool
+		output  string
+		wantErr bool
+		want    []string
+	}{{
+		desc:   "repaired",
+		repair: true,
+		output: "Error: the cached package for registry.terraform.io/hashicorp/null 3.2.1 does not match any of the checksums recorded in the dependency lock file",
+		want:   []string{"init", "init", "apply -auto-approve"},
+	}, {
+		desc:    "not enabled",
+		output:  "Error: the cached package for registry.terraform.io/hashicorp/null 3.2.1 does not match any of the checksums recorded in the dependency lock file",
+		wantErr: true,
+		want:    []string{"init"},
+	}, {
+		desc:    "other error",
+		repair:  true,
+		output:  "Error: Unsupported block type",
+		wantErr: true,
+		want:    []string{"init"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"main.tf": "", lockFileName: "corrupt"})
+			m := testApplyModel(dir)
+			m.RepairLockfile = types.BoolValue(c.repair)
+
+			fake := &fakeRunner{outputs: map[string]string{"init": c.output}, failures: map[string]int{"init": 1}}
+			r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+			_, warnings, err := r.doApply(context.Background(), &m)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("doApply: %v, want error %t", err, c.wantErr)
+			}
+			if diff := cmp.Diff(c.want, fake.commands); diff != "" {
+				t.Errorf("commands (-want,+got): %s", diff)
+			}
+			_, statErr := os.Stat(filepath.Join(dir, lockFileName))
+			if repaired := os.IsNotExist(statErr); repaired != !c.wantErr {
+				t.Errorf("lock file removed = %t, want %t", repaired, !c.wantErr)
+			}
+			if got, want := warnings.WarningsCount() == 1, !c.wantErr; got != want {
+				t.Errorf("warned = %t, want %t: %v", got, want, warnings)
+			}
+		})
+	}
+}
+
+func TestDoApplyCommands(t *testing.T) {
+	const plan = "PLAN"
+
+	for _, c := range []struct {
+		desc   string
+		modify func(m *ApplyResourceModel, dir string)
+		want   []string
+	}{{
+		desc: "default",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Args = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("-var=value=cool")})
+		},
+		want: []string{"init", "apply -auto-approve -var=value=cool"},
+	}, {
+		desc: "variables",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Variables = types.MapValueMust(types.StringType, map[string]attr.Value{
+				"b": types.StringValue("line one\nline \"two\""),
+				"a": types.StringValue(`{"x": 1}`),
+			})
+			m.Args = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("-var=a=override")})
+		},
+		want: []string{"init", "apply -auto-approve -var=a={\"x\": 1} -var=b=line one\nline \"two\" -var=a=override"},
+	}, {
+		desc: "default tags",
+		modify: func(m *ApplyResourceModel, dir string) {
+			if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`variable "tags" { type = map(string) }`), 0644); err != nil {
+				t.Fatal(err)
+			}
+		},
+		want: []string{"init", `apply -auto-approve -var=tags={"owner":"platform"}`},
+	}, {
+		desc: "workspace",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.WorkspaceName = types.StringValue("staging")
+		},
+		want: []string{"init", "workspace list", "workspace new staging", "apply -auto-approve"},
+	}, {
+		desc: "modules only update",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.ModulesOnlyUpdate = types.BoolValue(true)
+			if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := recordInit(dir, nil, 0); err != nil {
+				t.Fatal(err)
+			}
+		},
+		want: []string{"get -update", "apply -auto-approve"},
+	}, {
+		desc: "backend config",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.BackendConfigFiles = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("prod.tfbackend")})
+			m.BackendConfig = types.MapValueMust(types.StringType, map[string]attr.Value{
+				"key":    types.StringValue("network/terraform.tfstate"),
+				"bucket": types.StringValue("state"),
+			})
+		},
+		want: []string{"init -backend-config=prod.tfbackend -backend-config=bucket=state -backend-config=key=network/terraform.tfstate", "apply -auto-approve"},
+	}, {
+		desc: "modules only update with changed backend config",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.ModulesOnlyUpdate = types.BoolValue(true)
+			if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := recordInit(dir, []string{"-backend-config=key=old"}, 0); err != nil {
+				t.Fatal(err)
+			}
+			m.BackendConfig = types.MapValueMust(types.StringType, map[string]attr.Value{"key": types.StringValue("new")})
+		},
+		want: []string{"init -backend-config=key=new", "apply -auto-approve"},
+	}, {
+		desc: "absent",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.DesiredState = types.StringValue("absent")
+			m.Expected = &ApplyExpectedModel{
+				Addresses:  types.ListValueMust(types.StringType, []attr.Value{types.StringValue("null_resource.a")}),
+				TypeCounts: types.MapNull(types.Int64Type),
+				Match:      types.StringNull(),
+			}
+		},
+		want: []string{"init", "apply -auto-approve -destroy"},
+	}, {
+		desc: "suspended",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.DesiredState = types.StringValue("present")
+			m.Suspended = types.BoolValue(true)
+			// Nothing is left to verify.
+			m.Expected = &ApplyExpectedModel{
+				Addresses:  types.ListValueMust(types.StringType, []attr.Value{types.StringValue("null_resource.a")}),
+				TypeCounts: types.MapNull(types.Int64Type),
+				Match:      types.StringNull(),
+			}
+		},
+		want: []string{"init", "apply -auto-approve -destroy"},
+	}, {
+		desc: "capture",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Capture = types.StringValue("errors")
+		},
+		want: []string{"init", "apply -auto-approve -json"},
+	}, {
+		desc: "human output options",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Capture = types.StringValue("human")
+			m.CompactWarnings = types.BoolValue(true)
+			m.Concise = types.BoolValue(true)
+		},
+		want: []string{"init", "apply -auto-approve -compact-warnings -concise"},
+	}, {
+		desc: "parallelism",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Parallelism = types.Int64Value(30)
+		},
+		want: []string{"init", "apply -auto-approve -parallelism=30"},
+	}, {
+		desc: "parallelism destroying",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Parallelism = types.Int64Value(2)
+			m.DesiredState = types.StringValue("absent")
+		},
+		want: []string{"init", "apply -auto-approve -parallelism=2 -destroy"},
+	}, {
+		desc: "plan file",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.PlanFile = types.StringValue("reviewed.tfplan")
+			if err := os.WriteFile(filepath.Join(dir, "reviewed.tfplan"), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		},
+		want: []string{"init", "apply -auto-approve reviewed.tfplan"},
+	}, {
+		desc: "plan file already applied",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.PlanFile = types.StringValue("reviewed.tfplan")
+			if err := os.WriteFile(filepath.Join(dir, "reviewed.tfplan"), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			// The SHA-256 digest of the empty plan.
+			m.appliedPlan = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		},
+		want: []string{"init"},
+	}, {
+		desc: "attestation",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Attestation = &ApplyAttestationModel{
+				Path:           types.StringValue(filepath.Join(dir, "attestation.json")),
+				SigningKeyFile: types.StringNull(),
+			}
+		},
+		want: []string{"init", "plan -out=" + plan, "apply -auto-approve " + plan},
+	}, {
+		desc: "skip refresh",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.skipRefresh = true
+		},
+		want: []string{"init", "apply -auto-approve -refresh=false"},
+	}, {
+		desc: "offline",
+		modify: func(m *ApplyResourceModel, dir string) {
+			m.Offline = types.BoolValue(true)
+		},
+		want: []string{"init -plugin-dir=/mirror", "apply -auto-approve"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+			m := testApplyModel(dir)
+			c.modify(&m, dir)
+
+			fake := &fakeRunner{}
+			r := &ApplyResource{
+				newRunner: func(resourceLimits) runner { return fake },
+				provider:  &providerData{DefaultTags: map[string]string{"owner": "platform"}, DefaultTagsVariable: defaultTagsVariable, PluginDirs: []string{"/mirror"}},
+			}
+			if _, _, err := r.doApply(context.Background(), &m); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, fake.commands); diff != "" {
+				t.Errorf("commands (-want,+got): %s", diff)
+			}
+		})
+	}
+}
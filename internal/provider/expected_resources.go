@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// expectedResources is what the nested state is expected to contain after
+// an apply.
+type expectedResources struct {
+	// Addresses are resource addresses, like module.vpc.aws_vpc.this, that
+	// must be in the state.
+	Addresses []string
+	// TypeCounts are how many resources of each type must be in the state.
+	TypeCounts map[string]int64
+	// Exact is whether the state must contain exactly these, rather than at
+	// least these.
+	Exact bool
+}
+
+// indexPattern matches the instance keys in a resource address.
+var indexPattern = regexp.MustCompile(`\[("(?:[^"\\]|\\.)*"|[^\]]*)\]`)
+
+// resourceType returns the type of the resource at addr, and whether it's a
+// managed resource rather than a data source.
+func resourceType(addr string) (string, bool) {
+	parts := strings.Split(indexPattern.ReplaceAllString(addr, ""), ".")
+	for len(parts) >= 2 && parts[0] == "module" {
+		parts = parts[2:]
+	}
+	if len(parts) >= 3 && parts[0] == "data" {
+		return parts[1], false
+	}
+	if len(parts) >= 2 {
+		return parts[0], true
+	}
+	return "", false
+}
+
+// check checks the managed resources in stateList, the output of terraform
+// state list, against e.
+func (e expectedResources) check(stateList string) error {
+	present := map[string]bool{}
+	counts := map[string]int64{}
+	for _, addr := range strings.Fields(stateList) {
+		typ, managed := resourceType(addr)
+		if !managed {
+			continue
+		}
+		present[addr] = true
+		counts[typ]++
+	}
+
+	var problems []string
+	expected := map[string]bool{}
+	for _, addr := range e.Addresses {
+		expected[addr] = true
+		if !present[addr] {
+			problems = append(problems, fmt.Sprintf("%s is missing", addr))
+		}
+	}
+	if e.Exact && len(e.Addresses) > 0 {
+		for addr := range present {
+			if !expected[addr] {
+				problems = append(problems, fmt.Sprintf("%s is not expected", addr))
+			}
+		}
+	}
+	for typ, want := range e.TypeCounts {
+		if got := counts[typ]; got < want || (e.Exact && got != want) {
+			problems = append(problems, fmt.Sprintf("%d %s resources, expected %d", got, typ, want))
+		}
+	}
+	if e.Exact && len(e.TypeCounts) > 0 {
+		for typ, got := range counts {
+			if _, ok := e.TypeCounts[typ]; !ok {
+				problems = append(problems, fmt.Sprintf("%d %s resources, expected none", got, typ))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("nested state does not match expected_resources:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceType(t *testing.T) {
+	for addr, want := range map[string]struct {
+		typ     string
+		managed bool
+	}{
+		"null_resource.a":                           {"null_resource", true},
+		`aws_instance.web["a.b"]`:                   {"aws_instance", true},
+		"aws_instance.web[0]":                       {"aws_instance", true},
+		`module.vpc["us.east"].aws_vpc.this`:        {"aws_vpc", true},
+		"module.a.module.b.aws_subnet.private[1]":   {"aws_subnet", true},
+		"data.aws_ami.ubuntu":                       {"aws_ami", false},
+		"module.vpc.data.aws_availability_zones.az": {"aws_availability_zones", false},
+	} {
+		typ, managed := resourceType(addr)
+		if typ != want.typ || managed != want.managed {
+			t.Errorf("resourceType(%q) = %q, %t, want %q, %t", addr, typ, managed, want.typ, want.managed)
+		}
+	}
+}
+
+func TestExpectedResourcesCheck(t *testing.T) {
+	state := `data.null_data_source.x
+null_resource.a
+null_resource.b
+module.m.random_pet.name
+`
+	for _, c := range []struct {
+		desc     string
+		expected expectedResources
+		problems []string
+	}{{
+		desc:     "at least addresses",
+		expected: expectedResources{Addresses: []string{"null_resource.a", "module.m.random_pet.name"}},
+	}, {
+		desc:     "missing address",
+		expected: expectedResources{Addresses: []string{"null_resource.c"}},
+		problems: []string{"null_resource.c is missing"},
+	}, {
+		desc:     "exact addresses",
+		expected: expectedResources{Addresses: []string{"null_resource.a", "module.m.random_pet.name"}, Exact: true},
+		problems: []string{"null_resource.b is not expected"},
+	}, {
+		desc:     "at least counts",
+		expected: expectedResources{TypeCounts: map[string]int64{"null_resource": 1}},
+	}, {
+		desc:     "too few",
+		expected: expectedResources{TypeCounts: map[string]int64{"null_resource": 3}},
+		problems: []string{"2 null_resource resources, expected 3"},
+	}, {
+		desc:     "exact counts",
+		expected: expectedResources{TypeCounts: map[string]int64{"null_resource": 1}, Exact: true},
+		problems: []string{"1 random_pet resources, expected none", "2 null_resource resources, expected 1"},
+	}, {
+		desc:     "exact everything",
+		expected: expectedResources{Addresses: []string{"null_resource.a", "null_resource.b", "module.m.random_pet.name"}, TypeCounts: map[string]int64{"null_resource": 2, "random_pet": 1}, Exact: true},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			err := c.expected.check(state)
+			if len(c.problems) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, p := range c.problems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("error %q does not mention %q", err, p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+)
+
+// failureClass classifies why terraform failed, so callers can tell a
+// configuration error from, say, a lost lock or a killed process.
+type failureClass string
+
+const (
+	// failureUsage is terraform rejecting its command line arguments.
+	failureUsage failureClass = "usage"
+	// failureDiagnostics is terraform reporting errors in the configuration,
+	// the plan, or from providers.
+	failureDiagnostics failureClass = "diagnostics"
+	// failureLock is terraform being unable to lock the state, usually
+	// because another operation holds the lock.
+	failureLock failureClass = "lock"
+	// failureCancelled is terraform being interrupted because the operation
+	// was cancelled or timed out.
+	failureCancelled failureClass = "cancelled"
+	// failureKilled is terraform being killed by a signal it didn't receive
+	// from the provider, most often by the kernel for running out of memory.
+	failureKilled failureClass = "killed"
+	// failureOther is any other failure.
+	failureOther failureClass = "other"
+)
+
+// describe returns a short description of the failure class, or "" for
+// failureOther.
+func (c failureClass) describe() string {
+	switch c {
+	case failureUsage:
+		return "invalid command line arguments"
+	case failureDiagnostics:
+		return "terraform reported errors"
+	case failureLock:
+		return "unable to lock the state"
+	case failureCancelled:
+		return "cancelled"
+	case failureKilled:
+		return "killed, possibly for running out of memory"
+	}
+	return ""
+}
+
+// lockFailurePattern matches terraform output reporting that it couldn't
+// lock the state.
+var lockFailurePattern = regexp.MustCompile(`(?i)Error (?:acquiring|locking) (?:the )?state(?: lock)?\b`)
+
+// usageFailurePattern matches terraform output rejecting its arguments.
+var usageFailurePattern = regexp.MustCompile(`(?m)^Usage: terraform |flag provided but not defined|Too many command line arguments|invalid value ".*" for flag`)
+
+// classifyFailure classifies a failed terraform run from ctx, which it was
+// run with, its exit code, -1 if it was killed, and its output.
+func classifyFailure(ctx context.Context, exitCode int, output string) failureClass {
+	switch {
+	case ctx.Err() != nil:
+		return failureCancelled
+	case exitCode == -1:
+		return failureKilled
+	case lockFailurePattern.MatchString(output):
+		return failureLock
+	case usageFailurePattern.MatchString(output):
+		return failureUsage
+	case errorSummaryPattern.MatchString(output):
+		return failureDiagnostics
+	}
+	for _, e := range parseEvents(output) {
+		if e.Type == "diagnostic" && e.Diagnostic.Severity == "error" {
+			return failureDiagnostics
+		}
+	}
+	return failureOther
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, c := range []struct {
+		desc     string
+		ctx      context.Context
+		exitCode int
+		output   string
+		want     failureClass
+	}{{
+		desc:     "cancelled",
+		ctx:      cancelled,
+		exitCode: -1,
+		want:     failureCancelled,
+	}, {
+		desc:     "killed",
+		exitCode: -1,
+		output:   "Refreshing state...",
+		want:     failureKilled,
+	}, {
+		desc:     "lock",
+		exitCode: 1,
+		output:   "╷\n│ Error: Error acquiring the state lock\n│ \n│ Lock Info:\n│   ID: 1234\n╵\n",
+		want:     failureLock,
+	}, {
+		desc:     "usage",
+		exitCode: 1,
+		output:   "flag provided but not defined: -nope\nUsage: terraform [global options] apply [options] [PLAN]\n",
+		want:     failureUsage,
+	}, {
+		desc:     "diagnostics",
+		exitCode: 1,
+		output:   "╷\n│ Error: Invalid reference\n╵\n",
+		want:     failureDiagnostics,
+	}, {
+		desc:     "json diagnostics",
+		exitCode: 1,
+		output:   `{"@level":"error","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid reference"}}` + "\n",
+		want:     failureDiagnostics,
+	}, {
+		desc:     "other",
+		exitCode: 2,
+		output:   "something went wrong",
+		want:     failureOther,
+	}} {
+		ctx := c.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if got := classifyFailure(ctx, c.exitCode, c.output); got != c.want {
+			t.Errorf("%s: classifyFailure = %s, want %s", c.desc, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// defaultFileMode is the permissions of files the provider writes when the
+// provider's file_permissions isn't set.
+const defaultFileMode os.FileMode = 0o644
+
+// secretFileMode is the permissions of files the provider writes that may
+// contain secrets, like provider credentials, when the provider's
+// file_permissions isn't set.
+const secretFileMode os.FileMode = 0o600
+
+// parseFileMode parses octal permissions, like "0600".
+func parseFileMode(s string) (os.FileMode, error) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0o777 {
+		return 0, fmt.Errorf("file permissions must be octal, like 0600, got %q", s)
+	}
+	return os.FileMode(n), nil
+}
+
+// fileMode returns the permissions of files the provider writes for this
+// resource, or 0 if they aren't configured.
+func (r *ApplyResource) fileMode() os.FileMode {
+	return r.provider.fileMode()
+}
+
+// fileMode returns the permissions of files the provider writes, or 0 if
+// they aren't configured. It may be called on a nil providerData.
+func (pd *providerData) fileMode() os.FileMode {
+	if pd == nil {
+		return 0
+	}
+	return pd.FileMode
+}
+
+// modeOr returns mode, or fallback if mode is 0.
+func modeOr(mode, fallback os.FileMode) os.FileMode {
+	if mode == 0 {
+		return fallback
+	}
+	return mode
+}
+
+// writeFile writes b to fn like os.WriteFile, but sets its permissions to
+// mode even if it already exists.
+func writeFile(fn string, b []byte, mode os.FileMode) error {
+	if err := os.WriteFile(fn, b, mode); err != nil {
+		return err
+	}
+	return os.Chmod(fn, mode)
+}
+
+// chmodFile sets the permissions of fn, a file the provider or terraform
+// wrote, to mode, unless mode is 0.
+func chmodFile(fn string, mode os.FileMode) error {
+	if mode == 0 {
+		return nil
+	}
+	return os.Chmod(fn, mode)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParseFileMode(t *testing.T) {
+	for s, want := range map[string]os.FileMode{"0600": 0o600, "640": 0o640, "0777": 0o777} {
+		if got, err := parseFileMode(s); err != nil || got != want {
+			t.Errorf("parseFileMode(%s) = %o, %v, want %o", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "rw-------", "0800", "01777"} {
+		if _, err := parseFileMode(s); err == nil {
+			t.Errorf("parseFileMode(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestAttestationFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't supported on Windows")
+	}
+	fn := filepath.Join(t.TempDir(), "att.json")
+	if err := os.WriteFile(fn, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := &attestation{Path: fn, Mode: 0o600, startedOn: time.Now()}
+	if err := a.write(t.TempDir(), nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fi.Mode().Perm(); got != 0o600 {
+		t.Errorf("attestation has permissions %o, want 0600", got)
+	}
+}
+
+func TestGeneratedFileModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't supported on Windows")
+	}
+	perm := func(fn string) os.FileMode {
+		t.Helper()
+		fi, err := os.Stat(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Mode().Perm()
+	}
+	dir := t.TempDir()
+	if err := writePIDFile(dir, "", os.Getpid(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := perm(filepath.Join(dir, pidFileName(""))); got != 0o600 {
+		t.Errorf("pid file has permissions %o, want 0600", got)
+	}
+	if err := appendJournal(dir, journalEntry{RunID: "r1", Event: "started"}, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if got := perm(filepath.Join(dir, journalFileName)); got != 0o640 {
+		t.Errorf("journal has permissions %o, want 0640", got)
+	}
+	if err := recordInit(dir, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := perm(filepath.Join(dir, initMarkerName)); got != defaultFileMode {
+		t.Errorf("init marker has permissions %o, want the default %o", got, defaultFileMode)
+	}
+	// Existing files get the configured permissions, too.
+	if err := recordInit(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := perm(filepath.Join(dir, initMarkerName)); got != 0o600 {
+		t.Errorf("rewritten init marker has permissions %o, want 0600", got)
+	}
+}
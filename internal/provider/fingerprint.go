@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// setFingerprint records where and with what settings the nested apply runs,
+// so that differences between machines can be investigated.
+func (r *ApplyResource) setFingerprint(m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.WorkingDirAbs, m.Host, m.EnvironmentHash = types.StringNull(), types.StringNull(), types.StringNull()
+	if abs, err := filepath.Abs(m.WorkingDir.ValueString()); err != nil {
+		diags.AddWarning("Unable to Resolve Working Directory", err.Error())
+	} else {
+		m.WorkingDirAbs = types.StringValue(abs)
+	}
+	if host, err := os.Hostname(); err != nil {
+		diags.AddWarning("Unable to Get Hostname", err.Error())
+	} else {
+		m.Host = types.StringValue(host)
+	}
+	// An error is reported when the apply runs. The run ID is left out,
+	// since it's different every time.
+	if env, err := r.env(""); err == nil {
+		env = append(r.provider.inherit().filter(os.Environ()), env...)
+		m.EnvironmentHash = types.StringValue(environmentHash(append(env, m.environmentEnv()...)))
+	}
+	return diags
+}
+
+// environmentHash returns a digest of the variables in environ that affect
+// terraform, which are those whose names start with TF_, and those set by
+// pteraform. As with exec.Cmd, the last value of each variable is used.
+func environmentHash(environ []string) string {
+	vars := map[string]string{}
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, "TF_") || strings.HasPrefix(k, "PTERAFORM_") {
+			vars[k] = v
+		}
+	}
+	names := make([]string, 0, len(vars))
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, k := range names {
+		fmt.Fprintf(h, "%s=%s\x00", k, vars[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,33 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "testing"
+
+func TestEnvironmentHash(t *testing.T) {
+	base := environmentHash([]string{"TF_VAR_region=us-east1", "PTERAFORM_NESTING_DEPTH=1"})
+	for _, c := range []struct {
+		desc    string
+		environ []string
+		same    bool
+	}{{
+		desc:    "order and unrelated variables",
+		environ: []string{"HOME=/root", "PTERAFORM_NESTING_DEPTH=1", "TF_VAR_region=us-east1", "SHLVL=2"},
+		same:    true,
+	}, {
+		desc:    "overridden",
+		environ: []string{"TF_VAR_region=us-west1", "TF_VAR_region=us-east1", "PTERAFORM_NESTING_DEPTH=1"},
+		same:    true,
+	}, {
+		desc:    "changed value",
+		environ: []string{"TF_VAR_region=us-west1", "PTERAFORM_NESTING_DEPTH=1"},
+	}, {
+		desc:    "added variable",
+		environ: []string{"TF_VAR_region=us-east1", "PTERAFORM_NESTING_DEPTH=1", "TF_LOG=debug"},
+	}} {
+		if got := environmentHash(c.environ) == base; got != c.same {
+			t.Errorf("%s: same hash = %t, want %t", c.desc, got, c.same)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// hostKey is the private state key the hostname of the machine that last
+// applied a nested configuration with local state is stored under.
+const hostKey = "host"
+
+// localState reports whether the nested configuration in dir keeps its state
+// in the working directory, because it has no backend or the local backend.
+// terraform init records the configured backend in .terraform.
+func localState(dir string) bool {
+	b, err := os.ReadFile(filepath.Join(dir, ".terraform", "terraform.tfstate"))
+	if err != nil {
+		return true
+	}
+	var st struct {
+		Backend struct {
+			Type string `json:"type"`
+		} `json:"backend"`
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return true
+	}
+	return st.Backend.Type == "" || st.Backend.Type == "local"
+}
+
+// checkHost returns an error if recorded, the hostname stored in private
+// state by the last apply, isn't host. Nothing is recorded for nested
+// configurations with a remote backend, which can be run from anywhere.
+func checkHost(recorded []byte, host string) error {
+	var last string
+	if len(recorded) == 0 || json.Unmarshal(recorded, &last) != nil || last == "" || last == host {
+		return nil
+	}
+	return fmt.Errorf("the nested configuration keeps its state locally, and was last applied on %s, not %s, so its state here may be missing or stale. Run it on %s, or configure a remote backend in the nested configuration so its state is shared", last, host, last)
+}
+
+// hostAffinity returns an error diagnostic if host_affinity is set and the
+// nested configuration in dir, with local state, was last applied on another
+// host.
+func (m *ApplyResourceModel) hostAffinity(ctx context.Context, private privateStateReader) diag.Diagnostics {
+	if !m.HostAffinity.ValueBool() {
+		return nil
+	}
+	recorded, diags := private.GetKey(ctx, hostKey)
+	if diags.HasError() {
+		return diags
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to get hostname, got error: %s", err))
+		return diags
+	}
+	if err := checkHost(recorded, host); err != nil {
+		diags.AddAttributeError(path.Root("host_affinity"), "Host Mismatch", err.Error())
+	}
+	return diags
+}
+
+// recordHost stores the hostname of this machine, if the nested
+// configuration in dir has local state, or clears it otherwise.
+func recordHost(ctx context.Context, private privateState, dir string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !localState(dir) {
+		return private.SetKey(ctx, hostKey, []byte("null"))
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		diags.AddWarning("Unable to Get Hostname", err.Error())
+		return diags
+	}
+	b, err := json.Marshal(host)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record host, got error: %s", err))
+		return diags
+	}
+	return private.SetKey(ctx, hostKey, b)
+}
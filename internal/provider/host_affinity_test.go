@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalState(t *testing.T) {
+	for _, c := range []struct {
+		desc  string
+		files map[string]string
+		want  bool
+	}{{
+		desc:  "not initialized",
+		files: map[string]string{"main.tf": ""},
+		want:  true,
+	}, {
+		desc:  "local backend",
+		files: map[string]string{".terraform/terraform.tfstate": `{"version": 3, "backend": {"type": "local"}}`},
+		want:  true,
+	}, {
+		desc:  "remote backend",
+		files: map[string]string{".terraform/terraform.tfstate": `{"version": 3, "backend": {"type": "s3"}}`},
+	}} {
+		if got := localState(writeFiles(t, c.files)); got != c.want {
+			t.Errorf("%s: localState = %t, want %t", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, recorded := range []string{"", "null", `"build-1"`} {
+		if err := checkHost([]byte(recorded), "build-1"); err != nil {
+			t.Errorf("checkHost(%s): %v", recorded, err)
+		}
+	}
+	err := checkHost([]byte(`"laptop"`), "build-1")
+	if err == nil || !strings.Contains(err.Error(), "last applied on laptop, not build-1") {
+		t.Errorf("checkHost from another host: got %v", err)
+	}
+}
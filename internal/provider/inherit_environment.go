@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// InheritEnvironmentModel describes the provider's inherit_environment
+// block.
+type InheritEnvironmentModel struct {
+	Allow types.List `tfsdk:"allow"`
+	Deny  types.List `tfsdk:"deny"`
+}
+
+// inheritEnvironment is which of the provider's environment variables
+// nested terraform inherits.
+type inheritEnvironment struct {
+	// allow, if non-nil, are patterns matching the only variables
+	// inherited.
+	allow []string
+	// deny are patterns matching variables that aren't inherited, even if
+	// they're allowed.
+	deny []string
+}
+
+// newInheritEnvironment returns an inheritEnvironment for the patterns
+// allow and deny, which are matched against variable names like
+// path.Match, so AWS_* matches all of the variables starting with AWS_.
+// allow may be nil to inherit all but the variables that configure the
+// outer run.
+func newInheritEnvironment(allow, deny []string) (inheritEnvironment, error) {
+	for _, p := range append(append([]string(nil), allow...), deny...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return inheritEnvironment{}, fmt.Errorf("invalid pattern %q: %s", p, err)
+		}
+	}
+	return inheritEnvironment{allow: allow, deny: deny}, nil
+}
+
+// matchAny reports whether name matches any of patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubbed reports whether the variable name is one that configures the
+// outer terraform run, which nested terraform would otherwise also obey:
+// TF_CLI_ARGS and TF_CLI_ARGS_name add arguments to every command, and
+// TF_WORKSPACE and TF_DATA_DIR point it at the outer run's workspace and
+// .terraform directory.
+func scrubbed(name string) bool {
+	return name == "TF_CLI_ARGS" || strings.HasPrefix(name, "TF_CLI_ARGS_") || name == "TF_WORKSPACE" || name == "TF_DATA_DIR"
+}
+
+// filter returns the variables of environ nested terraform inherits. Those
+// that configure the outer run are only inherited if they're allowed
+// explicitly, and denied variables never are.
+func (e inheritEnvironment) filter(environ []string) []string {
+	var out []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if e.inherits(name) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// inherits reports whether the variable name is inherited.
+func (e inheritEnvironment) inherits(name string) bool {
+	if matchAny(e.deny, name) {
+		return false
+	}
+	if e.allow == nil {
+		return !scrubbed(name)
+	}
+	return matchAny(e.allow, name)
+}
+
+// inherit returns which of the provider's environment variables nested
+// terraform inherits. It may be called on a nil providerData, for a
+// provider that hasn't been configured.
+func (pd *providerData) inherit() inheritEnvironment {
+	if pd == nil {
+		return inheritEnvironment{}
+	}
+	return pd.InheritEnvironment
+}
@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInheritEnvironment(t *testing.T) {
+	environ := []string{"HOME=/home/me", "PATH=/bin", "TF_CLI_ARGS=-no-color", "TF_CLI_ARGS_plan=-refresh=false", "TF_WORKSPACE=prod", "TF_DATA_DIR=/outer/.terraform", "TF_LOG=debug"}
+	for _, c := range []struct {
+		desc    string
+		inherit inheritEnvironment
+		want    []string
+	}{{
+		desc: "default",
+		want: []string{"HOME=/home/me", "PATH=/bin", "TF_LOG=debug"},
+	}, {
+		desc:    "allow",
+		inherit: inheritEnvironment{allow: []string{"PATH", "TF_WORKSPACE"}},
+		want:    []string{"PATH=/bin", "TF_WORKSPACE=prod"},
+	}, {
+		desc:    "allow none",
+		inherit: inheritEnvironment{allow: []string{}},
+	}, {
+		desc:    "patterns",
+		inherit: inheritEnvironment{allow: []string{"HOME", "TF_*"}, deny: []string{"TF_CLI_ARGS*"}},
+		want:    []string{"HOME=/home/me", "TF_WORKSPACE=prod", "TF_DATA_DIR=/outer/.terraform", "TF_LOG=debug"},
+	}, {
+		desc:    "deny",
+		inherit: inheritEnvironment{deny: []string{"TF_*", "HOME"}},
+		want:    []string{"PATH=/bin"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, c.inherit.filter(environ)); diff != "" {
+				t.Errorf("filter (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewInheritEnvironment(t *testing.T) {
+	if _, err := newInheritEnvironment([]string{"AWS_*"}, []string{"[TF_"}); err == nil {
+		t.Error("newInheritEnvironment with an invalid pattern succeeded")
+	}
+}
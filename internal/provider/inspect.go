@@ -0,0 +1,487 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// moduleConfig is the subset of a Terraform module's configuration that the
+// provider inspects statically, without running terraform.
+type moduleConfig struct {
+	Dir               string
+	RequiredVersion   []string
+	RequiredProviders map[string]providerRequirement
+	Variables         map[string]*variableConfig
+	Outputs           map[string]*outputConfig
+	Resources         []resourceConfig
+	ModuleCalls       []moduleCall
+}
+
+// variableConfig is a variable block.
+type variableConfig struct {
+	Name        string
+	Type        string // the type constraint as written, or "" if none
+	Constraint  cty.Type
+	Defaults    *typeexpr.Defaults
+	Validations []variableValidation
+	Description string
+	Default     cty.Value // cty.NilVal if there is no default
+	Sensitive   bool
+	DeclRange   hcl.Range
+}
+
+// Required reports whether a value must be supplied for the variable.
+func (v *variableConfig) Required() bool {
+	return v.Default == cty.NilVal
+}
+
+// variableValidation is a validation block in a variable block.
+type variableValidation struct {
+	Condition    hcl.Expression
+	ErrorMessage hcl.Expression
+}
+
+// outputConfig is an output block.
+type outputConfig struct {
+	Name        string
+	Description string
+	Sensitive   bool
+}
+
+// providerRequirement is an entry in a required_providers block.
+type providerRequirement struct {
+	Source             string
+	VersionConstraints []string
+}
+
+// resourceConfig is a resource or data block.
+type resourceConfig struct {
+	Mode         string // "managed" or "data"
+	Type         string
+	Name         string
+	Provider     string // local name of the provider, without alias
+	Provisioners []string
+	Pos          hcl.Pos
+	Filename     string
+}
+
+// Address returns the resource address relative to its module.
+func (r resourceConfig) Address() string {
+	if r.Mode == "data" {
+		return fmt.Sprintf("data.%s.%s", r.Type, r.Name)
+	}
+	return fmt.Sprintf("%s.%s", r.Type, r.Name)
+}
+
+// moduleCall is a module block.
+type moduleCall struct {
+	Name    string
+	Source  string
+	Version string
+}
+
+var rootSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "terraform"},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+	},
+}
+
+var terraformBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "required_version"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+}
+
+var variableBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "type"}, {Name: "default"}, {Name: "description"}, {Name: "sensitive"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "validation"}},
+}
+
+var validationBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "condition", Required: true}, {Name: "error_message"}},
+}
+
+var outputBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "description"}, {Name: "sensitive"}},
+}
+
+var resourceBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "provider"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "provisioner", LabelNames: []string{"type"}}},
+}
+
+var moduleBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "source"}, {Name: "version"}},
+}
+
+// configFiles returns the Terraform configuration files in dir, in the same
+// order terraform loads them.
+func configFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "#") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		if strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadModule parses the configuration files in dir.
+func loadModule(dir string) (*moduleConfig, error) {
+	files, err := configFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read configuration directory %s, got error: %s", dir, err)
+	}
+
+	mod := &moduleConfig{
+		Dir:               dir,
+		RequiredProviders: map[string]providerRequirement{},
+		Variables:         map[string]*variableConfig{},
+		Outputs:           map[string]*outputConfig{},
+	}
+	parser := hclparse.NewParser()
+	var diags hcl.Diagnostics
+	for _, fn := range files {
+		var f *hcl.File
+		var fdiags hcl.Diagnostics
+		if strings.HasSuffix(fn, ".json") {
+			f, fdiags = parser.ParseJSONFile(fn)
+		} else {
+			f, fdiags = parser.ParseHCLFile(fn)
+		}
+		diags = append(diags, fdiags...)
+		if f == nil {
+			continue
+		}
+		diags = append(diags, mod.decodeFile(f)...)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("Unable to parse configuration in %s, got error: %s", dir, diags.Error())
+	}
+	return mod, nil
+}
+
+func (m *moduleConfig) decodeFile(f *hcl.File) hcl.Diagnostics {
+	content, _, diags := f.Body.PartialContent(rootSchema)
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "terraform":
+			diags = append(diags, m.decodeTerraformBlock(block)...)
+		case "variable":
+			diags = append(diags, m.decodeVariableBlock(f, block)...)
+		case "output":
+			diags = append(diags, m.decodeOutputBlock(block)...)
+		case "resource", "data":
+			diags = append(diags, m.decodeResourceBlock(block)...)
+		case "module":
+			diags = append(diags, m.decodeModuleBlock(block)...)
+		}
+	}
+	return diags
+}
+
+func (m *moduleConfig) decodeTerraformBlock(block *hcl.Block) hcl.Diagnostics {
+	content, _, diags := block.Body.PartialContent(terraformBlockSchema)
+	if attr, ok := content.Attributes["required_version"]; ok {
+		if s, ok := staticString(attr.Expr); ok {
+			m.RequiredVersion = append(m.RequiredVersion, s)
+		}
+	}
+	for _, rp := range content.Blocks {
+		attrs, adiags := rp.Body.JustAttributes()
+		diags = append(diags, adiags...)
+		for name, attr := range attrs {
+			req := providerRequirement{}
+			// Legacy syntax: aws = "~> 4.0"
+			if s, ok := staticString(attr.Expr); ok {
+				req.VersionConstraints = append(req.VersionConstraints, s)
+				m.RequiredProviders[name] = req
+				continue
+			}
+			// Evaluate each key separately, since configuration_aliases
+			// contains references that can't be evaluated statically.
+			pairs, pdiags := hcl.ExprMap(attr.Expr)
+			diags = append(diags, pdiags...)
+			for _, pair := range pairs {
+				key, ok := staticString(pair.Key)
+				if !ok {
+					continue
+				}
+				v, ok := staticString(pair.Value)
+				if !ok {
+					continue
+				}
+				switch key {
+				case "source":
+					req.Source = v
+				case "version":
+					req.VersionConstraints = append(req.VersionConstraints, v)
+				}
+			}
+			m.RequiredProviders[name] = req
+		}
+	}
+	return diags
+}
+
+func (m *moduleConfig) decodeVariableBlock(f *hcl.File, block *hcl.Block) hcl.Diagnostics {
+	v := &variableConfig{Name: block.Labels[0], Constraint: cty.DynamicPseudoType, DeclRange: block.DefRange}
+	content, _, diags := block.Body.PartialContent(variableBlockSchema)
+	if attr, ok := content.Attributes["type"]; ok {
+		// In JSON the type constraint is a string; in native syntax it's the
+		// expression's source text.
+		expr := attr.Expr
+		if s, ok := staticString(attr.Expr); ok {
+			v.Type = s
+			var pdiags hcl.Diagnostics
+			expr, pdiags = hclsyntax.ParseExpression([]byte(s), attr.Expr.Range().Filename, attr.Expr.Range().Start)
+			diags = append(diags, pdiags...)
+		} else {
+			v.Type = string(attr.Expr.Range().SliceBytes(f.Bytes))
+		}
+		if expr != nil {
+			ty, defaults, tdiags := typeexpr.TypeConstraintWithDefaults(expr)
+			diags = append(diags, tdiags...)
+			v.Constraint, v.Defaults = ty, defaults
+		}
+	}
+	for _, vb := range content.Blocks {
+		vc, _, vdiags := vb.Body.PartialContent(validationBlockSchema)
+		diags = append(diags, vdiags...)
+		val := variableValidation{}
+		if attr, ok := vc.Attributes["condition"]; ok {
+			val.Condition = attr.Expr
+		}
+		if attr, ok := vc.Attributes["error_message"]; ok {
+			val.ErrorMessage = attr.Expr
+		}
+		if val.Condition != nil {
+			v.Validations = append(v.Validations, val)
+		}
+	}
+	if attr, ok := content.Attributes["default"]; ok {
+		val, vdiags := attr.Expr.Value(nil)
+		diags = append(diags, vdiags...)
+		v.Default = val
+	}
+	if attr, ok := content.Attributes["description"]; ok {
+		v.Description, _ = staticString(attr.Expr)
+	}
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		v.Sensitive = staticBool(attr.Expr)
+	}
+	m.Variables[v.Name] = v
+	return diags
+}
+
+func (m *moduleConfig) decodeOutputBlock(block *hcl.Block) hcl.Diagnostics {
+	o := &outputConfig{Name: block.Labels[0]}
+	content, _, diags := block.Body.PartialContent(outputBlockSchema)
+	if attr, ok := content.Attributes["description"]; ok {
+		o.Description, _ = staticString(attr.Expr)
+	}
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		o.Sensitive = staticBool(attr.Expr)
+	}
+	m.Outputs[o.Name] = o
+	return diags
+}
+
+// staticString evaluates expr without any variables or functions, and
+// returns its value if it is a known, non-null string.
+func staticString(expr hcl.Expression) (string, bool) {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+		return "", false
+	}
+	return v.AsString(), true
+}
+
+// staticBool evaluates expr without any variables or functions, and returns
+// true only if it is a known true bool.
+func staticBool(expr hcl.Expression) bool {
+	v, diags := expr.Value(nil)
+	return !diags.HasErrors() && v.IsKnown() && !v.IsNull() && v.Type() == cty.Bool && v.True()
+}
+
+func (m *moduleConfig) decodeResourceBlock(block *hcl.Block) hcl.Diagnostics {
+	r := resourceConfig{
+		Mode:     "managed",
+		Type:     block.Labels[0],
+		Name:     block.Labels[1],
+		Pos:      block.DefRange.Start,
+		Filename: block.DefRange.Filename,
+	}
+	if block.Type == "data" {
+		r.Mode = "data"
+	}
+	content, _, diags := block.Body.PartialContent(resourceBlockSchema)
+	if attr, ok := content.Attributes["provider"]; ok {
+		traversal, tdiags := hcl.AbsTraversalForExpr(attr.Expr)
+		diags = append(diags, tdiags...)
+		if len(traversal) > 0 {
+			r.Provider = traversal.RootName()
+		}
+	}
+	if r.Provider == "" {
+		// Implied provider: the resource type prefix.
+		r.Provider, _, _ = strings.Cut(r.Type, "_")
+	}
+	for _, p := range content.Blocks {
+		r.Provisioners = append(r.Provisioners, p.Labels[0])
+	}
+	m.Resources = append(m.Resources, r)
+	return diags
+}
+
+func (m *moduleConfig) decodeModuleBlock(block *hcl.Block) hcl.Diagnostics {
+	call := moduleCall{Name: block.Labels[0]}
+	content, _, diags := block.Body.PartialContent(moduleBlockSchema)
+	if attr, ok := content.Attributes["source"]; ok {
+		call.Source, _ = staticString(attr.Expr)
+	}
+	if attr, ok := content.Attributes["version"]; ok {
+		call.Version, _ = staticString(attr.Expr)
+	}
+	m.ModuleCalls = append(m.ModuleCalls, call)
+	return diags
+}
+
+// ProviderSource returns the fully-qualified source address of the provider
+// with the given local name in this module.
+func (m *moduleConfig) ProviderSource(local string) (string, error) {
+	if req, ok := m.RequiredProviders[local]; ok && req.Source != "" {
+		return normalizeProviderSource(req.Source)
+	}
+	if local == "terraform" {
+		return tfaddr.NewProvider(tfaddr.BuiltInProviderHost, tfaddr.BuiltInProviderNamespace, local).String(), nil
+	}
+	return tfaddr.NewProvider(tfaddr.DefaultProviderRegistryHost, "hashicorp", local).String(), nil
+}
+
+// normalizeProviderSource returns the fully-qualified form of a provider
+// source address, so that "hashicorp/null" and
+// "registry.terraform.io/hashicorp/null" compare equal.
+func normalizeProviderSource(s string) (string, error) {
+	p, err := tfaddr.ParseProviderSource(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid provider source %q: %s", s, err)
+	}
+	if p.Namespace == tfaddr.UnknownProviderNamespace {
+		p.Namespace = "hashicorp"
+	}
+	return p.String(), nil
+}
+
+// modulesManifest is the format of .terraform/modules/modules.json, written
+// by terraform init.
+type modulesManifest struct {
+	Modules []moduleManifestEntry `json:"Modules"`
+}
+
+// moduleManifestEntry describes one installed module.
+type moduleManifestEntry struct {
+	Key     string `json:"Key"`
+	Source  string `json:"Source"`
+	Version string `json:"Version,omitempty"`
+	Dir     string `json:"Dir"`
+}
+
+// readModulesManifest returns the modules installed by terraform init in
+// root, or nil if init hasn't installed any.
+func readModulesManifest(root string) ([]moduleManifestEntry, error) {
+	b, err := os.ReadFile(filepath.Join(root, ".terraform", "modules", "modules.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Unable to read modules.json, got error: %s", err)
+	}
+	var manifest modulesManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("Unable to parse modules.json, got error: %s", err)
+	}
+	sort.Slice(manifest.Modules, func(i, j int) bool { return manifest.Modules[i].Key < manifest.Modules[j].Key })
+	return manifest.Modules, nil
+}
+
+// moduleDirs returns the directories of every module in the configuration
+// rooted at root, including root itself. After terraform init this includes
+// remote modules; before it only local modules are found.
+func moduleDirs(root string) ([]string, error) {
+	installed, err := readModulesManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	if installed != nil {
+		dirs := []string{root}
+		for _, m := range installed {
+			if m.Key == "" {
+				continue
+			}
+			dirs = append(dirs, filepath.Join(root, m.Dir))
+		}
+		return dirs, nil
+	}
+
+	seen := map[string]bool{}
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			return nil
+		}
+		seen[dir] = true
+		mod, err := loadModule(dir)
+		if err != nil {
+			return err
+		}
+		for _, call := range mod.ModuleCalls {
+			if isLocalModuleSource(call.Source) {
+				if err := walk(filepath.Join(dir, call.Source)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for d := range seen {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
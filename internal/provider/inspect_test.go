@@ -0,0 +1,262 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadModule(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `
+terraform {
+  required_version = ">= 1.0"
+  required_providers {
+    null = {
+      source  = "hashicorp/null"
+      version = "~> 3.0"
+    }
+    legacy = "~> 1.0"
+  }
+}
+
+variable "name" {
+  type        = string
+  description = "The name."
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+output "id" {
+  value     = null_resource.x.id
+  sensitive = true
+}
+
+resource "null_resource" "x" {}
+`,
+		"vars.tf.json": `{"variable": {"count": {"type": "number", "sensitive": true}}}`,
+		".hidden.tf":   `this is not valid`,
+	})
+
+	mod, err := loadModule(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{">= 1.0"}, mod.RequiredVersion); diff != "" {
+		t.Errorf("RequiredVersion (-want,+got): %s", diff)
+	}
+	if diff := cmp.Diff(map[string]providerRequirement{
+		"null":   {Source: "hashicorp/null", VersionConstraints: []string{"~> 3.0"}},
+		"legacy": {VersionConstraints: []string{"~> 1.0"}},
+	}, mod.RequiredProviders); diff != "" {
+		t.Errorf("RequiredProviders (-want,+got): %s", diff)
+	}
+
+	type variable struct {
+		Type      string
+		Required  bool
+		Sensitive bool
+	}
+	got := map[string]variable{}
+	for name, v := range mod.Variables {
+		got[name] = variable{v.Type, v.Required(), v.Sensitive}
+	}
+	if diff := cmp.Diff(map[string]variable{
+		"name":  {Type: "string", Required: true},
+		"tags":  {Type: "map(string)"},
+		"count": {Type: "number", Required: true, Sensitive: true},
+	}, got); diff != "" {
+		t.Errorf("Variables (-want,+got): %s", diff)
+	}
+
+	if o := mod.Outputs["id"]; o == nil || !o.Sensitive {
+		t.Errorf("Outputs[id] = %+v, want sensitive output", o)
+	}
+	if len(mod.Resources) != 1 || mod.Resources[0].Address() != "null_resource.x" || mod.Resources[0].Provider != "null" {
+		t.Errorf("Resources = %+v", mod.Resources)
+	}
+}
+
+func TestLoadModuleJSON(t *testing.T) {
+	hclDir := writeFiles(t, map[string]string{
+		"main.tf": `
+terraform {
+  required_version = ">= 1.0"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+variable "zones" {
+  type    = list(string)
+  default = ["a"]
+
+  validation {
+    condition     = length(var.zones) > 0
+    error_message = "At least one zone is required."
+  }
+}
+
+output "id" {
+  value     = aws_instance.web.id
+  sensitive = true
+}
+
+resource "aws_instance" "web" {
+  provider = aws.west
+
+  provisioner "local-exec" {
+    command = "echo hi"
+  }
+}
+
+module "vpc" {
+  source  = "./vpc"
+  version = "1.0.0"
+}
+`,
+		"vpc/main.tf": "",
+	})
+	jsonDir := writeFiles(t, map[string]string{
+		"main.tf.json": `{
+  "//": "Generated.",
+  "terraform": {
+    "required_version": ">= 1.0",
+    "required_providers": {
+      "//": "Pinned.",
+      "aws": {"source": "hashicorp/aws", "version": "~> 5.0"}
+    }
+  },
+  "variable": {
+    "zones": {
+      "//": "Where to run.",
+      "type": "list(string)",
+      "default": ["a"],
+      "validation": {
+        "condition": "${length(var.zones) > 0}",
+        "error_message": "At least one zone is required."
+      }
+    }
+  },
+  "output": {"id": {"value": "${aws_instance.web.id}", "sensitive": true}},
+  "resource": {
+    "aws_instance": {
+      "web": {
+        "provider": "aws.west",
+        "provisioner": [{"local-exec": {"command": "echo hi"}}]
+      }
+    }
+  },
+  "module": {"vpc": {"source": "./vpc", "version": "1.0.0"}}
+}`,
+		"vpc/main.tf.json": `{}`,
+	})
+
+	load := func(dir string) *moduleConfig {
+		t.Helper()
+		mod, err := loadModule(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mod
+	}
+	want, got := load(hclDir), load(jsonDir)
+	if diff := cmp.Diff(want.RequiredVersion, got.RequiredVersion); diff != "" {
+		t.Errorf("RequiredVersion (-hcl,+json): %s", diff)
+	}
+	if diff := cmp.Diff(want.RequiredProviders, got.RequiredProviders); diff != "" {
+		t.Errorf("RequiredProviders (-hcl,+json): %s", diff)
+	}
+	if diff := cmp.Diff(want.ModuleCalls, got.ModuleCalls); diff != "" {
+		t.Errorf("ModuleCalls (-hcl,+json): %s", diff)
+	}
+	if diff := cmp.Diff(want.Resources, got.Resources, cmpopts.IgnoreFields(resourceConfig{}, "Pos", "Filename")); diff != "" {
+		t.Errorf("Resources (-hcl,+json): %s", diff)
+	}
+	if o := got.Outputs["id"]; o == nil || !o.Sensitive {
+		t.Errorf("Outputs[id] = %+v, want sensitive output", o)
+	}
+
+	v := got.Variables["zones"]
+	if v == nil || v.Type != "list(string)" || !v.Default.RawEquals(want.Variables["zones"].Default) || len(v.Validations) != 1 {
+		t.Fatalf("Variables[zones] = %+v", v)
+	}
+	for _, zones := range []cty.Value{cty.ListValEmpty(cty.String), cty.ListVal([]cty.Value{cty.StringVal("b")})} {
+		wantErrs := want.Variables["zones"].validate(zones, map[string]cty.Value{"zones": zones})
+		if diff := cmp.Diff(wantErrs, v.validate(zones, map[string]cty.Value{"zones": zones})); diff != "" {
+			t.Errorf("validate(%#v) (-hcl,+json): %s", zones, diff)
+		}
+	}
+
+	for _, dir := range []string{hclDir, jsonDir} {
+		dirs, err := moduleDirs(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dirs) != 2 {
+			t.Errorf("moduleDirs(%s) = %q, want the root and vpc", dir, dirs)
+		}
+	}
+}
+
+func TestReadModulesManifest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		".terraform/modules/modules.json": `{"Modules":[
+			{"Key":"vpc","Source":"registry.terraform.io/terraform-aws-modules/vpc/aws","Version":"5.1.0","Dir":".terraform/modules/vpc"},
+			{"Key":"","Source":"","Dir":"."},
+			{"Key":"local","Source":"./local","Dir":"local"}
+		]}`,
+	})
+	got, err := readModulesManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]moduleManifestEntry{
+		{Key: "", Source: "", Dir: "."},
+		{Key: "local", Source: "./local", Dir: "local"},
+		{Key: "vpc", Source: "registry.terraform.io/terraform-aws-modules/vpc/aws", Version: "5.1.0", Dir: ".terraform/modules/vpc"},
+	}, got); diff != "" {
+		t.Errorf("readModulesManifest (-want,+got): %s", diff)
+	}
+
+	dirs, err := moduleDirs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{dir, filepath.Join(dir, "local"), filepath.Join(dir, ".terraform/modules/vpc")}, dirs); diff != "" {
+		t.Errorf("moduleDirs (-want,+got): %s", diff)
+	}
+
+	if got, err := readModulesManifest(t.TempDir()); err != nil || got != nil {
+		t.Errorf("readModulesManifest(empty) = %v, %v, want nil, nil", got, err)
+	}
+}
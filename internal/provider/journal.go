@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// journalFileName is where the phases of a nested apply are recorded as they
+// start and finish, relative to its working directory. It's removed when the
+// apply returns, so if it's found when an apply starts, the provider died
+// during the last one.
+var journalFileName = filepath.Join(".terraform", "pteraform.journal")
+
+// stalePlanPattern matches terraform apply output reporting that a saved
+// plan can't be applied because the state changed since it was created.
+var stalePlanPattern = regexp.MustCompile(`(?i)saved plan is stale|plan file can no longer be applied`)
+
+// journalEntry is a line of the journal.
+type journalEntry struct {
+	Time  time.Time `json:"time"`
+	RunID string    `json:"run_id"`
+	Phase string    `json:"phase"`
+	// Event is "started" or "finished" for a terraform command, or "planned"
+	// when a saved plan has been written.
+	Event   string `json:"event"`
+	Command string `json:"command,omitempty"`
+	// PlanFile is the saved plan, as passed to terraform apply, and
+	// PlanDigest its hex-encoded SHA-256 digest.
+	PlanFile   string `json:"plan_file,omitempty"`
+	PlanDigest string `json:"plan_digest,omitempty"`
+}
+
+// journal records the phases of a nested apply in its working directory.
+type journal struct {
+	dir   string
+	runID string
+	// phase is the phase of the apply currently running.
+	phase *string
+	// mode is the permissions of the journal, or 0 for defaultFileMode.
+	mode os.FileMode
+}
+
+// write appends e to the journal, synced to disk so that it survives the
+// provider dying. Failures are logged rather than failing the apply.
+func (j *journal) write(ctx context.Context, e journalEntry) {
+	e.Time = time.Now().UTC()
+	e.RunID = j.runID
+	e.Phase = *j.phase
+	if err := appendJournal(j.dir, e, j.mode); err != nil {
+		tflog.Warn(ctx, "Unable to write apply journal, an interrupted apply won't be detected", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// appendJournal appends e to the journal in dir, which has permissions
+// mode, or defaultFileMode if it's 0.
+func appendJournal(dir string, e journalEntry, mode os.FileMode) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	fn := filepath.Join(dir, journalFileName)
+	if err := os.MkdirAll(filepath.Dir(fn), 0o755); err != nil {
+		return err
+	}
+	mode = modeOr(mode, defaultFileMode)
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeJournal removes the journal of the apply in dir, once it's returned.
+func removeJournal(dir string) {
+	os.Remove(filepath.Join(dir, journalFileName))
+}
+
+// interruptedApply describes a nested apply the provider died during.
+type interruptedApply struct {
+	RunID string
+	// Phase is the last phase the apply started.
+	Phase string
+	// PlanFile and PlanDigest are the saved plan it was applying, if any.
+	PlanFile   string
+	PlanDigest string
+}
+
+// readJournal returns the nested apply in dir left incomplete by a provider
+// that died, or nil if there's none.
+func readJournal(dir string) (*interruptedApply, error) {
+	f, err := os.Open(filepath.Join(dir, journalFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Unable to read %s, got error: %s", journalFileName, err)
+	}
+	defer f.Close()
+	ia := &interruptedApply{}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		var e journalEntry
+		// A partially written last entry is ignored.
+		if json.Unmarshal(line, &e) == nil {
+			ia.RunID, ia.Phase = e.RunID, e.Phase
+			if e.Event == "planned" {
+				ia.PlanFile, ia.PlanDigest = e.PlanFile, e.PlanDigest
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("Unable to read %s, got error: %s", journalFileName, err)
+		}
+	}
+	return ia, nil
+}
+
+// resumablePlan returns the saved plan the interrupted apply in dir was
+// applying, as passed to terraform apply, and its path, if it's still there
+// unchanged, or "" if not.
+func (ia *interruptedApply) resumablePlan(dir string) (planFile, path string) {
+	if ia.PlanFile == "" {
+		return "", ""
+	}
+	path = ia.PlanFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if digest, err := fileDigest(path); err != nil || digest != ia.PlanDigest {
+		return "", ""
+	}
+	return ia.PlanFile, path
+}
+
+// journalRunner records each command it runs in a journal as it starts and
+// finishes.
+type journalRunner struct {
+	runner
+	journal *journal
+}
+
+func (j journalRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	return j.stream(ctx, dir, nil, args...)
+}
+
+func (j journalRunner) stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error) {
+	j.journal.write(ctx, journalEntry{Event: "started", Command: args[0]})
+	var out string
+	var err error
+	if s, ok := j.runner.(streamer); ok && w != nil {
+		out, err = s.stream(ctx, dir, w, args...)
+	} else {
+		out, err = j.runner.run(ctx, dir, args...)
+	}
+	j.journal.write(ctx, journalEntry{Event: "finished", Command: args[0]})
+	return out, err
+}
+
+func (j journalRunner) stdout(ctx context.Context, dir string, args ...string) (string, error) {
+	j.journal.write(ctx, journalEntry{Event: "started", Command: args[0]})
+	out, err := runStdout(ctx, j.runner, dir, args...)
+	j.journal.write(ctx, journalEntry{Event: "finished", Command: args[0]})
+	return out, err
+}
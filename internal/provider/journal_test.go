@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadJournal(t *testing.T) {
+	if ia, err := readJournal(t.TempDir()); err != nil || ia != nil {
+		t.Errorf("readJournal with no journal = %v, %v", ia, err)
+	}
+
+	dir := writeFiles(t, map[string]string{journalFileName: `{"run_id":"r1","phase":"init","event":"started","command":"init"}
+{"run_id":"r1","phase":"init","event":"finished","command":"init"}
+{"run_id":"r1","phase":"plan","event":"planned","plan_file":".terraform/pteraform.tfplan","plan_digest":"abc"}
+{"run_id":"r1","phase":"apply","event":"started","command":"apply"}
+{"run_id":"r1","phase":"apply","ev`})
+	ia, err := readJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &interruptedApply{RunID: "r1", Phase: "apply", PlanFile: ".terraform/pteraform.tfplan", PlanDigest: "abc"}
+	if diff := cmp.Diff(want, ia); diff != "" {
+		t.Errorf("readJournal (-want,+got): %s", diff)
+	}
+	if planFile, _ := ia.resumablePlan(dir); planFile != "" {
+		t.Errorf("resumablePlan without the saved plan = %q", planFile)
+	}
+}
+
+func TestDoApplyResumesInterrupted(t *testing.T) {
+	planFile := filepath.Join(".terraform", "pteraform.tfplan")
+	for _, c := range []struct {
+		desc  string
+		stale bool
+		want  []string
+	}{{
+		desc: "resume",
+		want: []string{"init", "apply -auto-approve " + planFile},
+	}, {
+		desc:  "stale",
+		stale: true,
+		want:  []string{"init", "apply -auto-approve " + planFile, "apply -auto-approve"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"main.tf": "", planFile: "plan"})
+			digest, err := fileDigest(filepath.Join(dir, planFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := appendJournal(dir, journalEntry{RunID: "r1", Phase: "plan", Event: "planned", PlanFile: planFile, PlanDigest: digest}, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			fake := &fakeRunner{}
+			if c.stale {
+				cmd := "apply -auto-approve " + planFile
+				fake.outputs = map[string]string{cmd: "Error: Saved plan is stale"}
+				fake.failures = map[string]int{cmd: 1}
+			}
+			r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+			m := testApplyModel(dir)
+			m.skipRefresh = true
+			_, warnings, err := r.doApply(context.Background(), &m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, fake.commands); diff != "" {
+				t.Errorf("commands (-want,+got): %s", diff)
+			}
+			if warnings.WarningsCount() != 1 {
+				t.Errorf("got warnings %v, want one about the interrupted apply", warnings)
+			}
+			for _, fn := range []string{planFile, journalFileName} {
+				if _, err := os.Stat(filepath.Join(dir, fn)); !os.IsNotExist(err) {
+					t.Errorf("%s is left after the apply", fn)
+				}
+			}
+		})
+	}
+}
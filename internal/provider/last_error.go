@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maxLastErrorDiagnostics is how many diagnostics are kept in last_error.
+const maxLastErrorDiagnostics = 5
+
+// lastErrorKey is the private state key last_error is also stored under.
+const lastErrorKey = "last_error"
+
+// phaseError is a failure of one phase of a nested apply.
+type phaseError struct {
+	Phase string
+	Err   error
+}
+
+func (e *phaseError) Error() string { return e.Err.Error() }
+
+func (e *phaseError) Unwrap() error { return e.Err }
+
+// lastError summarizes a failed nested apply.
+type lastError struct {
+	Phase       string   `json:"phase"`
+	ExitCode    int      `json:"exit_code"`
+	Class       string   `json:"class"`
+	Diagnostics []string `json:"diagnostics"`
+}
+
+// ApplyLastErrorModel describes the last_error attribute.
+type ApplyLastErrorModel struct {
+	Phase       types.String `tfsdk:"phase"`
+	ExitCode    types.Int64  `tfsdk:"exit_code"`
+	Class       types.String `tfsdk:"class"`
+	Diagnostics types.List   `tfsdk:"diagnostics"`
+}
+
+var applyLastErrorAttrTypes = map[string]attr.Type{
+	"phase":       types.StringType,
+	"exit_code":   types.Int64Type,
+	"class":       types.StringType,
+	"diagnostics": types.ListType{ElemType: types.StringType},
+}
+
+// errorSummaryPattern matches the summary line of an error diagnostic in
+// terraform's human-readable output, which may be framed by box drawing.
+var errorSummaryPattern = regexp.MustCompile(`(?m)^[│╷\s]*Error: (.+?)\s*$`)
+
+// newLastError summarizes err, returned by doApply. Diagnostics are the
+// error summaries terraform printed, in either its human-readable or JSON
+// output, or the error itself if it didn't come from terraform.
+func newLastError(err error) *lastError {
+	le := &lastError{Phase: "apply", ExitCode: -1, Class: string(failureOther)}
+	var pe *phaseError
+	if errors.As(err, &pe) {
+		le.Phase = pe.Phase
+	}
+	var re *runError
+	if !errors.As(err, &re) {
+		le.Diagnostics = []string{err.Error()}
+		return le
+	}
+	le.ExitCode = re.ExitCode
+	if re.Class != "" {
+		le.Class = string(re.Class)
+	}
+	for _, m := range errorSummaryPattern.FindAllStringSubmatch(re.Output, maxLastErrorDiagnostics) {
+		le.Diagnostics = append(le.Diagnostics, m[1])
+	}
+	for _, e := range parseEvents(re.Output) {
+		if len(le.Diagnostics) == maxLastErrorDiagnostics {
+			break
+		}
+		if e.Type == "diagnostic" && e.Diagnostic.Severity == "error" {
+			le.Diagnostics = append(le.Diagnostics, e.Diagnostic.Summary)
+		}
+	}
+	if len(le.Diagnostics) == 0 {
+		le.Diagnostics = []string{fmt.Sprintf("terraform %s failed, got error: %s", re.Command, re.Err)}
+	}
+	return le
+}
+
+// privateState is the private state of a resource, as in a CreateResponse.
+type privateState interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// privateStateReader is the private state of a resource, as in a
+// ReadRequest.
+type privateStateReader interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// setLastError sets last_error to summarize err, or to null if err is nil,
+// and stores the same summary in private state.
+func (m *ApplyResourceModel) setLastError(ctx context.Context, private privateState, err error) diag.Diagnostics {
+	if err == nil {
+		m.LastError = types.ObjectNull(applyLastErrorAttrTypes)
+		return private.SetKey(ctx, lastErrorKey, []byte("null"))
+	}
+
+	le := newLastError(err)
+	b, jerr := json.Marshal(le)
+	if jerr != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Client Error", fmt.Sprintf("Unable to encode last_error, got error: %s", jerr))
+		return diags
+	}
+	diags := private.SetKey(ctx, lastErrorKey, b)
+
+	diagnostics, d := types.ListValueFrom(ctx, types.StringType, le.Diagnostics)
+	diags.Append(d...)
+	m.LastError, d = types.ObjectValueFrom(ctx, applyLastErrorAttrTypes, ApplyLastErrorModel{
+		Phase:       types.StringValue(le.Phase),
+		ExitCode:    types.Int64Value(int64(le.ExitCode)),
+		Class:       types.StringValue(le.Class),
+		Diagnostics: diagnostics,
+	})
+	diags.Append(d...)
+	return diags
+}
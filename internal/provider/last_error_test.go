@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewLastError(t *testing.T) {
+	output := `
+Planning failed. Terraform encountered an error while generating this plan.
+
+╷
+│ Error: Invalid reference
+│ 
+│   on main.tf line 2, in resource "null_resource" "x":
+│    2:   triggers = { a = nope }
+│ 
+╵
+╷
+│ Error: Unsupported argument
+╵
+`
+	for _, c := range []struct {
+		desc string
+		err  error
+		want *lastError
+	}{{
+		desc: "terraform error",
+		err:  &phaseError{Phase: "plan", Err: &runError{Command: "plan", ExitCode: 1, Class: failureDiagnostics, Output: output, Err: errors.New("exit status 1")}},
+		want: &lastError{Phase: "plan", ExitCode: 1, Class: "diagnostics", Diagnostics: []string{"Invalid reference", "Unsupported argument"}},
+	}, {
+		desc: "json",
+		err: &phaseError{Phase: "apply", Err: &runError{Command: "apply", ExitCode: 1, Class: failureDiagnostics, Err: errors.New("exit status 1"), Output: `{"@level":"info","@message":"Terraform 1.6.0","type":"version"}
+{"@level":"error","@message":"Error: Invalid reference","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid reference"}}
+`}},
+		want: &lastError{Phase: "apply", ExitCode: 1, Class: "diagnostics", Diagnostics: []string{"Invalid reference"}},
+	}, {
+		desc: "no diagnostics",
+		err:  &phaseError{Phase: "init", Err: &runError{Command: "init", ExitCode: -1, Class: failureKilled, Output: "Initializing...", Err: errors.New("signal: killed")}},
+		want: &lastError{Phase: "init", ExitCode: -1, Class: "killed", Diagnostics: []string{"terraform init failed, got error: signal: killed"}},
+	}, {
+		desc: "provider error",
+		err:  &phaseError{Phase: "policy", Err: errors.New(`provider "hashicorp/null" is not allowed`)},
+		want: &lastError{Phase: "policy", ExitCode: -1, Class: "other", Diagnostics: []string{`provider "hashicorp/null" is not allowed`}},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, newLastError(c.err)); diff != "" {
+				t.Errorf("newLastError (-want,+got): %s", diff)
+			}
+		})
+	}
+}
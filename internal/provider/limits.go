@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// resourceLimits constrains the terraform processes the provider runs, so a
+// heavy nested apply can't starve the host.
+type resourceLimits struct {
+	// Nice is the scheduling priority to run at, from -20 to 19. Zero leaves
+	// the priority unchanged.
+	Nice int
+	// MaxMemory is the maximum memory in bytes the process and its children
+	// may use. Zero means no limit.
+	MaxMemory int64
+}
+
+var sizePattern = regexp.MustCompile(`^([0-9]+)\s*([KMGT]i?B?|B)?$`)
+
+// parseSize parses a size like "512MiB" or "2G" into bytes. Decimal
+// suffixes (KB, MB, ...) are powers of 1000, and binary suffixes (KiB, MiB,
+// ...) and bare letters (K, M, ...) are powers of 1024.
+func parseSize(s string) (int64, error) {
+	m := sizePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q: expected a number with an optional unit like MiB or GiB", s)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %s", s, err)
+	}
+	unit := m[2]
+	if unit == "" || unit == "B" {
+		return n, nil
+	}
+	base := int64(1024)
+	if strings.HasSuffix(unit, "B") && !strings.HasSuffix(unit, "iB") {
+		base = 1000
+	}
+	mult := int64(1)
+	for i := 0; i <= strings.Index("KMGT", unit[:1]); i++ {
+		mult *= base
+	}
+	if n > (1<<63-1)/mult {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return n * mult, nil
+}
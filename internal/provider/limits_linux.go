@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// apply applies the limits to the started process pid, whose children
+// inherit them. It returns a function to clean up after the process exits.
+//
+// The memory limit uses a cgroup v2 child of the provider's own cgroup, which
+// requires the memory controller to be delegated to it. If that's not
+// possible, the memory limit is skipped with a warning rather than failing
+// the apply.
+func (l resourceLimits) apply(ctx context.Context, pid int) (func(), error) {
+	cleanup := func() {}
+	if l.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, l.Nice); err != nil {
+			return cleanup, fmt.Errorf("Unable to set cpu_nice, got error: %s", err)
+		}
+	}
+	if l.MaxMemory > 0 {
+		dir, err := l.memoryCgroup(pid)
+		if err != nil {
+			tflog.Warn(ctx, "Unable to apply max_memory with cgroup v2, running without a memory limit", map[string]interface{}{"error": err.Error()})
+		} else {
+			cleanup = func() { os.Remove(dir) }
+		}
+	}
+	return cleanup, nil
+}
+
+// memoryCgroup creates a cgroup with the memory limit and moves pid into it.
+func (l resourceLimits) memoryCgroup(pid int) (string, error) {
+	parent, err := ownCgroup()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cgroupRoot, parent, fmt.Sprintf("pteraform-%d", pid))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(l.MaxMemory, 10)), 0o644); err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// ownCgroup returns this process's cgroup v2 path, relative to cgroupRoot.
+func ownCgroup() (string, error) {
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// cgroup v2 has a single entry of the form "0::/path".
+		if p, ok := strings.CutPrefix(s.Text(), "0::"); ok {
+			return p, nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("cgroup v2 is not available")
+}
@@ -0,0 +1,20 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// apply warns that resource limits are not supported on this platform.
+func (l resourceLimits) apply(ctx context.Context, pid int) (func(), error) {
+	if l != (resourceLimits{}) {
+		tflog.Warn(ctx, "resource_limits are only supported on Linux, running without limits")
+	}
+	return func() {}, nil
+}
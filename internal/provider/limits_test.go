@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"1024":   1024,
+		"100B":   100,
+		"2GiB":   2 << 30,
+		"2G":     2 << 30,
+		"512MiB": 512 << 20,
+		"512MB":  512 * 1000 * 1000,
+		"1 KiB":  1024,
+		"1T":     1 << 40,
+	} {
+		got, err := parseSize(s)
+		if err != nil {
+			t.Errorf("parseSize(%q): %v", s, err)
+		} else if got != want {
+			t.Errorf("parseSize(%q) = %d, want %d", s, got, want)
+		}
+	}
+
+	for _, s := range []string{"", "lots", "-1GiB", "1.5GiB", "2PB", "99999999999999999999"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("parseSize(%q): expected error", s)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows
+
+package provider
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockExclusive waits for an exclusive lock on f, which is released when f
+// is closed.
+func lockExclusive(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// lockFileName is the dependency lock file written by terraform init.
+const lockFileName = ".terraform.lock.hcl"
+
+// lockFileErrorPattern matches terraform init output reporting that the
+// dependency lock file is corrupt or inconsistent with the configuration or
+// the installed providers.
+var lockFileErrorPattern = regexp.MustCompile(`(?i)(?:Failed to read|Inconsistent|Invalid) dependency lock file|checksums? recorded in the dependency lock file|locked provider .* does not match|` + regexp.QuoteMeta(lockFileName) + `:\d+`)
+
+// lockedProvider is a provider block in the dependency lock file.
+type lockedProvider struct {
+	Source      string   `hcl:"source,label"`
+	Version     string   `hcl:"version"`
+	Constraints string   `hcl:"constraints,optional"`
+	Hashes      []string `hcl:"hashes,optional"`
+}
+
+type lockFile struct {
+	Providers []lockedProvider `hcl:"provider,block"`
+	Remain    hcl.Body         `hcl:",remain"`
+}
+
+// readLockFile returns the providers locked in dir's dependency lock file,
+// sorted by source, or nil if there is no lock file.
+func readLockFile(dir string) ([]lockedProvider, error) {
+	fn := filepath.Join(dir, lockFileName)
+	if _, err := os.Stat(fn); os.IsNotExist(err) {
+		return nil, nil
+	}
+	f, diags := hclparse.NewParser().ParseHCLFile(fn)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("Unable to parse %s, got error: %s", lockFileName, diags.Error())
+	}
+	var lf lockFile
+	if diags := gohcl.DecodeBody(f.Body, nil, &lf); diags.HasErrors() {
+		return nil, fmt.Errorf("Unable to parse %s, got error: %s", lockFileName, diags.Error())
+	}
+	sort.Slice(lf.Providers, func(i, j int) bool { return lf.Providers[i].Source < lf.Providers[j].Source })
+	return lf.Providers, nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// inputManifest lists the inputs of an apply, so build systems that cache
+// the outer run can tell whether those of the nested one changed.
+type inputManifest struct {
+	// Files are the files of the nested configuration, as included in
+	// SourceDigest, in path order.
+	Files        []sourceFile `json:"files"`
+	SourceDigest string       `json:"source_digest"`
+	// Modules maps the keys of the installed remote modules to their
+	// resolved sources.
+	Modules map[string]string `json:"modules"`
+	// VariablesDigest is the digest of the arguments passed to terraform,
+	// including the variables and the contents of variable definitions
+	// files, the backend configuration and the contents of its files,
+	// environment, and the environment variables
+	// envsubst templates reference.
+	VariablesDigest string `json:"variables_digest"`
+}
+
+// inputManifest returns the JSON-encoded input manifest of m.
+func (r *ApplyResource) inputManifest(ctx context.Context, m *ApplyResourceModel) (string, error) {
+	dir := m.WorkingDir.ValueString()
+	files, err := sourceFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	manifest := inputManifest{
+		Files:        files,
+		SourceDigest: filesDigest(files),
+		Modules:      map[string]string{},
+	}
+
+	installed, err := readModulesManifest(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, mod := range installed {
+		if mod.Key == "" {
+			continue
+		}
+		src, err := resolvedSource(ctx, dir, mod)
+		if err != nil {
+			return "", err
+		}
+		if src != "" {
+			manifest.Modules[mod.Key] = src
+		}
+	}
+
+	var args []string
+	if diags := m.Args.ElementsAs(ctx, &args, false); diags.HasError() {
+		return "", fmt.Errorf("errors getting args: %v", diags.Errors())
+	}
+	args, err = r.nestedArgs(ctx, m, args)
+	if err != nil {
+		return "", err
+	}
+	varFiles, err := varFilesDigest(dir, args)
+	if err != nil {
+		return "", err
+	}
+	backend, err := m.backendDigest(ctx)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, a := range args {
+		fmt.Fprintf(h, "arg %s\x00", a)
+	}
+	if varFiles != "" {
+		fmt.Fprintf(h, "var files %s\x00", varFiles)
+	}
+	if backend != "" {
+		fmt.Fprintf(h, "backend %s\x00", backend)
+	}
+	for _, kv := range m.environmentEnv() {
+		fmt.Fprintf(h, "env %s\x00", kv)
+	}
+	if m.Templating != nil && m.Templating.engine() == "envsubst" {
+		templates, err := m.Templating.inputsDigest(ctx, dir, r.nestedEnviron(m))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "templates %s\x00", templates)
+	}
+	manifest.VariablesDigest = fmt.Sprintf("%x", h.Sum(nil))
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// setInputManifest sets m.InputManifest after a successful apply.
+func (r *ApplyResource) setInputManifest(ctx context.Context, m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	manifest, err := r.inputManifest(ctx, m)
+	if err != nil {
+		diags.AddWarning("Unable to Record Input Manifest", fmt.Sprintf("input_manifest won't be set: %s", err))
+		m.InputManifest = types.StringNull()
+		return diags
+	}
+	m.InputManifest = types.StringValue(manifest)
+	return diags
+}
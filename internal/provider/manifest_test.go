@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestInputManifest(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{
+		"variables.tf":              `variable "region" {}`,
+		"main.tf":                   "",
+		"modules/vpc/main.tf":       "",
+		"terraform.tfstate":         "{}",
+		".terraform/modules/x.json": "{}",
+	})
+	r := &ApplyResource{}
+	m := testApplyModel(dir)
+	m.Variables = types.MapValueMust(types.StringType, map[string]attr.Value{"region": types.StringValue("us-east-1")})
+	got, err := r.inputManifest(ctx, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := r.inputManifest(ctx, &m); err != nil || again != got {
+		t.Errorf("inputManifest again = %s, %v, want %s", again, err, got)
+	}
+	if strings.Contains(got, "us-east-1") {
+		t.Errorf("inputManifest() = %s, contains a variable value", got)
+	}
+
+	var manifest inputManifest
+	if err := json.Unmarshal([]byte(got), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range manifest.Files {
+		paths = append(paths, f.Path)
+	}
+	if diff := cmp.Diff([]string{"main.tf", "modules/vpc/main.tf", "variables.tf"}, paths); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%s", diff)
+	}
+	if source, err := sourceDigest(dir); err != nil || manifest.SourceDigest != source {
+		t.Errorf("source_digest = %s, want %s (%v)", manifest.SourceDigest, source, err)
+	}
+
+	m.Variables = types.MapValueMust(types.StringType, map[string]attr.Value{"region": types.StringValue("us-west-2")})
+	other, err := r.inputManifest(ctx, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed inputManifest
+	if err := json.Unmarshal([]byte(other), &changed); err != nil {
+		t.Fatal(err)
+	}
+	if changed.VariablesDigest == manifest.VariablesDigest {
+		t.Errorf("variables_digest didn't change with variables")
+	}
+	if diff := cmp.Diff(manifest.Files, changed.Files); diff != "" {
+		t.Errorf("files changed with variables (-before +after):\n%s", diff)
+	}
+}
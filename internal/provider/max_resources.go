@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// plannedModule is a module in the planned_values of a JSON plan.
+type plannedModule struct {
+	Resources []struct {
+		Mode string `json:"mode"`
+	} `json:"resources"`
+	ChildModules []plannedModule `json:"child_modules"`
+}
+
+// managedResources returns how many managed resource instances there are
+// in m and its descendants.
+func (m plannedModule) managedResources() int64 {
+	var n int64
+	for _, r := range m.Resources {
+		if r.Mode == "managed" {
+			n++
+		}
+	}
+	for _, c := range m.ChildModules {
+		n += c.managedResources()
+	}
+	return n
+}
+
+// plannedResources returns how many managed resource instances there would
+// be after applying the JSON plan.
+func plannedResources(planJSON []byte) (int64, error) {
+	var plan struct {
+		PlannedValues struct {
+			RootModule plannedModule `json:"root_module"`
+		} `json:"planned_values"`
+	}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return 0, fmt.Errorf("Unable to parse plan, got error: %s", err)
+	}
+	return plan.PlannedValues.RootModule.managedResources(), nil
+}
+
+// checkMaxResources returns an error if applying the saved plan in dir
+// would leave more than max managed resources in the nested state.
+func checkMaxResources(ctx context.Context, tf runner, dir, planFile string, max int64) error {
+	out, err := tf.run(ctx, dir, "show", "-json", planFile)
+	if err != nil {
+		return err
+	}
+	n, err := plannedResources([]byte(out))
+	if err != nil {
+		return err
+	}
+	if n > max {
+		return fmt.Errorf("the nested plan would result in %d managed resources, more than max_resources, %d, so it wasn't applied", n, max)
+	}
+	return nil
+}
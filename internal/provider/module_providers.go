@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// moduleProvidersKey is the private state key the provider requirements of
+// the remote modules installed by the last apply are stored under.
+const moduleProvidersKey = "module_providers"
+
+// moduleProviders returns the version constraints each remote module
+// installed in root places on each provider, keyed by module key and then
+// provider source. Local modules are left out, since they change along with
+// the nested configuration itself.
+func moduleProviders(root string) (map[string]map[string]string, error) {
+	installed, err := readModulesManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]map[string]string{}
+	for _, m := range installed {
+		if m.Key == "" || isLocalModuleSource(m.Source) {
+			continue
+		}
+		mod, err := loadModule(filepath.Join(root, m.Dir))
+		if err != nil {
+			return nil, err
+		}
+		providers := map[string]string{}
+		for local, req := range mod.RequiredProviders {
+			src, err := mod.ProviderSource(local)
+			if err != nil {
+				return nil, err
+			}
+			providers[src] = strings.Join(req.VersionConstraints, ", ")
+		}
+		out[m.Key] = providers
+	}
+	return out, nil
+}
+
+// providerJumps describes how the provider requirements of each module in
+// both before and after changed, sorted by module and provider.
+func providerJumps(before, after map[string]map[string]string) []string {
+	var jumps []string
+	for key, was := range before {
+		now, ok := after[key]
+		if !ok {
+			continue
+		}
+		for src, c := range now {
+			if old, ok := was[src]; !ok {
+				jumps = append(jumps, fmt.Sprintf("module.%s: %s added, %s", key, src, constraintString(c)))
+			} else if old != c {
+				jumps = append(jumps, fmt.Sprintf("module.%s: %s %s -> %s", key, src, constraintString(old), constraintString(c)))
+			}
+		}
+		for src, old := range was {
+			if _, ok := now[src]; !ok {
+				jumps = append(jumps, fmt.Sprintf("module.%s: %s removed, was %s", key, src, constraintString(old)))
+			}
+		}
+	}
+	sort.Strings(jumps)
+	return jumps
+}
+
+// constraintString returns the version constraint c, or "any version" if
+// there is none.
+func constraintString(c string) string {
+	if c == "" {
+		return "any version"
+	}
+	return fmt.Sprintf("%q", c)
+}
+
+// recordModuleProviders stores the provider requirements of the remote
+// modules installed in dir. If prior, the private state before the apply, is
+// non-nil, it first warns about any requirements that changed since they
+// were last recorded, which usually means a module update bumped a
+// provider.
+func recordModuleProviders(ctx context.Context, prior privateStateReader, private privateState, dir string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	now, err := moduleProviders(dir)
+	if err != nil {
+		diags.AddWarning("Unable to Read Module Provider Requirements", err.Error())
+		return diags
+	}
+	if prior != nil {
+		b, d := prior.GetKey(ctx, moduleProvidersKey)
+		diags.Append(d...)
+		var was map[string]map[string]string
+		if len(b) > 0 && json.Unmarshal(b, &was) == nil {
+			if jumps := providerJumps(was, now); len(jumps) > 0 {
+				diags.AddWarning("Nested Provider Requirements Changed",
+					fmt.Sprintf("Updated modules changed the providers they require, which may change provider versions in the nested configuration:\n  %s", strings.Join(jumps, "\n  ")))
+			}
+		}
+	}
+	b, err := json.Marshal(now)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record module provider requirements, got error: %s", err))
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, moduleProvidersKey, b)...)
+	return diags
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleProviders(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		".terraform/modules/modules.json": `{"Modules":[{"Key":"","Source":"","Dir":"."},{"Key":"local","Source":"./local","Dir":"local"},{"Key":"vpc","Source":"registry.terraform.io/acme/vpc/aws","Version":"2.0.0","Dir":".terraform/modules/vpc"}]}`,
+		"local/main.tf":                   `terraform { required_providers { null = {} } }`,
+		".terraform/modules/vpc/main.tf": `
+terraform {
+  required_providers {
+    aws    = { source = "hashicorp/aws", version = ">= 5.0" }
+    random = "~> 3.0"
+  }
+}`,
+	})
+	got, err := moduleProviders(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{"vpc": {
+		"registry.terraform.io/hashicorp/aws":    ">= 5.0",
+		"registry.terraform.io/hashicorp/random": "~> 3.0",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("moduleProviders (-want +got):\n%s", diff)
+	}
+}
+
+func TestProviderJumps(t *testing.T) {
+	before := map[string]map[string]string{
+		"vpc": {"hashicorp/aws": "~> 4.0", "hashicorp/null": ""},
+		"dns": {"hashicorp/aws": "~> 4.0"},
+		"old": {"hashicorp/aws": "~> 3.0"},
+	}
+	after := map[string]map[string]string{
+		"vpc": {"hashicorp/aws": "~> 5.0", "hashicorp/random": ""},
+		"dns": {"hashicorp/aws": "~> 4.0"},
+		"new": {"hashicorp/aws": "~> 6.0"},
+	}
+	want := []string{
+		`module.vpc: hashicorp/aws "~> 4.0" -> "~> 5.0"`,
+		"module.vpc: hashicorp/null removed, was any version",
+		"module.vpc: hashicorp/random added, any version",
+	}
+	if diff := cmp.Diff(want, providerJumps(before, after)); diff != "" {
+		t.Errorf("providerJumps (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// initMarkerName records the dependency lock file a full terraform init
+// last installed providers for, and the backend configuration it used,
+// relative to the working directory, so modules_only_update can tell
+// whether it's still current.
+var initMarkerName = filepath.Join(".terraform", "pteraform-init.json")
+
+type initMarker struct {
+	// LockFile is the hex-encoded SHA-256 digest of the lock file, or "" if
+	// there was none.
+	LockFile string `json:"lock_file"`
+	// Backend is the hex-encoded SHA-256 digest of the -backend-config
+	// arguments, which may be secret, or "" if there were none.
+	Backend string `json:"backend,omitempty"`
+}
+
+// backendDigest returns the digest of the -backend-config arguments in
+// backend, or "" if there are none.
+func backendDigest(backend []string) string {
+	if len(backend) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, a := range backend {
+		fmt.Fprintf(h, "%s\x00", a)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// lockFileDigest returns the digest of the lock file in dir, or "" if there
+// is none.
+func lockFileDigest(dir string) (string, error) {
+	digest, err := fileDigest(filepath.Join(dir, lockFileName))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return digest, err
+}
+
+// recordInit records that terraform init installed the providers of the
+// lock file in dir, and configured the backend with backend, in a file
+// with permissions mode, or defaultFileMode if it's 0.
+func recordInit(dir string, backend []string, mode os.FileMode) error {
+	digest, err := lockFileDigest(dir)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(initMarker{LockFile: digest, Backend: backendDigest(backend)})
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, initMarkerName), b, modeOr(mode, defaultFileMode))
+}
+
+// initCurrent reports whether dir was initialized by a full terraform init,
+// and neither its lock file nor backend have changed since, so only modules
+// might need updating.
+func initCurrent(dir string, backend []string) bool {
+	b, err := os.ReadFile(filepath.Join(dir, initMarkerName))
+	if err != nil {
+		return false
+	}
+	var m initMarker
+	if json.Unmarshal(b, &m) != nil {
+		return false
+	}
+	digest, err := lockFileDigest(dir)
+	return err == nil && digest == m.LockFile && backendDigest(backend) == m.Backend
+}
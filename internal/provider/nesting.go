@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// nestingDepthEnv is set for nested runs to how many pteraform applies
+	// they're nested in, so that a pteraform provider in them knows its own
+	// depth.
+	nestingDepthEnv = "PTERAFORM_NESTING_DEPTH"
+	// maxNestingDepthEnv is set for nested runs to the max_nesting_depth in
+	// effect, so that it holds however deep the nested providers are
+	// configured to go.
+	maxNestingDepthEnv = "PTERAFORM_MAX_NESTING_DEPTH"
+)
+
+// nesting describes how deeply the provider is nested in pteraform applies.
+type nesting struct {
+	// Depth is zero for the outermost provider.
+	Depth int64
+	// Max is the deepest a nested run may be, or zero if it's unlimited.
+	Max int64
+}
+
+// readNesting returns the provider's nesting as set in its environment by
+// the pteraform apply it's nested in, if any. getenv looks up the provider's
+// environment.
+func readNesting(getenv func(string) string) (nesting, error) {
+	var n nesting
+	for name, v := range map[string]*int64{nestingDepthEnv: &n.Depth, maxNestingDepthEnv: &n.Max} {
+		s := getenv(name)
+		if s == "" {
+			continue
+		}
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || i < 0 {
+			return nesting{}, fmt.Errorf("%s must be a non-negative integer, got %q", name, s)
+		}
+		*v = i
+	}
+	return n, nil
+}
+
+// limit returns n limited to max, unless an outer provider already set a
+// lower limit.
+func (n nesting) limit(max int64) nesting {
+	if n.Max == 0 || max < n.Max {
+		n.Max = max
+	}
+	return n
+}
+
+// next returns the nesting of a run nested in this provider, or an error if
+// it would be deeper than the limit.
+func (n nesting) next() (nesting, error) {
+	if n.Max > 0 && n.Depth+1 > n.Max {
+		return nesting{}, fmt.Errorf("a nested run would be at nesting depth %d, which exceeds max_nesting_depth %d; check the configuration isn't wrapping itself", n.Depth+1, n.Max)
+	}
+	return nesting{Depth: n.Depth + 1, Max: n.Max}, nil
+}
+
+// env returns environment variables that pass n on to a nested run.
+func (n nesting) env() []string {
+	env := []string{fmt.Sprintf("%s=%d", nestingDepthEnv, n.Depth)}
+	if n.Max > 0 {
+		env = append(env, fmt.Sprintf("%s=%d", maxNestingDepthEnv, n.Max))
+	}
+	return env
+}
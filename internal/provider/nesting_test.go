@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNesting(t *testing.T) {
+	for _, c := range []struct {
+		desc    string
+		env     map[string]string
+		max     int64
+		want    []string
+		wantErr string
+	}{{
+		desc: "outermost",
+		want: []string{"PTERAFORM_NESTING_DEPTH=1"},
+	}, {
+		desc: "limited",
+		max:  2,
+		want: []string{"PTERAFORM_NESTING_DEPTH=1", "PTERAFORM_MAX_NESTING_DEPTH=2"},
+	}, {
+		desc: "inherited limit",
+		env:  map[string]string{"PTERAFORM_NESTING_DEPTH": "1", "PTERAFORM_MAX_NESTING_DEPTH": "2"},
+		want: []string{"PTERAFORM_NESTING_DEPTH=2", "PTERAFORM_MAX_NESTING_DEPTH=2"},
+	}, {
+		desc: "lower inherited limit wins",
+		env:  map[string]string{"PTERAFORM_NESTING_DEPTH": "1", "PTERAFORM_MAX_NESTING_DEPTH": "2"},
+		max:  5,
+		want: []string{"PTERAFORM_NESTING_DEPTH=2", "PTERAFORM_MAX_NESTING_DEPTH=2"},
+	}, {
+		desc:    "too deep",
+		env:     map[string]string{"PTERAFORM_NESTING_DEPTH": "2", "PTERAFORM_MAX_NESTING_DEPTH": "2"},
+		wantErr: "nesting depth 3, which exceeds max_nesting_depth 2",
+	}, {
+		desc:    "invalid",
+		env:     map[string]string{"PTERAFORM_NESTING_DEPTH": "deep"},
+		wantErr: "PTERAFORM_NESTING_DEPTH must be a non-negative integer",
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			n, err := readNesting(func(k string) string { return c.env[k] })
+			if err == nil && c.max > 0 {
+				n = n.limit(c.max)
+			}
+			if err == nil {
+				n, err = n.next()
+			}
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("got error %v, want %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, n.env()); diff != "" {
+				t.Errorf("env (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// offlineRunner runs terraform init so that providers are only installed
+// from local directories, never from a registry, so nested applies don't
+// depend on the network and always use the same provider packages.
+type offlineRunner struct {
+	runner
+	// pluginDirs are the directories providers may be installed from, in the
+	// layout of a local filesystem mirror or the plugin cache.
+	pluginDirs []string
+}
+
+func (o offlineRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	return o.stream(ctx, dir, nil, args...)
+}
+
+func (o offlineRunner) stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error) {
+	args = o.args(args)
+	if s, ok := o.runner.(streamer); ok && w != nil {
+		return s.stream(ctx, dir, w, args...)
+	}
+	return o.runner.run(ctx, dir, args...)
+}
+
+func (o offlineRunner) stdout(ctx context.Context, dir string, args ...string) (string, error) {
+	return runStdout(ctx, o.runner, dir, o.args(args)...)
+}
+
+// args returns args with -plugin-dir set to each of the plugin directories
+// if they're for init.
+func (o offlineRunner) args(args []string) []string {
+	if args[0] != "init" {
+		return args
+	}
+	args = append([]string{}, args...)
+	for _, d := range o.pluginDirs {
+		args = append(args, "-plugin-dir="+d)
+	}
+	return args
+}
+
+// offlinePluginDirs returns the directories providers may be installed from
+// when offline, or an error if there are none.
+func (r *ApplyResource) offlinePluginDirs() ([]string, error) {
+	var dirs []string
+	if r.provider != nil {
+		dirs = append(dirs, r.provider.PluginDirs...)
+		if r.provider.PluginCacheDir != "" {
+			dirs = append(dirs, r.provider.PluginCacheDir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("offline requires the provider's plugin_dirs or plugin_cache_dir to be set, to install providers from")
+	}
+	return dirs, nil
+}
+
+// offline reports whether nested runs for m are offline: as m sets, or as
+// the provider sets if it doesn't.
+func (r *ApplyResource) offline(m *ApplyResourceModel) bool {
+	if !m.Offline.IsNull() {
+		return m.Offline.ValueBool()
+	}
+	return r.provider != nil && r.provider.Offline
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestOffline(t *testing.T) {
+	m := testApplyModel(t.TempDir())
+	r := &ApplyResource{provider: &providerData{Offline: true, PluginCacheDir: "/cache"}}
+	if !r.offline(&m) {
+		t.Error("offline() = false with the provider's offline set, want true")
+	}
+	m.Offline = types.BoolValue(false)
+	if r.offline(&m) {
+		t.Error("offline() = true with the resource's offline false, want false")
+	}
+
+	fake := &fakeRunner{}
+	o := offlineRunner{runner: fake, pluginDirs: []string{"/mirror", "/cache"}}
+	for _, args := range [][]string{{"init", "-input=false"}, {"plan"}} {
+		if _, err := o.run(context.Background(), "", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"init -input=false -plugin-dir=/mirror -plugin-dir=/cache", "plan"}; len(fake.commands) != 2 || fake.commands[0] != want[0] || fake.commands[1] != want[1] {
+		t.Errorf("got commands %q, want %q", fake.commands, want)
+	}
+
+	if _, err := (&ApplyResource{provider: &providerData{}}).offlinePluginDirs(); err == nil {
+		t.Error("offlinePluginDirs() with no directories succeeded, want error")
+	}
+}
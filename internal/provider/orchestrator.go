@@ -0,0 +1,303 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// orchestratorPollInterval is how often the status of a run is checked.
+var orchestratorPollInterval = 10 * time.Second
+
+// orchestrator triggers runs of a stack managed by another Terraform
+// automation platform.
+type orchestrator interface {
+	// trigger starts a run and returns its ID.
+	trigger(ctx context.Context) (string, error)
+	// status returns the status of the run, and whether it has finished. It
+	// returns an error if the run finished unsuccessfully.
+	status(ctx context.Context, id string) (status string, done bool, err error)
+}
+
+// orchestratorConfig configures a driver for one platform.
+type orchestratorConfig struct {
+	client   *http.Client
+	endpoint string
+	token    string
+	stack    string
+	settings map[string]string
+}
+
+// orchestratorDrivers returns the driver for each supported platform.
+var orchestratorDrivers = map[string]func(orchestratorConfig) (orchestrator, error){
+	"atlantis":  newAtlantis,
+	"env0":      newEnv0,
+	"spacelift": newSpacelift,
+}
+
+// orchestratorPlatforms returns the names of the supported platforms.
+func orchestratorPlatforms() []string {
+	var names []string
+	for name := range orchestratorDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// orchestratorToken returns the token for platform from the environment.
+func orchestratorToken(getenv func(string) string, platform string) string {
+	switch platform {
+	case "atlantis":
+		return getenv("ATLANTIS_API_SECRET")
+	case "env0":
+		if key, secret := getenv("ENV0_API_KEY"), getenv("ENV0_API_SECRET"); key != "" || secret != "" {
+			return key + ":" + secret
+		}
+	case "spacelift":
+		return getenv("SPACELIFT_API_TOKEN")
+	}
+	return ""
+}
+
+// awaitRun triggers a run and waits for it to finish, returning its ID and
+// final status.
+func awaitRun(ctx context.Context, o orchestrator) (string, string, error) {
+	id, err := o.trigger(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("Unable to trigger run, got error: %s", err)
+	}
+	for {
+		status, done, err := o.status(ctx, id)
+		if err != nil || done {
+			return id, status, err
+		}
+		if err := sleep(ctx, orchestratorPollInterval); err != nil {
+			return id, status, err
+		}
+	}
+}
+
+// doJSON sends req with the JSON encoding of body, if it's non-nil, and
+// decodes the JSON response into out.
+func doJSON(client *http.Client, req *http.Request, body, out interface{}) error {
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		req.ContentLength = int64(len(b))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: unable to parse response, got error: %s", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// spacelift triggers runs of a Spacelift stack through its GraphQL API.
+type spacelift struct{ orchestratorConfig }
+
+func newSpacelift(c orchestratorConfig) (orchestrator, error) {
+	if c.endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required for spacelift, like https://example.app.spacelift.io")
+	}
+	return spacelift{c}, nil
+}
+
+func (s spacelift) query(ctx context.Context, query string, vars map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.endpoint, "/")+"/graphql", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := doJSON(s.client, req, map[string]interface{}{"query": query, "variables": vars}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("%s", resp.Errors[0].Message)
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+func (s spacelift) trigger(ctx context.Context) (string, error) {
+	var out struct {
+		RunTrigger struct {
+			ID string `json:"id"`
+		} `json:"runTrigger"`
+	}
+	if err := s.query(ctx, `mutation($stack: ID!) { runTrigger(stack: $stack) { id } }`, map[string]string{"stack": s.stack}, &out); err != nil {
+		return "", err
+	}
+	return out.RunTrigger.ID, nil
+}
+
+func (s spacelift) status(ctx context.Context, id string) (string, bool, error) {
+	var out struct {
+		Stack struct {
+			Run struct {
+				State string `json:"state"`
+			} `json:"run"`
+		} `json:"stack"`
+	}
+	if err := s.query(ctx, `query($stack: ID!, $run: ID!) { stack(id: $stack) { run(id: $run) { state } } }`, map[string]string{"stack": s.stack, "run": id}, &out); err != nil {
+		return "", false, err
+	}
+	switch state := out.Stack.Run.State; state {
+	case "FINISHED":
+		return state, true, nil
+	case "FAILED", "CANCELED", "DISCARDED", "STOPPED":
+		return state, true, fmt.Errorf("run %s finished with state %s", id, state)
+	default:
+		return state, false, nil
+	}
+}
+
+// env0 deploys an env0 environment through its REST API.
+type env0 struct{ orchestratorConfig }
+
+func newEnv0(c orchestratorConfig) (orchestrator, error) {
+	if c.endpoint == "" {
+		c.endpoint = "https://api.env0.com"
+	}
+	if !strings.Contains(c.token, ":") {
+		return nil, fmt.Errorf("token for env0 must be an API key and secret, as KEY:SECRET")
+	}
+	return env0{c}, nil
+}
+
+func (e env0) request(ctx context.Context, method, path string, body, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(e.endpoint, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	key, secret, _ := strings.Cut(e.token, ":")
+	req.SetBasicAuth(key, secret)
+	return doJSON(e.client, req, body, out)
+}
+
+func (e env0) trigger(ctx context.Context) (string, error) {
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := e.request(ctx, http.MethodPost, fmt.Sprintf("/environments/%s/deployments", url.PathEscape(e.stack)), map[string]interface{}{}, &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+func (e env0) status(ctx context.Context, id string) (string, bool, error) {
+	var out struct {
+		Status string `json:"status"`
+	}
+	if err := e.request(ctx, http.MethodGet, "/environments/deployments/"+url.PathEscape(id), nil, &out); err != nil {
+		return "", false, err
+	}
+	switch out.Status {
+	case "SUCCESS":
+		return out.Status, true, nil
+	case "FAILURE", "CANCELLED", "ABORTED", "TIMEOUT", "NEVER_DEPLOYED":
+		return out.Status, true, fmt.Errorf("deployment %s finished with status %s", id, out.Status)
+	default:
+		return out.Status, false, nil
+	}
+}
+
+// atlantis plans and applies a project through the Atlantis API, which
+// waits for each to finish before responding, so a run has finished
+// successfully once it's triggered.
+type atlantis struct{ orchestratorConfig }
+
+func newAtlantis(c orchestratorConfig) (orchestrator, error) {
+	if c.endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required for atlantis")
+	}
+	if c.settings["ref"] == "" {
+		return nil, fmt.Errorf("settings.ref is required for atlantis, with the repository in stack")
+	}
+	return atlantis{c}, nil
+}
+
+func (a atlantis) trigger(ctx context.Context) (string, error) {
+	vcs := a.settings["vcs"]
+	if vcs == "" {
+		vcs = "Github"
+	}
+	dir := a.settings["directory"]
+	if dir == "" {
+		dir = "."
+	}
+	ws := a.settings["workspace"]
+	if ws == "" {
+		ws = "default"
+	}
+	body := map[string]interface{}{
+		"Repository": a.stack,
+		"Ref":        a.settings["ref"],
+		"Type":       vcs,
+		"Paths":      []map[string]string{{"Directory": dir, "Workspace": ws}},
+	}
+	var out struct {
+		Error          interface{} `json:"Error"`
+		Failure        string      `json:"Failure"`
+		ProjectResults []struct {
+			Error   interface{} `json:"Error"`
+			Failure string      `json:"Failure"`
+		} `json:"ProjectResults"`
+	}
+	for _, cmd := range []string{"plan", "apply"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(a.endpoint, "/")+"/api/"+cmd, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-Atlantis-Token", a.token)
+		if err := doJSON(a.client, req, body, &out); err != nil {
+			return "", err
+		}
+		failure := out.Failure
+		if out.Error != nil {
+			failure = fmt.Sprint(out.Error)
+		}
+		for _, r := range out.ProjectResults {
+			if r.Error != nil {
+				failure = fmt.Sprint(r.Error)
+			} else if r.Failure != "" {
+				failure = r.Failure
+			}
+		}
+		if failure != "" {
+			return "", fmt.Errorf("atlantis %s failed: %s", cmd, failure)
+		}
+	}
+	return fmt.Sprintf("%s@%s:%s/%s", a.stack, a.settings["ref"], dir, ws), nil
+}
+
+func (a atlantis) status(ctx context.Context, id string) (string, bool, error) {
+	return "applied", true, nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &OrchestratorRunResource{}
+var _ resource.ResourceWithValidateConfig = &OrchestratorRunResource{}
+
+func NewOrchestratorRunResource() resource.Resource {
+	return &OrchestratorRunResource{}
+}
+
+// OrchestratorRunResource defines the resource implementation.
+type OrchestratorRunResource struct {
+	// client, if set, is the HTTP client used to call the platform. It is
+	// overridden in tests.
+	client *http.Client
+}
+
+// OrchestratorRunResourceModel describes the resource data model.
+type OrchestratorRunResourceModel struct {
+	Platform types.String `tfsdk:"platform"`
+	Endpoint types.String `tfsdk:"endpoint"`
+	Token    types.String `tfsdk:"token"`
+	Stack    types.String `tfsdk:"stack"`
+	Settings types.Map    `tfsdk:"settings"`
+	Triggers types.Map    `tfsdk:"triggers"`
+
+	Id     types.String `tfsdk:"id"`
+	Status types.String `tfsdk:"status"`
+}
+
+func (r *OrchestratorRunResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_orchestrator_run"
+}
+
+func (r *OrchestratorRunResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Triggers a run of a stack managed by a Terraform automation platform, and waits for it to finish successfully, so stacks already run by one can be sequenced with the outer configuration. A run is triggered when the resource is created and whenever its arguments change. Destroying the resource doesn't destroy the stack.",
+
+		Attributes: map[string]schema.Attribute{
+			"platform": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Platform that manages the stack: one of %s.", strings.Join(quoted(orchestratorPlatforms()), ", ")),
+				Required:            true,
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "Base URL of the platform, like `https://example.app.spacelift.io` or the Atlantis server's URL. Defaults to `https://api.env0.com` for env0, and is required otherwise.",
+				Optional:            true,
+			},
+			"token": schema.StringAttribute{
+				MarkdownDescription: "API token: a Spacelift API token, an env0 API key and secret as `KEY:SECRET`, or the Atlantis API secret. Defaults to `SPACELIFT_API_TOKEN`, `ENV0_API_KEY` and `ENV0_API_SECRET`, or `ATLANTIS_API_SECRET` from the environment.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"stack": schema.StringAttribute{
+				MarkdownDescription: "What to run: the Spacelift stack ID, the env0 environment ID, or the repository, like `example/infra`, for Atlantis.",
+				Required:            true,
+			},
+			"settings": schema.MapAttribute{
+				MarkdownDescription: "Platform-specific settings. Atlantis requires `ref`, the branch or commit to plan and apply, and accepts `directory`, `workspace`, and `vcs`, which defaults to `Github`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values that trigger a new run whenever they change.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "ID of the last run.",
+				Computed:            true,
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "Final status of the last run, as the platform reports it.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// quoted returns each of strs in backticks.
+func quoted(strs []string) []string {
+	out := make([]string, len(strs))
+	for i, s := range strs {
+		out[i] = "`" + s + "`"
+	}
+	return out
+}
+
+func (r *OrchestratorRunResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data OrchestratorRunResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if p := data.Platform; !p.IsUnknown() && !slices.Contains(orchestratorPlatforms(), p.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("platform"), "Invalid Platform", fmt.Sprintf("platform must be one of %s, got %q.", strings.Join(orchestratorPlatforms(), ", "), p.ValueString()))
+	}
+}
+
+// orchestrator returns the driver for the configured platform.
+func (r *OrchestratorRunResource) orchestrator(ctx context.Context, m *OrchestratorRunResourceModel) (orchestrator, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	platform := m.Platform.ValueString()
+	newDriver, ok := orchestratorDrivers[platform]
+	if !ok {
+		diags.AddAttributeError(path.Root("platform"), "Invalid Platform", fmt.Sprintf("platform must be one of %s, got %q.", strings.Join(orchestratorPlatforms(), ", "), platform))
+		return nil, diags
+	}
+	c := orchestratorConfig{
+		client:   r.client,
+		endpoint: m.Endpoint.ValueString(),
+		token:    m.Token.ValueString(),
+		stack:    m.Stack.ValueString(),
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	if c.token == "" {
+		c.token = orchestratorToken(os.Getenv, platform)
+	}
+	diags.Append(m.Settings.ElementsAs(ctx, &c.settings, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	o, err := newDriver(c)
+	if err != nil {
+		diags.AddError("Invalid Orchestrator Configuration", err.Error())
+		return nil, diags
+	}
+	return o, diags
+}
+
+// run triggers a run and waits for it to finish.
+func (r *OrchestratorRunResource) run(ctx context.Context, m *OrchestratorRunResourceModel) diag.Diagnostics {
+	o, diags := r.orchestrator(ctx, m)
+	if diags.HasError() {
+		return diags
+	}
+	id, status, err := awaitRun(ctx, o)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to run %s stack %s, got error: %s", m.Platform.ValueString(), m.Stack.ValueString(), err))
+		return diags
+	}
+	m.Id, m.Status = types.StringValue(id), types.StringValue(status)
+	return diags
+}
+
+func (r *OrchestratorRunResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data OrchestratorRunResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.run(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OrchestratorRunResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The last run is what it was; there's nothing to refresh.
+}
+
+func (r *OrchestratorRunResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data OrchestratorRunResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.run(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OrchestratorRunResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Nothing to delete; the stack is left as it is.
+}
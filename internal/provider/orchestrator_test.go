@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAwaitRun(t *testing.T) {
+	defer func(d time.Duration) { orchestratorPollInterval = d }(orchestratorPollInterval)
+	orchestratorPollInterval = 0
+
+	for _, c := range []struct {
+		desc       string
+		platform   string
+		token      string
+		settings   map[string]string
+		handler    func(w http.ResponseWriter, r *http.Request, body map[string]interface{})
+		wantID     string
+		wantStatus string
+		wantErr    string
+	}{{
+		desc:     "spacelift",
+		platform: "spacelift",
+		token:    "jwt",
+		handler: func() func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			polls := 0
+			return func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+				if r.URL.Path != "/graphql" || r.Header.Get("Authorization") != "Bearer jwt" {
+					http.Error(w, "unexpected request", http.StatusBadRequest)
+					return
+				}
+				if strings.HasPrefix(body["query"].(string), "mutation") {
+					w.Write([]byte(`{"data": {"runTrigger": {"id": "01RUN"}}}`))
+					return
+				}
+				polls++
+				state := "APPLYING"
+				if polls > 1 {
+					state = "FINISHED"
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"stack": map[string]interface{}{"run": map[string]string{"state": state}}}})
+			}
+		}(),
+		wantID:     "01RUN",
+		wantStatus: "FINISHED",
+	}, {
+		desc:     "env0 failure",
+		platform: "env0",
+		token:    "key:secret",
+		handler: func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			if user, pass, _ := r.BasicAuth(); user != "key" || pass != "secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			switch r.Method + " " + r.URL.Path {
+			case "POST /environments/env-1/deployments":
+				w.Write([]byte(`{"id": "dep-1"}`))
+			case "GET /environments/deployments/dep-1":
+				w.Write([]byte(`{"status": "FAILURE"}`))
+			default:
+				http.NotFound(w, r)
+			}
+		},
+		wantID:     "dep-1",
+		wantStatus: "FAILURE",
+		wantErr:    "deployment dep-1 finished with status FAILURE",
+	}, {
+		desc:     "atlantis",
+		platform: "atlantis",
+		token:    "secret",
+		settings: map[string]string{"ref": "main", "directory": "network"},
+		handler: func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			if r.Header.Get("X-Atlantis-Token") != "secret" || body["Ref"] != "main" {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"Error": null, "Failure": "", "ProjectResults": [{"Error": null, "Failure": ""}]}`))
+		},
+		wantID:     "example/infra@main:network/default",
+		wantStatus: "applied",
+	}, {
+		desc:     "atlantis failure",
+		platform: "atlantis",
+		token:    "secret",
+		settings: map[string]string{"ref": "main"},
+		handler: func(w http.ResponseWriter, r *http.Request, body map[string]interface{}) {
+			w.Write([]byte(`{"ProjectResults": [{"Failure": "plan has errors"}]}`))
+		},
+		wantErr: "atlantis plan failed: plan has errors",
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				c.handler(w, r, body)
+			}))
+			defer srv.Close()
+
+			o, err := orchestratorDrivers[c.platform](orchestratorConfig{
+				client:   srv.Client(),
+				endpoint: srv.URL,
+				token:    c.token,
+				stack:    map[string]string{"spacelift": "network", "env0": "env-1", "atlantis": "example/infra"}[c.platform],
+				settings: c.settings,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, status, err := awaitRun(context.Background(), o)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Errorf("got error %v, want %q", err, c.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if id != c.wantID || status != c.wantStatus {
+				t.Errorf("got run %q with status %q, want %q with %q", id, status, c.wantID, c.wantStatus)
+			}
+		})
+	}
+}
+
+func TestOrchestratorToken(t *testing.T) {
+	env := map[string]string{"ENV0_API_KEY": "key", "ENV0_API_SECRET": "secret", "SPACELIFT_API_TOKEN": "jwt"}
+	getenv := func(k string) string { return env[k] }
+	for platform, want := range map[string]string{"env0": "key:secret", "spacelift": "jwt", "atlantis": ""} {
+		if got := orchestratorToken(getenv, platform); got != want {
+			t.Errorf("orchestratorToken(%s) = %q, want %q", platform, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// outerMetadata returns environment variables describing the outer run, so
+// nested configurations can record the provenance of the resources they
+// create by declaring the corresponding variables. Terraform ignores them
+// otherwise. getenv looks up the provider's environment, which it inherits
+// from the outer terraform.
+//
+// Terraform doesn't tell providers the address of the resource they're
+// managing, so that can't be included.
+func outerMetadata(getenv func(string) string) []string {
+	env := []string{"TF_VAR_pteraform_outer_workspace=" + outerWorkspace(getenv)}
+	if id := getenv("TFC_RUN_ID"); id != "" {
+		// Set in HCP Terraform and Terraform Enterprise runs.
+		env = append(env, "TF_VAR_pteraform_outer_run_id="+id)
+	}
+	return env
+}
+
+// outerDataDir returns the outer terraform's data directory, relative to the
+// outer root module, which is the provider's working directory.
+func outerDataDir(getenv func(string) string) string {
+	if dir := getenv("TF_DATA_DIR"); dir != "" {
+		return dir
+	}
+	return ".terraform"
+}
+
+// outerWorkspace returns the outer workspace, which terraform records in its
+// data directory in the outer root module, unless it's overridden by
+// TF_WORKSPACE.
+func outerWorkspace(getenv func(string) string) string {
+	if ws := getenv("TF_WORKSPACE"); ws != "" {
+		return ws
+	}
+	b, err := os.ReadFile(filepath.Join(outerDataDir(getenv), "environment"))
+	if ws := strings.TrimSpace(string(b)); err == nil && ws != "" {
+		return ws
+	}
+	return "default"
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOuterMetadata(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataDir, "environment"), []byte("staging"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		desc string
+		env  map[string]string
+		want []string
+	}{{
+		desc: "default",
+		env:  map[string]string{"TF_DATA_DIR": t.TempDir()},
+		want: []string{"TF_VAR_pteraform_outer_workspace=default"},
+	}, {
+		desc: "selected workspace",
+		env:  map[string]string{"TF_DATA_DIR": dataDir},
+		want: []string{"TF_VAR_pteraform_outer_workspace=staging"},
+	}, {
+		desc: "TF_WORKSPACE",
+		env:  map[string]string{"TF_DATA_DIR": dataDir, "TF_WORKSPACE": "prod"},
+		want: []string{"TF_VAR_pteraform_outer_workspace=prod"},
+	}, {
+		desc: "run ID",
+		env:  map[string]string{"TF_DATA_DIR": dataDir, "TFC_RUN_ID": "run-abc123"},
+		want: []string{"TF_VAR_pteraform_outer_workspace=staging", "TF_VAR_pteraform_outer_run_id=run-abc123"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			got := outerMetadata(func(k string) string { return c.env[k] })
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("outerMetadata (-want,+got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// outputHeadLines and outputTailLines are how many lines from the start and
+// end of a command's output are included in errors: the start shows what it
+// was doing, and the end usually why it failed.
+const (
+	outputHeadLines = 50
+	outputTailLines = 200
+)
+
+// outputLogDir is where the full output of failed commands is written when
+// it's too long to include in errors, relative to the directory they ran in,
+// unless the provider has a temp_dir.
+var outputLogDir = filepath.Join(".terraform", "pteraform-logs")
+
+// outputLogsDir returns the directory the full output of failed commands
+// run in dir is saved in: outputLogDir in dir, or if tempDir isn't "", a
+// directory in it named for dir, so output, which can include sensitive
+// values, is kept with the other scratch files.
+func outputLogsDir(tempDir, dir string) string {
+	if tempDir == "" {
+		return filepath.Join(dir, outputLogDir)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(tempDir, fmt.Sprintf("pteraform-logs-%x", sum[:8]))
+}
+
+// normalizeOutput returns out, the output of a command, as valid UTF-8
+// with \n line endings, so it can be stored in string attributes: invalid
+// bytes, as written by terraform in some legacy locales, are replaced with
+// U+FFFD, and \r\n and lone \r with \n.
+func normalizeOutput(out string) string {
+	out = strings.ToValidUTF8(out, "\uFFFD")
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	return strings.ReplaceAll(out, "\r", "\n")
+}
+
+// outputLines returns the lines of out, without a trailing newline.
+func outputLines(out string) []string {
+	return strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+}
+
+// truncateOutput returns out, or if it's longer than outputHeadLines and
+// outputTailLines together, its first and last lines with a line between
+// them saying how many were left out and, if logFile isn't "", where the
+// full output is.
+func truncateOutput(out, logFile string) string {
+	lines := outputLines(out)
+	if len(lines) <= outputHeadLines+outputTailLines {
+		return out
+	}
+	omitted := len(lines) - outputHeadLines - outputTailLines
+	marker := fmt.Sprintf("[... %d lines omitted ...]", omitted)
+	if logFile != "" {
+		marker = fmt.Sprintf("[... %d lines omitted, the full output is in %s ...]", omitted, logFile)
+	}
+	kept := append(append(append([]string{}, lines[:outputHeadLines]...), marker), lines[len(lines)-outputTailLines:]...)
+	return strings.Join(kept, "\n")
+}
+
+// saveOutput writes out, the output of the command name that failed, to a
+// log in logDir with permissions mode, or secretFileMode if it's 0, if
+// truncateOutput would shorten it, and returns its path, or "" if it wasn't
+// written. Each log replaces the last one of the same command.
+func saveOutput(logDir, name, out string, mode os.FileMode) string {
+	if len(outputLines(out)) <= outputHeadLines+outputTailLines {
+		return ""
+	}
+	fn := filepath.Join(logDir, name+".log")
+	if err := os.MkdirAll(logDir, 0o700); err != nil {
+		return ""
+	}
+	if err := writeFile(fn, []byte(out), modeOr(mode, secretFileMode)); err != nil {
+		return ""
+	}
+	return fn
+}
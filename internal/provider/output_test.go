@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns n lines, "line 1" to "line n".
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestNormalizeOutput(t *testing.T) {
+	for _, c := range []struct {
+		desc, in, want string
+	}{
+		{"utf-8", "Création terminée ✓\n", "Création terminée ✓\n"},
+		{"latin-1", "Cr\xe9ation termin\xe9e\n", "Cr\uFFFDation termin\uFFFDe\n"},
+		{"crlf", "Apply complete!\r\nOutputs:\r\n", "Apply complete!\nOutputs:\n"},
+		{"cr", "a\rb\n", "a\nb\n"},
+	} {
+		if got := normalizeOutput(c.in); got != c.want {
+			t.Errorf("%s: normalizeOutput(%q) = %q, want %q", c.desc, c.in, got, c.want)
+		}
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	short := numberedLines(outputHeadLines + outputTailLines)
+	if got := truncateOutput(short, "x.log"); got != short {
+		t.Errorf("truncateOutput(%d lines) changed it", outputHeadLines+outputTailLines)
+	}
+
+	got := truncateOutput(numberedLines(1000), "x.log")
+	lines := strings.Split(got, "\n")
+	if len(lines) != outputHeadLines+1+outputTailLines {
+		t.Fatalf("truncateOutput(1000 lines) has %d lines, want %d", len(lines), outputHeadLines+1+outputTailLines)
+	}
+	if lines[0] != "line 1" || lines[outputHeadLines-1] != "line 50" || lines[len(lines)-1] != "line 1000" || lines[outputHeadLines+1] != "line 801" {
+		t.Errorf("truncateOutput(1000 lines) kept the wrong lines:\n%s", got)
+	}
+	if want := "[... 750 lines omitted, the full output is in x.log ...]"; lines[outputHeadLines] != want {
+		t.Errorf("marker = %q, want %q", lines[outputHeadLines], want)
+	}
+	if got := truncateOutput(numberedLines(1000), ""); !strings.Contains(got, "\n[... 750 lines omitted ...]\n") {
+		t.Errorf("truncateOutput without a log file has no marker:\n%s", got)
+	}
+}
+
+func TestSaveOutput(t *testing.T) {
+	dir := t.TempDir()
+	logDir := outputLogsDir("", dir)
+	if fn := saveOutput(logDir, "terraform-apply", numberedLines(10), 0); fn != "" {
+		t.Errorf("saveOutput(10 lines) = %q, want nothing saved", fn)
+	}
+	long := numberedLines(1000)
+	fn := saveOutput(logDir, "terraform-apply", long, 0)
+	if want := filepath.Join(dir, outputLogDir, "terraform-apply.log"); fn != want {
+		t.Fatalf("saveOutput() = %q, want %q", fn, want)
+	}
+	if b, err := os.ReadFile(fn); err != nil || string(b) != long {
+		t.Errorf("saved output = %d bytes, %v, want the full output", len(b), err)
+	}
+	if fi, err := os.Stat(fn); err != nil || runtime.GOOS != "windows" && fi.Mode().Perm() != secretFileMode {
+		t.Errorf("saved output has permissions %v, %v, want %o", fi.Mode().Perm(), err, secretFileMode)
+	}
+	if fn := saveOutput(logDir, "terraform-apply", long, 0o640); fn == "" {
+		t.Error("saveOutput() saved nothing")
+	} else if fi, err := os.Stat(fn); err != nil || runtime.GOOS != "windows" && fi.Mode().Perm() != 0o640 {
+		t.Errorf("saved output has permissions %v, %v, want 0640", fi.Mode().Perm(), err)
+	}
+
+	// With a temp_dir, logs are kept there, apart for each working directory.
+	tmp := t.TempDir()
+	if got := outputLogsDir(tmp, dir); filepath.Dir(got) != tmp || got == outputLogsDir(tmp, t.TempDir()) {
+		t.Errorf("outputLogsDir(%s, %s) = %s, want a directory in %s for %s", tmp, dir, got, tmp, dir)
+	}
+
+	err := &runError{Command: "apply", ExitCode: 1, Output: long, LogFile: fn, Err: errors.New("exit status 1")}
+	if msg := err.Error(); strings.Contains(msg, "line 500\n") || !strings.Contains(msg, fn) {
+		t.Errorf("runError.Error() isn't truncated with a pointer to %s", fn)
+	}
+}
+
+func TestDoApplyRemovesOutputLogs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": "", filepath.Join(outputLogDir, "terraform-apply.log"): "failed"})
+	m := testApplyModel(dir)
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return &fakeRunner{} }}
+	if _, _, err := r.doApply(context.Background(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, outputLogDir)); !os.IsNotExist(err) {
+		t.Errorf("%s after a successful apply: %v, want it removed", outputLogDir, err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// outputsSnapshotKey is the private state key the nested outputs after the
+// last successful apply are stored under, so the next one can report which
+// changed.
+const outputsSnapshotKey = "outputs_snapshot"
+
+// sensitiveValue is shown in place of the values of sensitive outputs.
+const sensitiveValue = "(sensitive)"
+
+var applyOutputChangeAttrTypes = map[string]attr.Type{
+	"old": types.StringType,
+	"new": types.StringType,
+}
+
+// outputSnapshot is the JSON-encoded value of a nested output, or for a
+// sensitive one, only a digest of it.
+type outputSnapshot struct {
+	Value  string `json:"value,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// display returns how s is shown in outputs_changed.
+func (s outputSnapshot) display() string {
+	if s.Digest != "" {
+		return sensitiveValue
+	}
+	return s.Value
+}
+
+// snapshotOutputs returns the snapshot of the outputs in out, the output of
+// terraform output -json.
+func snapshotOutputs(out string) (map[string]outputSnapshot, error) {
+	values, sensitive, err := stateOutputs(out)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := map[string]outputSnapshot{}
+	for name, v := range values {
+		snapshot[name] = outputSnapshot{Value: v}
+	}
+	for name, v := range sensitive {
+		snapshot[name] = outputSnapshot{Digest: fmt.Sprintf("%x", sha256.Sum256([]byte(v)))}
+	}
+	return snapshot, nil
+}
+
+// outputsChanged returns outputs_changed for the outputs in now compared
+// with those before: the old and new values of each output that changed,
+// with old null for those added and new null for those removed.
+func outputsChanged(before, now map[string]outputSnapshot) (types.Map, diag.Diagnostics) {
+	changes := map[string]attr.Value{}
+	pair := func(old, new types.String) attr.Value {
+		return types.ObjectValueMust(applyOutputChangeAttrTypes, map[string]attr.Value{"old": old, "new": new})
+	}
+	for name, n := range now {
+		if b, ok := before[name]; !ok {
+			changes[name] = pair(types.StringNull(), types.StringValue(n.display()))
+		} else if b != n {
+			changes[name] = pair(types.StringValue(b.display()), types.StringValue(n.display()))
+		}
+	}
+	for name, b := range before {
+		if _, ok := now[name]; !ok {
+			changes[name] = pair(types.StringValue(b.display()), types.StringNull())
+		}
+	}
+	return types.MapValue(types.ObjectType{AttrTypes: applyOutputChangeAttrTypes}, changes)
+}
+
+// recordOutputChanges sets outputs_changed after an apply, and stores the
+// nested outputs for the next one. prior is nil when the resource is
+// created.
+func (r *ApplyResource) recordOutputChanges(ctx context.Context, m *ApplyResourceModel, prior privateStateReader, private privateState) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.OutputsChanged = types.MapNull(types.ObjectType{AttrTypes: applyOutputChangeAttrTypes})
+	out, err := r.nestedOutputJSON(ctx, m)
+	if err != nil {
+		diags.AddWarning("Unable to Read Nested Outputs", fmt.Sprintf("outputs_changed won't be set: %s", err))
+		return diags
+	}
+	now, err := snapshotOutputs(out)
+	if err != nil {
+		diags.AddWarning("Unable to Read Nested Outputs", fmt.Sprintf("outputs_changed won't be set: %s", err))
+		return diags
+	}
+	before := map[string]outputSnapshot{}
+	if prior != nil {
+		b, d := prior.GetKey(ctx, outputsSnapshotKey)
+		diags.Append(d...)
+		if len(b) > 0 && json.Unmarshal(b, &before) != nil || before == nil {
+			before = map[string]outputSnapshot{}
+		}
+	}
+	var d diag.Diagnostics
+	m.OutputsChanged, d = outputsChanged(before, now)
+	diags.Append(d...)
+
+	b, err := json.Marshal(now)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record nested outputs, got error: %s", err))
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, outputsSnapshotKey, b)...)
+	return diags
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestOutputsChanged(t *testing.T) {
+	before, err := snapshotOutputs(`{
+  "endpoint": {"sensitive": false, "type": "string", "value": "a.example.com"},
+  "password": {"sensitive": true, "type": "string", "value": "hunter2"},
+  "token": {"sensitive": true, "type": "string", "value": "abc"},
+  "region": {"sensitive": false, "type": "string", "value": "us-east-1"},
+  "old": {"sensitive": false, "type": "number", "value": 1}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := before["password"]; got.Value != "" || got.Digest == "" {
+		t.Errorf("sensitive snapshot = %+v, want only a digest", got)
+	}
+	now, err := snapshotOutputs(`{
+  "endpoint": {"sensitive": false, "type": "string", "value": "b.example.com"},
+  "password": {"sensitive": true, "type": "string", "value": "hunter3"},
+  "token": {"sensitive": true, "type": "string", "value": "abc"},
+  "region": {"sensitive": false, "type": "string", "value": "us-east-1"},
+  "ids": {"sensitive": false, "type": ["list", "string"], "value": ["x"]}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, diags := outputsChanged(before, now)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	got := map[string][2]*string{}
+	for name, v := range m.Elements() {
+		attrs := v.(types.Object).Attributes()
+		var pair [2]*string
+		for i, k := range []string{"old", "new"} {
+			if s := attrs[k].(types.String); !s.IsNull() {
+				v := s.ValueString()
+				pair[i] = &v
+			}
+		}
+		got[name] = pair
+	}
+	str := func(s string) *string { return &s }
+	want := map[string][2]*string{
+		"endpoint": {str(`"a.example.com"`), str(`"b.example.com"`)},
+		"password": {str("(sensitive)"), str("(sensitive)")},
+		"ids":      {nil, str(`["x"]`)},
+		"old":      {str("1"), nil},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("outputsChanged (-want +got):\n%s", diff)
+	}
+}
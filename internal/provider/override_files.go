@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checkOverrideFileName returns an error if name isn't the name of a file
+// terraform loads as an override file from the directory it's run in.
+func checkOverrideFileName(name string) error {
+	if filepath.Base(name) != name || !filepath.IsLocal(name) {
+		return fmt.Errorf("%q must be a file name in the working directory, without a directory", name)
+	}
+	for _, suffix := range []string{".tf", ".tf.json"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok && (base == "override" || strings.HasSuffix(base, "_override")) {
+			if name == providersOverrideFile {
+				return fmt.Errorf("%q is written for generated_provider_config", name)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%q must be named override.tf, or end in _override.tf or _override.tf.json, for terraform to load it as an override file", name)
+}
+
+// writeOverrideFiles writes files, their contents by name, to dir, with
+// permissions mode, or secretFileMode if it's 0, since overrides like
+// backends can hold credentials, and returns a function that removes them
+// again. Files that already exist, say because they're part of the nested
+// configuration, are never overwritten or removed: it's an error if their
+// contents differ.
+func writeOverrideFiles(dir string, files map[string]string, mode os.FileMode) (func(), error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var written []string
+	remove := func() {
+		for _, fn := range written {
+			os.Remove(fn)
+		}
+	}
+	for _, name := range names {
+		if err := checkOverrideFileName(name); err != nil {
+			remove()
+			return nil, err
+		}
+		fn := filepath.Join(dir, name)
+		if b, err := os.ReadFile(fn); err == nil {
+			if !bytes.Equal(b, []byte(files[name])) {
+				remove()
+				return nil, fmt.Errorf("%s already exists in %s, so it can't be written for override_files", name, dir)
+			}
+			continue
+		}
+		if err := writeFile(fn, []byte(files[name]), modeOr(mode, secretFileMode)); err != nil {
+			remove()
+			return nil, fmt.Errorf("Unable to write %s, got error: %s", name, err)
+		}
+		written = append(written, fn)
+	}
+	return remove, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckOverrideFileName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"override.tf":               true,
+		"override.tf.json":          true,
+		"backend_override.tf":       true,
+		"versions_override.tf.json": true,
+		"backend.tf":                false,
+		"override.tfvars":           false,
+		"myoverride.tf":             false,
+		"_override.tf.txt":          false,
+		"sub/backend_override.tf":   false,
+		"../backend_override.tf":    false,
+		providersOverrideFile:       false,
+	} {
+		if err := checkOverrideFileName(name); (err == nil) != valid {
+			t.Errorf("checkOverrideFileName(%q) = %v, want valid %t", name, err, valid)
+		}
+	}
+}
+
+func TestWriteOverrideFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf":              "",
+		"vendored_override.tf": "# part of the configuration",
+	})
+	backend := `terraform {
+  backend "local" {}
+}
+`
+	remove, err := writeOverrideFiles(dir, map[string]string{
+		"backend_override.tf":       backend,
+		"versions_override.tf.json": `{"terraform": {"required_version": ">= 1.5"}}`,
+	}, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "backend_override.tf")); err != nil || runtime.GOOS != "windows" && fi.Mode().Perm() != 0o640 {
+		t.Errorf("backend_override.tf has permissions %v, %v, want 0640", fi.Mode().Perm(), err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "backend_override.tf")); err != nil || string(b) != backend {
+		t.Errorf("backend_override.tf = %q, %v", b, err)
+	}
+	remove()
+	for _, name := range []string{"backend_override.tf", "versions_override.tf.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s not removed: %v", name, err)
+		}
+	}
+
+	// Files of the nested configuration are left alone, and nothing else is
+	// written.
+	if _, err := writeOverrideFiles(dir, map[string]string{
+		"a_override.tf":        backend,
+		"vendored_override.tf": backend,
+	}, 0); err == nil {
+		t.Error("overwriting vendored_override.tf: got no error")
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "vendored_override.tf")); err != nil || string(b) != "# part of the configuration" {
+		t.Errorf("vendored_override.tf = %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a_override.tf")); !os.IsNotExist(err) {
+		t.Errorf("a_override.tf not removed: %v", err)
+	}
+
+	// Unless they already have the same contents, when they're kept.
+	remove, err = writeOverrideFiles(dir, map[string]string{"vendored_override.tf": "# part of the configuration"}, 0)
+	if err != nil {
+		t.Fatalf("same contents: %v", err)
+	}
+	remove()
+	if _, err := os.Stat(filepath.Join(dir, "vendored_override.tf")); err != nil {
+		t.Errorf("vendored_override.tf removed: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"strings"
+)
+
+// passthroughVars returns the TF_VAR_ variables that forward the variables
+// of environ named TF_VAR_ then prefix to the nested configuration, with the
+// prefix stripped, so TF_VAR_nested_region sets the nested region variable
+// for the prefix nested_. It returns nothing if prefix is "".
+func passthroughVars(environ []string, prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	var vars []string
+	for _, kv := range environ {
+		if rest, ok := strings.CutPrefix(kv, "TF_VAR_"+prefix); ok && !strings.HasPrefix(rest, "=") {
+			vars = append(vars, "TF_VAR_"+rest)
+		}
+	}
+	return vars
+}
+
+// nestedEnviron returns the provider's environment as nested terraform for
+// m inherits it, without the variables inherit_environment leaves out, with
+// the variables passed through to the nested configuration by
+// passthrough_var_prefix and those of environment, as terraform sees it when
+// looking for TF_VAR_ variables.
+func (r *ApplyResource) nestedEnviron(m *ApplyResourceModel) []string {
+	environ := r.provider.inherit().filter(os.Environ())
+	environ = append(environ, passthroughVars(environ, m.PassthroughPrefix.ValueString())...)
+	return append(environ, m.environmentEnv()...)
+}
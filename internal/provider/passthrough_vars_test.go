@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPassthroughVars(t *testing.T) {
+	environ := []string{"TF_VAR_nested_region=us-east-1", "TF_VAR_nested_tags={\"a\"=\"b\"}", "TF_VAR_region=eu-west-1", "TF_VAR_nested_=empty", "NESTED_x=y"}
+	want := []string{"TF_VAR_region=us-east-1", "TF_VAR_tags={\"a\"=\"b\"}"}
+	if diff := cmp.Diff(want, passthroughVars(environ, "nested_")); diff != "" {
+		t.Errorf("passthroughVars (-want,+got): %s", diff)
+	}
+	if got := passthroughVars(environ, ""); got != nil {
+		t.Errorf("passthroughVars with no prefix = %q", got)
+	}
+}
+
+func TestNestedEnvironInherit(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `variable "region" {}`})
+	t.Setenv("TF_VAR_region", "us-east-1")
+	t.Setenv("TF_VAR_nested_zone", "a")
+	r := &ApplyResource{provider: &providerData{InheritEnvironment: inheritEnvironment{deny: []string{"TF_*"}}}}
+	m := testApplyModel(dir)
+	m.PassthroughPrefix = types.StringValue("nested_")
+	for _, kv := range r.nestedEnviron(&m) {
+		if strings.HasPrefix(kv, "TF_VAR_") {
+			t.Errorf("nestedEnviron() has denied variable %s", kv)
+		}
+	}
+	if diags := r.checkVariables(context.Background(), &m); !diags.HasError() {
+		t.Error("checkVariables() with region denied succeeded, want a missing variable")
+	}
+}
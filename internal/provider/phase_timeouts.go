@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ApplyPhaseTimeoutsModel describes the phase_timeouts block.
+type ApplyPhaseTimeoutsModel struct {
+	Init  types.String `tfsdk:"init"`
+	Plan  types.String `tfsdk:"plan"`
+	Apply types.String `tfsdk:"apply"`
+}
+
+// timeouts returns the time limit for each terraform command that has one.
+// m may be nil.
+func (m *ApplyPhaseTimeoutsModel) timeouts() (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	if m == nil {
+		return timeouts, nil
+	}
+	for cmd, v := range map[string]types.String{"init": m.Init, "plan": m.Plan, "apply": m.Apply} {
+		if v.IsNull() {
+			continue
+		}
+		d, err := time.ParseDuration(v.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s timeout: %s", cmd, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%s timeout must be positive, got %q", cmd, v.ValueString())
+		}
+		timeouts[cmd] = d
+	}
+	return timeouts, nil
+}
+
+// timeoutRunner runs terraform commands with a time limit for each kind of
+// command, after which terraform is interrupted like when the outer
+// terraform is.
+type timeoutRunner struct {
+	runner
+	timeouts map[string]time.Duration
+}
+
+func (t timeoutRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	return t.stream(ctx, dir, nil, args...)
+}
+
+func (t timeoutRunner) stream(ctx context.Context, dir string, w io.Writer, args ...string) (string, error) {
+	d, ok := t.timeouts[args[0]]
+	if ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	var out string
+	var err error
+	if s, ok := t.runner.(streamer); ok && w != nil {
+		out, err = s.stream(ctx, dir, w, args...)
+	} else {
+		out, err = t.runner.run(ctx, dir, args...)
+	}
+	if ok && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out, fmt.Errorf("terraform %s timed out after %s: %w", args[0], d, err)
+	}
+	return out, err
+}
+
+func (t timeoutRunner) stdout(ctx context.Context, dir string, args ...string) (string, error) {
+	d, ok := t.timeouts[args[0]]
+	if ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	out, err := runStdout(ctx, t.runner, dir, args...)
+	if ok && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out, fmt.Errorf("terraform %s timed out after %s: %w", args[0], d, err)
+	}
+	return out, err
+}
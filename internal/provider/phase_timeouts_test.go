@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// slowRunner runs commands that last until they're cancelled.
+type slowRunner struct{}
+
+func (slowRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestTimeoutRunner(t *testing.T) {
+	tf := timeoutRunner{runner: slowRunner{}, timeouts: map[string]time.Duration{"init": time.Millisecond}}
+	_, err := tf.run(context.Background(), t.TempDir(), "init")
+	if err == nil || !strings.Contains(err.Error(), "terraform init timed out after 1ms") {
+		t.Errorf("init: got error %v, want timeout", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tf.run(ctx, t.TempDir(), "apply")
+	if err == nil || strings.Contains(err.Error(), "timed out") {
+		t.Errorf("cancelled apply: got error %v, want cancellation", err)
+	}
+}
+
+func TestPhaseTimeouts(t *testing.T) {
+	m := &ApplyPhaseTimeoutsModel{Init: types.StringValue("5m"), Plan: types.StringNull(), Apply: types.StringValue("2h")}
+	got, err := m.timeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["init"] != 5*time.Minute || got["apply"] != 2*time.Hour {
+		t.Errorf("timeouts() = %v", got)
+	}
+	m.Plan = types.StringValue("0s")
+	if _, err := m.timeouts(); err == nil {
+		t.Error("timeouts() with a zero plan timeout succeeded, want error")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// pidFileName returns where the pid of a running nested terraform of
+// workspace is recorded, relative to its working directory, so that it can
+// be found again if the provider dies while it's running. Each workspace
+// has its own, so resources sharing a working directory don't wait for each
+// other; runs outside of any resource's workspace, like data sources', use
+// workspace "".
+func pidFileName(workspace string) string {
+	if workspace == "" {
+		return filepath.Join(".terraform", "pteraform.pid")
+	}
+	return filepath.Join(".terraform", "pteraform-"+workspace+".pid")
+}
+
+// pidFilePattern matches the pid files of every workspace.
+var pidFilePattern = filepath.Join(".terraform", "pteraform*.pid")
+
+// orphanPollInterval is how often recoverOrphan checks whether an orphaned
+// terraform has exited.
+var orphanPollInterval = time.Second
+
+// writePIDFile records pid as the nested terraform of workspace running in
+// dir, with its start time so a later process reusing the pid isn't
+// mistaken for it, in a file with permissions mode, or defaultFileMode if
+// it's 0.
+func writePIDFile(dir, workspace string, pid int, mode os.FileMode) error {
+	start, err := processStartTime(pid)
+	if err != nil {
+		return err
+	}
+	fn := filepath.Join(dir, pidFileName(workspace))
+	if err := os.MkdirAll(filepath.Dir(fn), 0o755); err != nil {
+		return err
+	}
+	return writeFile(fn, []byte(fmt.Sprintf("%d %s\n", pid, start)), modeOr(mode, defaultFileMode))
+}
+
+// removePIDFile removes the record of the nested terraform of workspace
+// running in dir.
+func removePIDFile(dir, workspace string) {
+	os.Remove(filepath.Join(dir, pidFileName(workspace)))
+}
+
+// recoverOrphan waits for a nested terraform of workspace left running in
+// dir by a provider process that died to exit, so that it isn't run
+// concurrently with the next operation. Waiting, rather than killing it,
+// lets it finish and release its state lock cleanly.
+func recoverOrphan(ctx context.Context, dir, workspace string) error {
+	return recoverPIDFile(ctx, filepath.Join(dir, pidFileName(workspace)))
+}
+
+// recoverOrphans is like recoverOrphan, but waits for the nested terraform
+// of every workspace in dir.
+func recoverOrphans(ctx context.Context, dir string) error {
+	fns, err := filepath.Glob(filepath.Join(dir, pidFilePattern))
+	if err != nil {
+		return err
+	}
+	for _, fn := range fns {
+		if err := recoverPIDFile(ctx, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverPIDFile waits for the process recorded in the pid file fn to exit,
+// and removes it. A pid that's now used by a different process, like after
+// a reboot, isn't waited for.
+func recoverPIDFile(ctx context.Context, fn string) error {
+	b, err := os.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Unable to read %s, got error: %s", fn, err)
+	}
+	pidStr, start, _ := strings.Cut(strings.TrimSpace(string(b)), " ")
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil || pid <= 0 || start == "" {
+		tflog.Warn(ctx, "Removing invalid pid file", map[string]interface{}{"path": fn})
+		os.Remove(fn)
+		return nil
+	}
+
+	if sameProcess(pid, start) {
+		tflog.Warn(ctx, "terraform started by a previous operation is still running, waiting for it to exit", map[string]interface{}{"pid": pid})
+		t := time.NewTicker(orphanPollInterval)
+		defer t.Stop()
+		for sameProcess(pid, start) {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("Unable to wait for terraform process %d started by a previous operation, got error: %s", pid, ctx.Err())
+			case <-t.C:
+			}
+		}
+	} else if processRunning(pid) {
+		tflog.Debug(ctx, "Ignoring pid file of a process that has since exited, its pid is in use by another", map[string]interface{}{"pid": pid})
+	}
+	os.Remove(fn)
+	return nil
+}
+
+// sameProcess reports whether pid is running and is the process that
+// started at start, as returned by processStartTime.
+func sameProcess(pid int, start string) bool {
+	if !processRunning(pid) {
+		return false
+	}
+	got, err := processStartTime(pid)
+	return err == nil && got == start
+}
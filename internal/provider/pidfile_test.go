@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecoverOrphan(t *testing.T) {
+	orphanPollInterval = 10 * time.Millisecond
+	exists := func(dir, workspace string) bool {
+		_, err := os.Stat(filepath.Join(dir, pidFileName(workspace)))
+		return err == nil
+	}
+
+	t.Run("none", func(t *testing.T) {
+		if err := recoverOrphan(context.Background(), t.TempDir(), "default"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("exited", func(t *testing.T) {
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		if err := writePIDFile(dir, "default", cmd.Process.Pid, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if err := recoverOrphan(context.Background(), dir, "default"); err != nil {
+			t.Fatal(err)
+		}
+		if exists(dir, "default") {
+			t.Error("stale pid file was not removed")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, content := range []string{"nope", fmt.Sprintf("%d", os.Getpid())} {
+			dir := writeFiles(t, map[string]string{pidFileName("default"): content})
+			if err := recoverOrphan(context.Background(), dir, "default"); err != nil {
+				t.Fatal(err)
+			}
+			if exists(dir, "default") {
+				t.Errorf("invalid pid file %q was not removed", content)
+			}
+		}
+	})
+
+	t.Run("running", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := writePIDFile(dir, "default", os.Getpid(), 0); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := recoverOrphan(ctx, dir, "default"); err == nil {
+			t.Fatal("expected error waiting for a running process")
+		}
+		if !exists(dir, "default") {
+			t.Error("pid file of a running process was removed")
+		}
+	})
+
+	t.Run("reused pid", func(t *testing.T) {
+		// This process is running, but isn't the one that was recorded.
+		dir := writeFiles(t, map[string]string{pidFileName("default"): fmt.Sprintf("%d not-its-start-time\n", os.Getpid())})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := recoverOrphan(ctx, dir, "default"); err != nil {
+			t.Fatalf("waited for a process that isn't terraform: %v", err)
+		}
+		if exists(dir, "default") {
+			t.Error("stale pid file was not removed")
+		}
+	})
+
+	t.Run("other workspace", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := writePIDFile(dir, "other", os.Getpid(), 0); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := recoverOrphan(ctx, dir, "default"); err != nil {
+			t.Fatalf("waited for terraform of another workspace: %v", err)
+		}
+		if !exists(dir, "other") {
+			t.Error("pid file of another workspace was removed")
+		}
+		if err := recoverOrphans(ctx, dir); err == nil {
+			t.Error("expected recoverOrphans to wait for every workspace")
+		}
+	})
+}
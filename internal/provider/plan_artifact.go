@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// isPlanArtifactDestination reports whether dest is somewhere plan_artifact
+// can upload to.
+func isPlanArtifactDestination(dest string) bool {
+	return isRemotePlanFile(dest) && !strings.Contains(strings.TrimPrefix(dest, "oci://"), "@")
+}
+
+// uploadCommand returns the command that uploads the file fn to the s3://,
+// gs:// or oci:// destination dest, using the usual CLI for each so that its
+// credentials are used.
+func uploadCommand(fn, dest string) (string, []string) {
+	switch {
+	case strings.HasPrefix(dest, "s3://"):
+		return "aws", []string{"s3", "cp", "--only-show-errors", fn, dest}
+	case strings.HasPrefix(dest, "gs://"):
+		return "gcloud", []string{"storage", "cp", fn, dest}
+	default:
+		return "oras", []string{"blob", "push", strings.TrimPrefix(dest, "oci://"), fn}
+	}
+}
+
+// uploadPlan uploads the saved plan fn, with the hex-encoded SHA-256 digest
+// digest, to dest, and returns the URL it can be downloaded from with
+// plan_file. For oci:// destinations, which are repositories, that is a
+// reference to the uploaded blob by digest.
+func uploadPlan(ctx context.Context, client *http.Client, fn, digest, dest string) (string, error) {
+	if strings.HasPrefix(dest, "https://") || strings.HasPrefix(dest, "http://") {
+		f, err := os.Open(fn)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, dest, f)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return "", fmt.Errorf("PUT %s: %s", dest, resp.Status)
+		}
+		return dest, nil
+	}
+
+	name, args := uploadCommand(fn, dest)
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed, got error: %s, output: %s", name, err, truncateOutput(buf.String(), ""))
+	}
+	if strings.HasPrefix(dest, "oci://") {
+		return dest + "@sha256:" + digest, nil
+	}
+	return dest, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestUploadCommand(t *testing.T) {
+	for dest, want := range map[string][]string{
+		"s3://bucket/plans/prod.tfplan": {"aws", "s3", "cp", "--only-show-errors", "plan", "s3://bucket/plans/prod.tfplan"},
+		"gs://bucket/plans/prod.tfplan": {"gcloud", "storage", "cp", "plan", "gs://bucket/plans/prod.tfplan"},
+		"oci://example.com/plans":       {"oras", "blob", "push", "example.com/plans", "plan"},
+	} {
+		name, args := uploadCommand("plan", dest)
+		if diff := cmp.Diff(want, append([]string{name}, args...)); diff != "" {
+			t.Errorf("uploadCommand(%q) (-want,+got): %s", dest, diff)
+		}
+	}
+
+	for dest, want := range map[string]bool{
+		"https://example.com/plans/prod.tfplan": true,
+		"oci://example.com/plans":               true,
+		"oci://example.com/plans@sha256:abcd":   false,
+		"plans/prod.tfplan":                     false,
+	} {
+		if got := isPlanArtifactDestination(dest); got != want {
+			t.Errorf("isPlanArtifactDestination(%q) = %t, want %t", dest, got, want)
+		}
+	}
+}
+
+func TestDoApplyPlanArtifact(t *testing.T) {
+	var uploaded []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		uploaded = append(uploaded, r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+	m := testApplyModel(dir)
+	m.PlanArtifact = &ApplyPlanArtifactModel{Destination: types.StringValue(srv.URL + "/prod.tfplan")}
+
+	fake := &fakeRunner{}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	if _, _, err := r.doApply(context.Background(), &m); err != nil {
+		t.Fatal(err)
+	}
+	const plan = "PLAN"
+	if diff := cmp.Diff([]string{"init", "plan -out=" + plan, "apply -auto-approve " + plan}, fake.commands); diff != "" {
+		t.Errorf("commands (-want,+got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"/prod.tfplan"}, uploaded); diff != "" {
+		t.Errorf("uploaded (-want,+got): %s", diff)
+	}
+	if got, want := m.PlanArtifactURL.ValueString(), srv.URL+"/prod.tfplan"; got != want {
+		t.Errorf("plan_artifact_url = %q, want %q", got, want)
+	}
+
+	// If the upload fails, nothing is applied.
+	srv.Close()
+	fake.commands = nil
+	if _, _, err := r.doApply(context.Background(), &m); err == nil {
+		t.Fatal("expected error uploading to a closed server")
+	}
+	if diff := cmp.Diff([]string{"init", "plan -out=" + plan}, fake.commands); diff != "" {
+		t.Errorf("commands after failed upload (-want,+got): %s", diff)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// appliedPlanKey is the private state key the digest of the last plan_file
+// applied is stored under, so it isn't applied again.
+const appliedPlanKey = "applied_plan_file"
+
+// appliedPlan returns the digest of the last plan_file applied, as stored in
+// prior by recordAppliedPlan, or "" if there isn't one.
+func appliedPlan(ctx context.Context, prior privateStateReader) (string, diag.Diagnostics) {
+	b, diags := prior.GetKey(ctx, appliedPlanKey)
+	var digest string
+	if diags.HasError() || len(b) == 0 || json.Unmarshal(b, &digest) != nil {
+		return "", diags
+	}
+	return digest, diags
+}
+
+// recordAppliedPlan stores digest, the digest of the plan_file that was
+// applied, or "" if none was.
+func recordAppliedPlan(ctx context.Context, private privateState, digest string) diag.Diagnostics {
+	b, err := json.Marshal(digest)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record applied plan, got error: %s", err))
+		return diags
+	}
+	return private.SetKey(ctx, appliedPlanKey, b)
+}
+
+// newPlanFile creates an empty file, named like pattern as in os.CreateTemp,
+// for a plan of the nested configuration in dir, and returns its absolute
+// path. It's in tempDir if that's set, or otherwise in dir's .terraform
+// directory, and it's unique, so runs sharing dir don't overwrite each
+// other's plans.
+func newPlanFile(dir, tempDir, pattern string) (string, error) {
+	if tempDir == "" {
+		tempDir = filepath.Join(dir, ".terraform")
+		if err := os.MkdirAll(tempDir, 0o755); err != nil {
+			return "", err
+		}
+	}
+	f, err := os.CreateTemp(tempDir, pattern)
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return filepath.Abs(f.Name())
+}
+
+// checkPlanFile returns an error if the plan_file ref is an http:// URL,
+// which anyone on the network could answer with a plan of their own.
+func checkPlanFile(ref string) error {
+	if strings.HasPrefix(ref, "http://") {
+		return fmt.Errorf("plan_file must be a local path or a remote reference like an https:// URL, got %q", ref)
+	}
+	return nil
+}
+
+// isRemotePlanFile reports whether ref is a remote reference to a plan file
+// rather than a path.
+func isRemotePlanFile(ref string) bool {
+	for _, prefix := range []string{"https://", "s3://", "gs://", "oci://"} {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchCommand returns the command that downloads the object at the s3://,
+// gs:// or oci:// reference ref to dest, using the usual CLI for each so that
+// its credentials are used.
+func fetchCommand(ref, dest string) (string, []string) {
+	switch {
+	case strings.HasPrefix(ref, "s3://"):
+		return "aws", []string{"s3", "cp", "--only-show-errors", ref, dest}
+	case strings.HasPrefix(ref, "gs://"):
+		return "gcloud", []string{"storage", "cp", ref, dest}
+	default:
+		return "oras", []string{"blob", "fetch", "--output", dest, strings.TrimPrefix(ref, "oci://")}
+	}
+}
+
+// fetch downloads the remote plan file ref to the file dest.
+func fetch(ctx context.Context, client *http.Client, ref, dest string) error {
+	if strings.HasPrefix(ref, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %s", ref, resp.Status)
+		}
+		f, err := os.Create(dest)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, resp.Body); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	name, args := fetchCommand(ref, dest)
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed, got error: %s, output: %s", name, err, truncateOutput(buf.String(), ""))
+	}
+	return nil
+}
+
+// resolvePlanFile returns the path, relative to dir or absolute, of the
+// saved plan ref, after downloading it if it's a remote reference, and its
+// hex-encoded SHA-256 digest. If want is set, the digest must match it. The
+// returned function removes anything that was downloaded. https:// URLs are
+// downloaded with client, to a new plan file in tempDir as newPlanFile
+// makes, and downloaded plans are given the permissions mode, unless it's 0.
+func resolvePlanFile(ctx context.Context, client *http.Client, dir, tempDir, ref, want string, mode os.FileMode) (string, string, func(), error) {
+	planFile, cleanup := ref, func() {}
+	if isRemotePlanFile(ref) {
+		fn, err := newPlanFile(dir, tempDir, "pteraform-remote-*.tfplan")
+		if err != nil {
+			return "", "", cleanup, fmt.Errorf("Unable to download plan_file %s, got error: %s", ref, err)
+		}
+		planFile = fn
+		cleanup = func() { os.Remove(fn) }
+		if err := fetch(ctx, client, ref, fn); err != nil {
+			cleanup()
+			return "", "", func() {}, fmt.Errorf("Unable to download plan_file %s, got error: %s", ref, err)
+		}
+		if err := chmodFile(fn, mode); err != nil {
+			cleanup()
+			return "", "", func() {}, fmt.Errorf("Unable to set permissions of plan_file, got error: %s", err)
+		}
+	}
+
+	fn := planFile
+	if !filepath.IsAbs(fn) {
+		fn = filepath.Join(dir, fn)
+	}
+	digest, err := fileDigest(fn)
+	if err != nil {
+		cleanup()
+		return "", "", func() {}, fmt.Errorf("Unable to read plan_file, got error: %s", err)
+	}
+	if want != "" && !strings.EqualFold(strings.TrimPrefix(want, "sha256:"), digest) {
+		cleanup()
+		return "", "", func() {}, fmt.Errorf("plan_file %s has SHA-256 digest %s, which does not match plan_file_hash %s", ref, digest, want)
+	}
+	return planFile, digest, cleanup, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFetchCommand(t *testing.T) {
+	for ref, want := range map[string][]string{
+		"s3://bucket/plans/prod.tfplan":       {"aws", "s3", "cp", "--only-show-errors", "s3://bucket/plans/prod.tfplan", "dest"},
+		"gs://bucket/plans/prod.tfplan":       {"gcloud", "storage", "cp", "gs://bucket/plans/prod.tfplan", "dest"},
+		"oci://example.com/plans@sha256:abcd": {"oras", "blob", "fetch", "--output", "dest", "example.com/plans@sha256:abcd"},
+	} {
+		name, args := fetchCommand(ref, "dest")
+		if diff := cmp.Diff(want, append([]string{name}, args...)); diff != "" {
+			t.Errorf("fetchCommand(%q) (-want,+got): %s", ref, diff)
+		}
+	}
+}
+
+func TestCheckPlanFile(t *testing.T) {
+	for ref, ok := range map[string]bool{
+		"reviewed.tfplan":                       true,
+		"https://example.com/plans/prod.tfplan": true,
+		"s3://bucket/plans/prod.tfplan":         true,
+		"http://example.com/plans/prod.tfplan":  false,
+	} {
+		if err := checkPlanFile(ref); (err == nil) != ok {
+			t.Errorf("checkPlanFile(%q) = %v, want ok %t", ref, err, ok)
+		}
+	}
+}
+
+func TestNewPlanFile(t *testing.T) {
+	// Runs sharing a working directory each save their plan to their own
+	// file.
+	dir := t.TempDir()
+	a, err := newPlanFile(dir, "", "pteraform-*.tfplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newPlanFile(dir, "", "pteraform-*.tfplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b || filepath.Dir(a) != filepath.Join(dir, ".terraform") || !filepath.IsAbs(a) {
+		t.Errorf("newPlanFile = %q, then %q, want distinct absolute paths in %s", a, b, filepath.Join(dir, ".terraform"))
+	}
+}
+
+func TestResolvePlanFile(t *testing.T) {
+	const plan = "not really a plan"
+	digest := fmt.Sprintf("%x", sha256.Sum256([]byte(plan)))
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prod.tfplan" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(plan))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	t.Run("local", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"reviewed.tfplan": plan})
+		planFile, got, cleanup, err := resolvePlanFile(ctx, srv.Client(), dir, "", "reviewed.tfplan", "sha256:"+strings.ToUpper(digest), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cleanup()
+		if planFile != "reviewed.tfplan" || got != digest {
+			t.Errorf("resolvePlanFile = %q, %q, want %q, %q", planFile, got, "reviewed.tfplan", digest)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "reviewed.tfplan")); err != nil {
+			t.Errorf("local plan file was removed: %v", err)
+		}
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		dir, tmp := t.TempDir(), t.TempDir()
+		for tempDir, wantDir := range map[string]string{"": filepath.Join(dir, ".terraform"), tmp: tmp} {
+			planFile, got, cleanup, err := resolvePlanFile(ctx, srv.Client(), dir, tempDir, srv.URL+"/prod.tfplan", digest, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Dir(planFile) != wantDir || got != digest {
+				t.Errorf("resolvePlanFile with temp_dir %q = %q, %q, want a file in %q, %q", tempDir, planFile, got, wantDir, digest)
+			}
+			cleanup()
+			if _, err := os.Stat(planFile); !os.IsNotExist(err) {
+				t.Errorf("downloaded plan file was not removed: %v", err)
+			}
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, _, _, err := resolvePlanFile(ctx, srv.Client(), dir, "", srv.URL+"/prod.tfplan", strings.Repeat("0", 64), 0); err == nil || !strings.Contains(err.Error(), "does not match plan_file_hash") {
+			t.Errorf("expected digest mismatch, got %v", err)
+		}
+		if left, _ := filepath.Glob(filepath.Join(dir, ".terraform", "*.tfplan")); len(left) > 0 {
+			t.Errorf("mismatched plan file was not removed: %v", left)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, _, _, err := resolvePlanFile(ctx, srv.Client(), t.TempDir(), "", srv.URL+"/missing.tfplan", digest, 0); err == nil {
+			t.Error("expected error downloading missing plan file")
+		}
+	})
+}
+
+func TestAppliedPlan(t *testing.T) {
+	ctx := context.Background()
+	private := fakePrivateState{}
+	if got, diags := appliedPlan(ctx, private); diags.HasError() || got != "" {
+		t.Errorf("appliedPlan() before any apply = %q, %v, want none", got, diags)
+	}
+	if diags := recordAppliedPlan(ctx, private, "abc123"); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got, diags := appliedPlan(ctx, private); diags.HasError() || got != "abc123" {
+		t.Errorf("appliedPlan() = %q, %v, want abc123", got, diags)
+	}
+}
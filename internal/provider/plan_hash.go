@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// nestedPlanJSON returns the JSON representation of the nested plan: the
+// saved planFile if it's set, otherwise a new plan of workspace made with
+// args, saved to a new plan file in tempDir as newPlanFile makes. backend
+// are the arguments that configure the backend when initializing dir.
+func nestedPlanJSON(ctx context.Context, tf runner, dir, tempDir, workspace string, backend, args []string, planFile string) ([]byte, error) {
+	if _, err := retryRateLimited(ctx, registryBackoff, func() (string, error) {
+		return tf.run(ctx, dir, append([]string{"init", "-input=false"}, backend...)...)
+	}); err != nil {
+		return nil, err
+	}
+	if err := ensureWorkspace(ctx, tf, dir, workspace); err != nil {
+		return nil, err
+	}
+	if planFile == "" {
+		fn, err := newPlanFile(dir, tempDir, "pteraform-*.tfplan")
+		if err != nil {
+			return nil, fmt.Errorf("Unable to create saved plan, got error: %s", err)
+		}
+		defer os.Remove(fn)
+		planFile = fn
+		if _, err := tf.run(ctx, dir, append([]string{"plan", "-input=false", "-out=" + planFile}, args...)...); err != nil {
+			return nil, err
+		}
+	}
+	out, err := tf.run(ctx, dir, "show", "-json", planFile)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// planChangesDigest returns the hex-encoded SHA-256 digest of the resource
+// and output changes in a JSON plan, and whether there are any. No-op changes
+// are left out, so the digest only depends on what would actually change.
+func planChangesDigest(planJSON []byte) (string, bool, error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Change struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+		OutputChanges map[string]struct {
+			Actions []string `json:"actions"`
+		} `json:"output_changes"`
+	}
+	var raw struct {
+		ResourceChanges []interface{}          `json:"resource_changes"`
+		OutputChanges   map[string]interface{} `json:"output_changes"`
+	}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return "", false, fmt.Errorf("Unable to parse plan, got error: %s", err)
+	}
+	if err := json.Unmarshal(planJSON, &raw); err != nil {
+		return "", false, fmt.Errorf("Unable to parse plan, got error: %s", err)
+	}
+
+	changes := struct {
+		ResourceChanges []interface{}          `json:"resource_changes"`
+		OutputChanges   map[string]interface{} `json:"output_changes"`
+	}{[]interface{}{}, map[string]interface{}{}}
+	for i, rc := range plan.ResourceChanges {
+		if !isNoOp(rc.Change.Actions) {
+			changes.ResourceChanges = append(changes.ResourceChanges, raw.ResourceChanges[i])
+		}
+	}
+	for name, oc := range plan.OutputChanges {
+		if !isNoOp(oc.Actions) {
+			changes.OutputChanges[name] = raw.OutputChanges[name]
+		}
+	}
+
+	// Maps are marshaled with sorted keys, so this is canonical.
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return "", false, err
+	}
+	changed := len(changes.ResourceChanges) > 0 || len(changes.OutputChanges) > 0
+	return fmt.Sprintf("%x", sha256.Sum256(b)), changed, nil
+}
+
+func isNoOp(actions []string) bool {
+	return len(actions) == 1 && actions[0] == "no-op"
+}
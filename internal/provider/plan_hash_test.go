@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlanChangesDigest(t *testing.T) {
+	noop := `{
+  "format_version": "1.2",
+  "timestamp": "2023-10-01T00:00:00Z",
+  "resource_changes": [{"address": "null_resource.a", "change": {"actions": ["no-op"], "before": {}, "after": {}}}],
+  "output_changes": {"a": {"actions": ["no-op"], "before": "x", "after": "x"}}
+}`
+	empty := `{"format_version": "1.2", "timestamp": "2023-10-02T00:00:00Z"}`
+	create := `{
+  "resource_changes": [
+    {"address": "null_resource.a", "change": {"actions": ["no-op"]}},
+    {"address": "null_resource.b", "change": {"actions": ["create"], "after": {"triggers": null}}}
+  ],
+  "output_changes": {"b": {"actions": ["create"], "after": "y"}, "a": {"actions": ["no-op"]}}
+}`
+	reordered := `{
+  "output_changes": {"a": {"actions": ["no-op"]}, "b": {"after": "y", "actions": ["create"]}},
+  "resource_changes": [
+    {"address": "null_resource.b", "change": {"after": {"triggers": null}, "actions": ["create"]}}
+  ]
+}`
+
+	digest := func(s string) (string, bool) {
+		t.Helper()
+		d, changed, err := planChangesDigest([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d, changed
+	}
+	noopDigest, changed := digest(noop)
+	if changed {
+		t.Error("no-op plan has changes")
+	}
+	if emptyDigest, _ := digest(empty); emptyDigest != noopDigest {
+		t.Errorf("empty plan digest %s != no-op plan digest %s", emptyDigest, noopDigest)
+	}
+	createDigest, changed := digest(create)
+	if !changed {
+		t.Error("create plan has no changes")
+	}
+	if createDigest == noopDigest {
+		t.Error("create plan digest is the same as no-op plan digest")
+	}
+	if reorderedDigest, _ := digest(reordered); reorderedDigest != createDigest {
+		t.Errorf("reordered plan digest %s != create plan digest %s", reorderedDigest, createDigest)
+	}
+
+	if _, _, err := planChangesDigest([]byte("Error: nope")); err == nil {
+		t.Error("expected error parsing invalid plan")
+	}
+}
+
+func TestNestedPlanJSONCommands(t *testing.T) {
+	for _, c := range []struct {
+		desc     string
+		planFile string
+		want     []string
+	}{{
+		desc: "plan",
+		want: []string{"init -input=false", "plan -input=false -out=PLAN -var=value=cool", "show -json PLAN"},
+	}, {
+		desc:     "plan file",
+		planFile: "reviewed.tfplan",
+		want:     []string{"init -input=false", "show -json reviewed.tfplan"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+			tempDir := t.TempDir()
+			fake := &fakeRunner{}
+			if _, err := nestedPlanJSON(context.Background(), fake, dir, tempDir, "default", nil, []string{"-var=value=cool"}, c.planFile); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, fake.commands); diff != "" {
+				t.Errorf("commands (-want,+got): %s", diff)
+			}
+			// The plan is saved to a new file in tempDir, removed afterwards.
+			if left, _ := filepath.Glob(filepath.Join(tempDir, "*")); len(left) > 0 {
+				t.Errorf("plans were not removed: %v", left)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// planSummary counts the resource changes in a plan, like the summary at
+// the end of terraform plan.
+type planSummary struct {
+	Add     int64
+	Change  int64
+	Destroy int64
+}
+
+// summarizePlan returns the summary of the resource changes in a JSON plan.
+// Replacements count as both an add and a destroy, as they do in terraform's
+// own summary.
+func summarizePlan(planJSON []byte) (planSummary, error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Change struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return planSummary{}, fmt.Errorf("Unable to parse plan, got error: %s", err)
+	}
+
+	var s planSummary
+	for _, rc := range plan.ResourceChanges {
+		if slices.Contains(rc.Change.Actions, "create") {
+			s.Add++
+		}
+		if slices.Contains(rc.Change.Actions, "update") {
+			s.Change++
+		}
+		if slices.Contains(rc.Change.Actions, "delete") {
+			s.Destroy++
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestSummarizePlan(t *testing.T) {
+	got, err := summarizePlan([]byte(`{"resource_changes": [
+    {"address": "null_resource.a", "change": {"actions": ["no-op"]}},
+    {"address": "null_resource.b", "change": {"actions": ["create"]}},
+    {"address": "null_resource.c", "change": {"actions": ["update"]}},
+    {"address": "null_resource.d", "change": {"actions": ["delete", "create"]}},
+    {"address": "null_resource.e", "change": {"actions": ["create", "delete"]}},
+    {"address": "null_resource.f", "change": {"actions": ["delete"]}},
+    {"address": "data.null_data_source.g", "change": {"actions": ["read"]}}
+  ]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (planSummary{Add: 3, Change: 1, Destroy: 3}); got != want {
+		t.Errorf("summarizePlan = %+v, want %+v", got, want)
+	}
+
+	if _, err := summarizePlan([]byte("not json")); err == nil {
+		t.Error("summarizePlan(invalid): expected error")
+	}
+}
+
+func TestPendingChanges(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+	m := testApplyModel(dir)
+	m.ReadRunsPlan = types.BoolValue(true)
+
+	fake := &fakeRunner{outputs: map[string]string{
+		"show -json PLAN": `{"resource_changes": [{"change": {"actions": ["update"]}}]}`,
+	}}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	if diags := r.pendingChanges(context.Background(), &m); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if m.PendingAdd.ValueInt64() != 0 || m.PendingChange.ValueInt64() != 1 || m.PendingDestroy.ValueInt64() != 0 {
+		t.Errorf("pending = %s, %s, %s, want 0, 1, 0", m.PendingAdd, m.PendingChange, m.PendingDestroy)
+	}
+}
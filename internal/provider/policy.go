@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pluginPolicy restricts which providers and provisioners a nested
+// configuration may use. Wrapped third-party modules can run arbitrary code
+// through either, so this is checked before terraform apply runs.
+type pluginPolicy struct {
+	// AllowedProviders, if non-empty, lists the provider source addresses
+	// that may be used.
+	AllowedProviders []string
+	// DeniedProvisioners lists provisioner types that may not be used.
+	DeniedProvisioners []string
+}
+
+func (p pluginPolicy) empty() bool {
+	return len(p.AllowedProviders) == 0 && len(p.DeniedProvisioners) == 0
+}
+
+// check inspects every module in the configuration rooted at root and
+// returns an error describing each violation of the policy.
+func (p pluginPolicy) check(root string) error {
+	if p.empty() {
+		return nil
+	}
+
+	allowed := map[string]bool{}
+	for _, a := range p.AllowedProviders {
+		src, err := normalizeProviderSource(a)
+		if err != nil {
+			return fmt.Errorf("invalid allowed_providers entry: %s", err)
+		}
+		allowed[src] = true
+	}
+	denied := map[string]bool{}
+	for _, d := range p.DeniedProvisioners {
+		denied[d] = true
+	}
+
+	dirs, err := moduleDirs(root)
+	if err != nil {
+		return err
+	}
+	var violations []string
+	for _, dir := range dirs {
+		mod, err := loadModule(dir)
+		if err != nil {
+			return err
+		}
+		if len(allowed) > 0 {
+			used := map[string]bool{}
+			for _, req := range mod.RequiredProviders {
+				if req.Source != "" {
+					src, err := normalizeProviderSource(req.Source)
+					if err != nil {
+						return err
+					}
+					used[src] = true
+				}
+			}
+			for _, r := range mod.Resources {
+				src, err := mod.ProviderSource(r.Provider)
+				if err != nil {
+					return err
+				}
+				used[src] = true
+			}
+			for src := range used {
+				if !allowed[src] && !strings.HasPrefix(src, "terraform.io/builtin/") {
+					violations = append(violations, fmt.Sprintf("module %s uses provider %q, which is not in allowed_providers", relDir(root, dir), src))
+				}
+			}
+		}
+		for _, r := range mod.Resources {
+			for _, prov := range r.Provisioners {
+				if denied[prov] {
+					violations = append(violations, fmt.Sprintf("%s (%s:%d) uses the %q provisioner, which is in denied_provisioners", r.Address(), relDir(root, r.Filename), r.Pos.Line, prov))
+				}
+			}
+		}
+	}
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("nested configuration violates plugin policy:\n  %s", strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+// relDir returns path relative to root for display, or path itself if that
+// isn't possible.
+func relDir(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
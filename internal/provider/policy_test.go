@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPluginPolicy(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `
+terraform {
+  required_providers {
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.0"
+    }
+  }
+}
+
+resource "random_pet" "pet" {}
+
+module "child" {
+  source = "./child"
+}
+`,
+		"child/main.tf": `
+resource "null_resource" "exec" {
+  provisioner "local-exec" {
+    command = "echo hi"
+  }
+}
+`,
+		"child/data.tf.json": `{"data": {"http": {"example": {"url": "https://example.com"}}}}`,
+	})
+
+	for _, c := range []struct {
+		desc    string
+		policy  pluginPolicy
+		wantErr []string
+	}{{
+		desc: "empty policy",
+	}, {
+		desc: "all allowed",
+		policy: pluginPolicy{
+			AllowedProviders: []string{"hashicorp/random", "registry.terraform.io/hashicorp/null", "hashicorp/http"},
+		},
+	}, {
+		desc: "provider not allowed",
+		policy: pluginPolicy{
+			AllowedProviders: []string{"hashicorp/random", "hashicorp/http"},
+		},
+		wantErr: []string{`module child uses provider "registry.terraform.io/hashicorp/null"`},
+	}, {
+		desc: "provisioner denied",
+		policy: pluginPolicy{
+			DeniedProvisioners: []string{"local-exec"},
+		},
+		wantErr: []string{`null_resource.exec (child/main.tf:2) uses the "local-exec" provisioner`},
+	}, {
+		desc: "other provisioner denied",
+		policy: pluginPolicy{
+			DeniedProvisioners: []string{"remote-exec"},
+		},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			err := c.policy.check(dir)
+			if len(c.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			for _, want := range c.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultMaxPrefetches is how many prefetches run at once if the provider's
+// max_prefetches isn't set.
+const defaultMaxPrefetches = 2
+
+// prefetcher runs terraform init in the background for resources with
+// prefetch_providers while the outer configuration is planned, so the
+// nested providers are installed by the time it's applied.
+type prefetcher struct {
+	// sem holds a token for each prefetch running.
+	sem chan struct{}
+
+	mu sync.Mutex
+	// dirs are the working directories being prefetched, or waiting to be,
+	// and channels closed once they're done.
+	dirs map[string]chan struct{}
+}
+
+func newPrefetcher(max int) *prefetcher {
+	return &prefetcher{sem: make(chan struct{}, max), dirs: map[string]chan struct{}{}}
+}
+
+// prefetcher returns the provider's prefetcher, or nil if it isn't
+// configured.
+func (pd *providerData) prefetcher() *prefetcher {
+	if pd == nil {
+		return nil
+	}
+	return pd.Prefetcher
+}
+
+// start runs terraform init -backend=false in dir with tf in the
+// background, unless dir is already being prefetched, and reports whether
+// it did. The backend isn't initialized, so that planning doesn't need the
+// nested backend's credentials or lock it.
+//
+// Terraform may stop the provider once planning finishes, and the process
+// keeps running: its pid file makes the nested apply wait for it to exit.
+func (p *prefetcher) start(ctx context.Context, tf runner, dir string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.dirs[dir]; ok {
+		return false
+	}
+	done := make(chan struct{})
+	p.dirs[dir] = done
+
+	// The plan's context is done once it's planned.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			delete(p.dirs, dir)
+			close(done)
+		}()
+		p.sem <- struct{}{}
+		defer func() { <-p.sem }()
+		if _, err := tf.run(ctx, dir, "init", "-backend=false", "-input=false"); err != nil {
+			tflog.Warn(ctx, "Unable to prefetch nested providers, they'll be installed when applying", map[string]interface{}{"dir": dir, "error": err.Error()})
+		}
+	}()
+	return true
+}
+
+// wait waits for any prefetch of dir started by this provider to finish.
+// Prefetches started by one that exited are waited for by recoverOrphan.
+func (p *prefetcher) wait(ctx context.Context, dir string) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	done, ok := p.dirs[dir]
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("Unable to wait for nested providers to be prefetched, got error: %s", ctx.Err())
+	}
+}
+
+// prefetch starts prefetching m's providers if prefetch_providers is set.
+func (r *ApplyResource) prefetch(ctx context.Context, m *ApplyResourceModel) {
+	if !m.PrefetchProviders.ValueBool() {
+		return
+	}
+	// Any error is reported when applying.
+	limits, err := m.ResourceLimits.limits()
+	if err != nil {
+		return
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		return
+	}
+	tf, err := r.runner(limits, m, runID)
+	if err != nil {
+		return
+	}
+	if r.provider.prefetcher().start(ctx, tf, m.WorkingDir.ValueString()) {
+		tflog.Info(ctx, "Prefetching nested providers", map[string]interface{}{"dir": m.WorkingDir.ValueString()})
+	}
+}
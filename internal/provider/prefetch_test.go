@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRunner records the commands it runs, and blocks each of them
+// until release is closed.
+type blockingRunner struct {
+	release chan struct{}
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func (b *blockingRunner) run(ctx context.Context, dir string, args ...string) (string, error) {
+	b.mu.Lock()
+	b.commands = append(b.commands, dir+": "+strings.Join(args, " "))
+	b.mu.Unlock()
+	<-b.release
+	return "", nil
+}
+
+func (b *blockingRunner) ran() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.commands...)
+}
+
+func TestPrefetcher(t *testing.T) {
+	ctx := context.Background()
+	var p *prefetcher
+	if p.start(ctx, nil, "a") {
+		t.Error("nil prefetcher started")
+	}
+	if err := p.wait(ctx, "a"); err != nil {
+		t.Errorf("nil prefetcher wait: %v", err)
+	}
+
+	p = newPrefetcher(1)
+	tf := &blockingRunner{release: make(chan struct{})}
+	if !p.start(ctx, tf, "a") {
+		t.Fatal("start a: not started")
+	}
+	if p.start(ctx, tf, "a") {
+		t.Error("start a again: started")
+	}
+	if !p.start(ctx, tf, "b") {
+		t.Fatal("start b: not started")
+	}
+
+	// Only one runs at once.
+	time.Sleep(50 * time.Millisecond)
+	if got := tf.ran(); len(got) != 1 || !strings.HasSuffix(got[0], ": init -backend=false -input=false") {
+		t.Errorf("running = %v, want a single init", got)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := p.wait(canceled, "b"); err == nil {
+		t.Error("wait with canceled context: got no error")
+	}
+
+	close(tf.release)
+	for _, dir := range []string{"a", "b"} {
+		if err := p.wait(ctx, dir); err != nil {
+			t.Errorf("wait %s: %v", dir, err)
+		}
+	}
+	if got := tf.ran(); len(got) != 2 {
+		t.Errorf("ran %v, want both", got)
+	}
+	if !p.start(ctx, tf, "a") {
+		t.Error("start a after it finished: not started")
+	}
+	if err := p.wait(ctx, "a"); err != nil {
+		t.Errorf("wait a: %v", err)
+	}
+}
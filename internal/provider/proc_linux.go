@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processStartTime returns when the process pid started, in clock ticks
+// since boot, from /proc/<pid>/stat. Together with the pid it identifies
+// the process, since pids are reused.
+func processStartTime(pid int) (string, error) {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return "", err
+	}
+	// The command name, in parentheses, may contain spaces, so fields are
+	// counted from after it. starttime is field 22; the state after the
+	// name is field 3.
+	s := string(b)
+	fields := strings.Fields(s[strings.LastIndexByte(s, ')')+1:])
+	if len(fields) < 20 {
+		return "", fmt.Errorf("unexpected /proc/%d/stat: %q", pid, s)
+	}
+	return fields[19], nil
+}
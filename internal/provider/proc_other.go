@@ -0,0 +1,28 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux && !windows
+
+package provider
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// processStartTime returns when the process pid started, as reported by
+// ps. Together with the pid it identifies the process, since pids are
+// reused.
+func processStartTime(pid int) (string, error) {
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	start := strings.Join(strings.Fields(string(out)), " ")
+	if start == "" {
+		return "", fmt.Errorf("no process %d", pid)
+	}
+	return start, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package provider
+
+import (
+	"os/exec"
+	"strconv"
+
+	"golang.org/x/sys/windows"
+)
+
+// interrupt stops the running cmd and the providers it started. Windows
+// can't deliver Ctrl-C to a process without a shared console, so this uses
+// taskkill to end the whole process tree.
+func interrupt(cmd *exec.Cmd) error {
+	if err := exec.Command("taskkill", taskkillArgs(cmd.Process.Pid)...).Run(); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+// stillActive is the exit code of a process that hasn't exited.
+const stillActive = 259
+
+// processRunning reports whether the process pid exists and hasn't exited.
+func processRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// processStartTime returns when the process pid was created, in 100ns
+// intervals since 1601. Together with the pid it identifies the process,
+// since pids are reused.
+func processStartTime(pid int) (string, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(int64(creation.HighDateTime)<<32|int64(creation.LowDateTime), 10), nil
+}
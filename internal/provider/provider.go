@@ -5,11 +5,15 @@ package provider
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure TerraformProvider satisfies various provider interfaces.
@@ -25,6 +29,56 @@ type TerraformProvider struct {
 
 // TerraformProviderModel describes the provider data model.
 type TerraformProviderModel struct {
+	DefaultTags         types.Map    `tfsdk:"default_tags"`
+	DefaultTagsVariable types.String `tfsdk:"default_tags_variable"`
+	PluginCacheDir      types.String `tfsdk:"plugin_cache_dir"`
+	CacheMaxSize        types.String `tfsdk:"cache_max_size"`
+	MaxNestingDepth     types.Int64  `tfsdk:"max_nesting_depth"`
+	PluginDirs          types.List   `tfsdk:"plugin_dirs"`
+	Offline             types.Bool   `tfsdk:"offline"`
+	FilePermissions     types.String `tfsdk:"file_permissions"`
+	TempDir             types.String `tfsdk:"temp_dir"`
+	UserAgentSuffix     types.String `tfsdk:"user_agent_suffix"`
+	RegistryDir         types.String `tfsdk:"registry_dir"`
+	CacheStateReads     types.Bool   `tfsdk:"cache_state_reads"`
+	TerraformVersions   types.String `tfsdk:"terraform_versions_dir"`
+	MaxPrefetches       types.Int64  `tfsdk:"max_prefetches"`
+
+	InheritEnvironment *InheritEnvironmentModel `tfsdk:"inherit_environment"`
+}
+
+// providerData is the provider configuration passed to resources and data
+// sources.
+type providerData struct {
+	DefaultTags         map[string]string
+	DefaultTagsVariable string
+	PluginCacheDir      string
+	PluginDirs          []string
+	Offline             bool
+	// FileMode is the permissions of files the provider writes, or 0 if
+	// they aren't configured.
+	FileMode os.FileMode
+	// TempDir, if set, is the absolute path of the directory scratch files
+	// are written in.
+	TempDir         string
+	UserAgentSuffix string
+	// RegistryDir, if set, is the directory of the registry applies publish
+	// outputs to.
+	RegistryDir string
+	// InheritEnvironment is which of the provider's environment variables
+	// nested terraform inherits.
+	InheritEnvironment inheritEnvironment
+	// CacheMaxSize is the most the plugin cache may hold in bytes, or zero
+	// if it's unbounded.
+	CacheMaxSize int64
+	Nesting      nesting
+	// StateCache, if set, caches the nested state files resources read.
+	StateCache *stateCache
+	// TerraformVersionsDir, if set, is the directory the terraform_version
+	// of resources are installed in.
+	TerraformVersionsDir string
+	// Prefetcher runs the prefetches of resources with prefetch_providers.
+	Prefetcher *prefetcher
 }
 
 func (p *TerraformProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -33,22 +87,208 @@ func (p *TerraformProvider) Metadata(ctx context.Context, req provider.MetadataR
 }
 
 func (p *TerraformProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
-	resp.Schema = schema.Schema{Attributes: map[string]schema.Attribute{}}
+	resp.Schema = schema.Schema{Attributes: map[string]schema.Attribute{
+		"default_tags": schema.MapAttribute{
+			MarkdownDescription: "Tags merged into the `default_tags_variable` variable of every nested configuration that declares it, which must be a map of strings. Tags set for the variable in the nested run, or by its default, take precedence.",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
+		"default_tags_variable": schema.StringAttribute{
+			MarkdownDescription: "Nested variable `default_tags` are merged into. Defaults to `tags`.",
+			Optional:            true,
+		},
+		"plugin_cache_dir": schema.StringAttribute{
+			MarkdownDescription: "Directory nested runs share as their [plugin cache](https://developer.hashicorp.com/terraform/cli/config/config-file#provider-plugin-cache), so each provider version is only downloaded once. It's created if it doesn't exist.",
+			Optional:            true,
+		},
+		"cache_max_size": schema.StringAttribute{
+			MarkdownDescription: "Most the plugin cache may hold, like `5GiB`. After each nested apply, the provider versions least recently used by nested applies are removed until it's no larger. Requires `plugin_cache_dir`.",
+			Optional:            true,
+		},
+		"plugin_dirs": schema.ListAttribute{
+			MarkdownDescription: "Directories nested runs install providers from when `offline`, laid out like a [filesystem mirror](https://developer.hashicorp.com/terraform/cli/config/config-file#filesystem_mirror), as `terraform providers mirror` writes.",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
+		"offline": schema.BoolAttribute{
+			MarkdownDescription: "Whether nested runs only install providers from `plugin_dirs` and `plugin_cache_dir`, never from a registry, unless a resource's `offline` says otherwise.",
+			Optional:            true,
+		},
+		"file_permissions": schema.StringAttribute{
+			MarkdownDescription: "Octal permissions, like `0600`, of every file the provider writes: saved plans, downloaded `plan_file`s, attestations, published outputs, the configuration `pteraform_backend_state` initializes, and the files it keeps in each working directory, like its apply journal, pid file and the full output of failed commands, the `generated_provider_config` and `override_files` override files, and files rendered by `templating`. By default, published outputs, override files, rendered templates and the output of failed commands, which often hold credentials, are `0600`, saved and downloaded plans get terraform's own defaults, and the rest are `0644`.",
+			Optional:            true,
+		},
+		"temp_dir": schema.StringAttribute{
+			MarkdownDescription: "Directory for scratch files, such as saved plans, including those made for `compute_plan_hash`, `read_runs_plan` and `preview_destroy`, downloaded `plan_file`s, the full output of failed commands and the temporary files of nested runs, which include the modules they download, instead of the system temporary directory. Use it to keep them on, say, a RAM disk or an encrypted volume. It's created if it doesn't exist.",
+			Optional:            true,
+		},
+		"user_agent_suffix": schema.StringAttribute{
+			MarkdownDescription: "Appended to the user agent of the API requests nested providers make, with `TF_APPEND_USER_AGENT`, so they can be attributed to the nested runs in cloud-side request logs, like `outer/${terraform.workspace}`. The ID of each nested run, including those of data sources, is also appended, as `pteraform-run/ID`; for `pteraform_apply` it's its `run_id`.",
+			Optional:            true,
+		},
+		"registry_dir": schema.StringAttribute{
+			MarkdownDescription: "Directory of the registry `publish_outputs` publishes to and `pteraform_registry` reads from. Defaults to a directory for the outer workspace in the outer configuration's `.terraform` directory, so it's shared by everything in it but isn't shared between workspaces.",
+			Optional:            true,
+		},
+		"cache_state_reads": schema.BoolAttribute{
+			MarkdownDescription: "Whether to cache the digests and contents of the nested state files `pteraform_apply` resources read when they're refreshed, for as long as the provider runs, so that configurations with many large nested states are refreshed faster. A cached state file is read again whenever its modification time or size changes.",
+			Optional:            true,
+		},
+		"terraform_versions_dir": schema.StringAttribute{
+			MarkdownDescription: "Directory the `terraform_version` of `pteraform_apply` resources are installed in, shared by all resources and by other runs of the provider. Defaults to `pteraform/terraform` in the user's cache directory, like `~/.cache` on Linux.",
+			Optional:            true,
+		},
+		"max_prefetches": schema.Int64Attribute{
+			MarkdownDescription: "How many `prefetch_providers` runs of `terraform init` may run at once. Defaults to 2.",
+			Optional:            true,
+		},
+		"max_nesting_depth": schema.Int64Attribute{
+			MarkdownDescription: "How deeply `pteraform_apply` runs may be nested in each other, for configurations that intentionally use `allow_recursion`. An apply that would exceed it fails before running `terraform`. The limit is passed on to nested providers, so it holds even if they set a higher one.",
+			Optional:            true,
+		},
+	}, Blocks: map[string]schema.Block{
+		"inherit_environment": schema.SingleNestedBlock{
+			MarkdownDescription: "Which of the provider's environment variables nested runs inherit. By default they inherit all of them except `TF_CLI_ARGS`, `TF_CLI_ARGS_name`, `TF_WORKSPACE` and `TF_DATA_DIR`, which configure the outer run and would otherwise also change what nested runs do.",
+			Attributes: map[string]schema.Attribute{
+				"allow": schema.ListAttribute{
+					MarkdownDescription: "Patterns matching the names of the only variables nested runs inherit, like `HOME`, `PATH` and `AWS_*`, where `*` matches any characters. Variables that configure the outer run are inherited if a pattern matches them.",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+				"deny": schema.ListAttribute{
+					MarkdownDescription: "Patterns matching the names of variables nested runs don't inherit, even if `allow` matches them, like `TF_*` or `*_TOKEN`. Use it to keep the outer run's credentials from third-party modules.",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+			},
+		},
+	}}
 }
 
 func (p *TerraformProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var data TerraformProviderModel
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pd := &providerData{DefaultTagsVariable: defaultTagsVariable}
+	resp.Diagnostics.Append(data.DefaultTags.ElementsAs(ctx, &pd.DefaultTags, false)...)
+	if v := data.DefaultTagsVariable.ValueString(); v != "" {
+		pd.DefaultTagsVariable = v
+	}
+	if dir := data.PluginCacheDir.ValueString(); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("plugin_cache_dir"), "Unable to Create Plugin Cache", err.Error())
+			return
+		}
+		pd.PluginCacheDir = dir
+	}
+	if !data.CacheMaxSize.IsNull() {
+		if pd.PluginCacheDir == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("cache_max_size"), "Missing Plugin Cache Directory", "cache_max_size can only be set with plugin_cache_dir.")
+			return
+		}
+		n, err := parseSize(data.CacheMaxSize.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cache_max_size"), "Invalid Cache Size", err.Error())
+			return
+		}
+		pd.CacheMaxSize = n
+	}
+	resp.Diagnostics.Append(data.PluginDirs.ElementsAs(ctx, &pd.PluginDirs, false)...)
+	pd.Offline = data.Offline.ValueBool()
+	if pd.Offline && len(pd.PluginDirs) == 0 && pd.PluginCacheDir == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("offline"), "Missing Plugin Directories", "offline requires plugin_dirs or plugin_cache_dir to install providers from.")
+		return
+	}
+	if p := data.FilePermissions.ValueString(); p != "" {
+		mode, err := parseFileMode(p)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("file_permissions"), "Invalid File Permissions", err.Error())
+			return
+		}
+		pd.FileMode = mode
+	}
+	if dir := data.TempDir.ValueString(); dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err == nil {
+			err = os.MkdirAll(abs, 0o700)
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("temp_dir"), "Unable to Create Temporary Directory", err.Error())
+			return
+		}
+		pd.TempDir = abs
+	}
+	pd.UserAgentSuffix = data.UserAgentSuffix.ValueString()
+	pd.RegistryDir = data.RegistryDir.ValueString()
+	pd.TerraformVersionsDir = data.TerraformVersions.ValueString()
+	maxPrefetches := int64(defaultMaxPrefetches)
+	if v := data.MaxPrefetches; !v.IsNull() {
+		if v.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_prefetches"), "Invalid Prefetch Limit", "max_prefetches must be at least 1.")
+			return
+		}
+		maxPrefetches = v.ValueInt64()
+	}
+	pd.Prefetcher = newPrefetcher(int(maxPrefetches))
+	if data.CacheStateReads.ValueBool() {
+		pd.StateCache = newStateCache()
+	}
+	if e := data.InheritEnvironment; e != nil {
+		var allow, deny []string
+		if !e.Allow.IsNull() {
+			allow = []string{}
+			resp.Diagnostics.Append(e.Allow.ElementsAs(ctx, &allow, false)...)
+		}
+		resp.Diagnostics.Append(e.Deny.ElementsAs(ctx, &deny, false)...)
+		inherit, err := newInheritEnvironment(allow, deny)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("inherit_environment"), "Invalid Environment Pattern", err.Error())
+			return
+		}
+		pd.InheritEnvironment = inherit
+	}
+	n, err := readNesting(os.Getenv)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Nesting Depth", err.Error())
+		return
+	}
+	if v := data.MaxNestingDepth; !v.IsNull() {
+		if v.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_nesting_depth"), "Invalid Nesting Depth", "max_nesting_depth must be at least 1.")
+			return
+		}
+		n = n.limit(v.ValueInt64())
+	}
+	pd.Nesting = n
+	resp.ResourceData = pd
+	resp.DataSourceData = pd
 }
 
 func (p *TerraformProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewApplyResource,
+		NewOrchestratorRunResource,
+		NewRemoteRunResource,
 	}
 }
 
 func (p *TerraformProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewBackendStateDataSource,
+		NewCacheUsageDataSource,
+		NewConfigInspectDataSource,
+		NewConsoleEvalDataSource,
+		NewEnvCheckDataSource,
+		NewProviderSchemasDataSource,
+		NewRegistryDataSource,
+		NewRevisionDataSource,
+		NewStateDiffDataSource,
+		NewTerraformCLIDataSource,
+		NewTFJSONDataSource,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// providersOverrideFile is the override file generated_provider_config is
+// written to in the working directory.
+const providersOverrideFile = "pteraform_providers_override.tf.json"
+
+// providersOverride returns the contents of the override file that merges
+// config, the JSON object of generated_provider_config, into the nested
+// configuration's provider blocks, or nil if config is empty.
+func providersOverride(config string) ([]byte, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var providers map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(config), &providers); err != nil || providers == nil {
+		return nil, fmt.Errorf("generated_provider_config must be a JSON object of provider settings by provider name, like jsonencode({ aws = { region = \"us-east-1\" } })")
+	}
+	for name, settings := range providers {
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(settings, &obj) != nil || obj == nil {
+			return nil, fmt.Errorf("the settings of provider %q in generated_provider_config must be an object", name)
+		}
+	}
+	return json.MarshalIndent(map[string]interface{}{"provider": providers}, "", "  ")
+}
+
+// writeProvidersOverride writes the override file for config in dir, with
+// permissions mode, or secretFileMode if it's 0, since provider settings
+// often include credentials. It removes the one written for an earlier
+// config if config is empty.
+func writeProvidersOverride(dir, config string, mode os.FileMode) error {
+	fn := filepath.Join(dir, providersOverrideFile)
+	b, err := providersOverride(config)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Unable to remove %s, got error: %s", providersOverrideFile, err)
+		}
+		return nil
+	}
+	if err := writeFile(fn, append(b, '\n'), modeOr(mode, secretFileMode)); err != nil {
+		return fmt.Errorf("Unable to write %s, got error: %s", providersOverrideFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteProvidersOverride(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, providersOverrideFile)
+
+	if err := writeProvidersOverride(dir, `{"aws":{"region":"us-east-1","assume_role":{"role_arn":"arn:aws:iam::123456789012:role/deploy"}}}`, 0); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(fn); err != nil || runtime.GOOS != "windows" && fi.Mode().Perm() != secretFileMode {
+		t.Errorf("%s has permissions %v, %v, want %o", providersOverrideFile, fi.Mode().Perm(), err, secretFileMode)
+	}
+	var got struct {
+		Provider map[string]struct {
+			Region     string `json:"region"`
+			AssumeRole struct {
+				RoleARN string `json:"role_arn"`
+			} `json:"assume_role"`
+		} `json:"provider"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if aws := got.Provider["aws"]; aws.Region != "us-east-1" || aws.AssumeRole.RoleARN != "arn:aws:iam::123456789012:role/deploy" {
+		t.Errorf("%s = %s", providersOverrideFile, b)
+	}
+
+	// Unsetting it removes the file, and doing so again is fine.
+	for i := 0; i < 2; i++ {
+		if err := writeProvidersOverride(dir, "", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(fn); !os.IsNotExist(err) {
+		t.Errorf("%s not removed: %v", providersOverrideFile, err)
+	}
+
+	for _, invalid := range []string{`[]`, `null`, `{"aws": "us-east-1"}`, `{"aws": [{}]}`, `{`} {
+		if _, err := providersOverride(invalid); err == nil {
+			t.Errorf("providersOverride(%s): got no error", invalid)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ProviderSchemasDataSource{}
+
+func NewProviderSchemasDataSource() datasource.DataSource {
+	return &ProviderSchemasDataSource{}
+}
+
+// ProviderSchemasDataSource defines the data source implementation.
+type ProviderSchemasDataSource struct {
+	provider *providerData
+}
+
+// ProviderSchemasDataSourceModel describes the data source data model.
+type ProviderSchemasDataSourceModel struct {
+	WorkingDir types.String `tfsdk:"working_dir"`
+	Schemas    types.String `tfsdk:"schemas"`
+	Providers  []string     `tfsdk:"providers"`
+}
+
+func (d *ProviderSchemasDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+	d.provider = data
+}
+
+func (d *ProviderSchemasDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_provider_schemas"
+}
+
+func (d *ProviderSchemasDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reads the schemas of the providers a nested configuration uses with `terraform providers schema -json`, so the outer configuration can check what they support. The working directory must already be initialized, for example by a `pteraform_apply`.",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory of the nested configuration.",
+				Required:            true,
+			},
+			"schemas": schema.StringAttribute{
+				MarkdownDescription: "The [provider schemas](https://developer.hashicorp.com/terraform/cli/commands/providers/schema#providers-schema-representation), JSON-encoded; use `jsondecode` to get its value.",
+				Computed:            true,
+			},
+			"providers": schema.ListAttribute{
+				MarkdownDescription: "Source addresses of the providers with schemas, like `registry.terraform.io/hashicorp/aws`, sorted.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// schemaProviders returns the sorted source addresses of the providers in
+// the output of terraform providers schema -json.
+func schemaProviders(out string) ([]string, error) {
+	var schemas struct {
+		ProviderSchemas map[string]json.RawMessage `json:"provider_schemas"`
+	}
+	if err := json.Unmarshal([]byte(out), &schemas); err != nil {
+		return nil, fmt.Errorf("Unable to parse provider schemas, got error: %s", err)
+	}
+	providers := make([]string, 0, len(schemas.ProviderSchemas))
+	for p := range schemas.ProviderSchemas {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers, nil
+}
+
+func (d *ProviderSchemasDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ProviderSchemasDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+	tf := terraformRunner{inherit: d.provider.inherit(), env: d.provider.runEnv(runID), fileMode: d.provider.fileMode()}
+	out, err := tf.run(ctx, data.WorkingDir.ValueString(), "providers", "schema", "-json")
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read provider schemas, got error: %s", err))
+		return
+	}
+	providers, err := schemaProviders(out)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+	data.Schemas = types.StringValue(out)
+	data.Providers = providers
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
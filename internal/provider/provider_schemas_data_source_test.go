@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaProviders(t *testing.T) {
+	got, err := schemaProviders(`{"format_version": "1.0", "provider_schemas": {
+  "registry.terraform.io/hashicorp/random": {"provider": {"version": 0, "block": {}}},
+  "registry.terraform.io/hashicorp/aws": {"provider": {"version": 0, "block": {}}}
+}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"registry.terraform.io/hashicorp/aws", "registry.terraform.io/hashicorp/random"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("schemaProviders (-want +got):\n%s", diff)
+	}
+
+	if got, err := schemaProviders(`{"format_version": "1.0"}`); err != nil || len(got) != 0 {
+		t.Errorf("schemaProviders with no providers = %q, %v, want none", got, err)
+	}
+	if _, err := schemaProviders("Error: Inconsistent dependency lock file"); err == nil {
+		t.Error("schemaProviders of an error succeeded, want error")
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// rateLimitVars maps the keys of rate_limits to the environment variables
+// the nested providers, or the SDKs they're built on, read them from.
+var rateLimitVars = map[string]string{
+	// The AWS SDK, used by the aws and awscc providers.
+	"aws_max_attempts": "AWS_MAX_ATTEMPTS",
+	"aws_retry_mode":   "AWS_RETRY_MODE",
+	// The cloudflare provider.
+	"cloudflare_rps":     "CLOUDFLARE_RPS",
+	"cloudflare_retries": "CLOUDFLARE_RETRIES",
+	// The Vault API client, used by the vault provider.
+	"vault_max_retries": "VAULT_MAX_RETRIES",
+	"vault_rate_limit":  "VAULT_RATE_LIMIT",
+}
+
+// rateLimitKeys returns the valid keys of rate_limits, sorted.
+func rateLimitKeys() []string {
+	keys := make([]string, 0, len(rateLimitVars))
+	for k := range rateLimitVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rateLimitEnv returns the environment variables that set m's rate_limits
+// for the nested providers, sorted by key. Unknown keys are left out, since
+// they're rejected when the configuration is validated.
+func (m *ApplyResourceModel) rateLimitEnv() []string {
+	var env []string
+	elements := m.RateLimits.Elements()
+	for _, k := range rateLimitKeys() {
+		v, ok := elements[k].(types.String)
+		if !ok || v.IsNull() || v.IsUnknown() {
+			continue
+		}
+		env = append(env, rateLimitVars[k]+"="+v.ValueString())
+	}
+	return env
+}
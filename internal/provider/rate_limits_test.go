@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRateLimitEnv(t *testing.T) {
+	m := testApplyModel(t.TempDir())
+	if got := m.rateLimitEnv(); got != nil {
+		t.Errorf("no rate_limits: got %v", got)
+	}
+
+	m.RateLimits = types.MapValueMust(types.StringType, map[string]attr.Value{
+		"vault_rate_limit": types.StringValue("10:20"),
+		"aws_max_attempts": types.StringValue("10"),
+		"cloudflare_rps":   types.StringUnknown(),
+		"not_a_limit":      types.StringValue("1"),
+	})
+	want := []string{"AWS_MAX_ATTEMPTS=10", "VAULT_RATE_LIMIT=10:20"}
+	if diff := cmp.Diff(want, m.rateLimitEnv()); diff != "" {
+		t.Errorf("rateLimitEnv (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tfaddr "github.com/hashicorp/terraform-registry-address"
+)
+
+// providerType is this provider's type name. A nested configuration that
+// uses a provider of this type, from any registry or namespace, would run
+// pteraform again.
+const providerType = "pteraform"
+
+// checkRecursion inspects every module in the configuration rooted at root
+// and returns an error describing each use of this provider, which would
+// wrap terraform inside itself, and is almost always a mistake.
+func checkRecursion(root string) error {
+	dirs, err := moduleDirs(root)
+	if err != nil {
+		return err
+	}
+	var uses []string
+	for _, dir := range dirs {
+		mod, err := loadModule(dir)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, r := range mod.Resources {
+			src, err := mod.ProviderSource(r.Provider)
+			if err != nil {
+				return err
+			}
+			if isThisProvider(src) {
+				found = true
+				uses = append(uses, fmt.Sprintf("%s (%s:%d) uses provider %q", r.Address(), relDir(root, r.Filename), r.Pos.Line, src))
+			}
+		}
+		if found {
+			continue
+		}
+		for _, req := range mod.RequiredProviders {
+			if isThisProvider(req.Source) {
+				uses = append(uses, fmt.Sprintf("module %s requires provider %q", relDir(root, dir), req.Source))
+			}
+		}
+	}
+	if len(uses) > 0 {
+		sort.Strings(uses)
+		return fmt.Errorf("nested configuration uses pteraform itself, which would recurse; set allow_recursion if that's intended:\n  %s", strings.Join(uses, "\n  "))
+	}
+	return nil
+}
+
+// isThisProvider reports whether the provider source address src is of this
+// provider's type.
+func isThisProvider(src string) bool {
+	p, err := tfaddr.ParseProviderSource(src)
+	return err == nil && p.Type == providerType
+}
@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRecursion(t *testing.T) {
+	for _, c := range []struct {
+		desc    string
+		files   map[string]string
+		wantErr []string
+	}{{
+		desc:  "no recursion",
+		files: map[string]string{"main.tf": `resource "null_resource" "a" {}`},
+	}, {
+		desc: "resource",
+		files: map[string]string{
+			"main.tf": `
+terraform {
+  required_providers {
+    pteraform = {
+      source = "imjasonh/pteraform"
+    }
+  }
+}
+
+resource "pteraform_apply" "inner" {
+  working_dir = "inner"
+}
+`,
+		},
+		wantErr: []string{`pteraform_apply.inner (main.tf:10) uses provider "registry.terraform.io/imjasonh/pteraform"`},
+	}, {
+		desc: "required by child module",
+		files: map[string]string{
+			"main.tf": `module "child" { source = "./child" }`,
+			"child/versions.tf": `
+terraform {
+  required_providers {
+    wrapper = {
+      source = "example.com/acme/pteraform"
+    }
+  }
+}
+`,
+		},
+		wantErr: []string{`module child requires provider "example.com/acme/pteraform"`},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			err := checkRecursion(writeFiles(t, c.files))
+			if len(c.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("checkRecursion: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("checkRecursion succeeded, want error")
+			}
+			for _, want := range c.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
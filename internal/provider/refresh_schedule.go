@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// lastFullRefreshKey is the private state key the time of the last apply
+// that refreshed the nested state is stored under, if full_refresh_every is
+// set.
+const lastFullRefreshKey = "last_full_refresh"
+
+// fullRefreshDue reports whether the nested state should be refreshed, given
+// the time of the last full refresh as stored in private state, which is nil
+// if there hasn't been one.
+func fullRefreshDue(last []byte, every time.Duration, now time.Time) bool {
+	var t time.Time
+	if len(last) == 0 || json.Unmarshal(last, &t) != nil || t.IsZero() {
+		return true
+	}
+	return now.Sub(t) >= every
+}
+
+// recordFullRefresh stores now as the time of the last full refresh.
+func recordFullRefresh(ctx context.Context, private privateState, now time.Time) diag.Diagnostics {
+	b, err := json.Marshal(now.UTC())
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record full refresh, got error: %s", err))
+		return diags
+	}
+	return private.SetKey(ctx, lastFullRefreshKey, b)
+}
@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFullRefreshDue(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		last string
+		want bool
+	}{
+		{"", true},
+		{"null", true},
+		{"not json", true},
+		{`"2024-01-02T11:00:00Z"`, false},
+		{`"2024-01-01T12:00:00Z"`, true},
+		{`"2024-01-01T07:00:00-05:00"`, true},
+	} {
+		var last []byte
+		if c.last != "" {
+			last = []byte(c.last)
+		}
+		if got := fullRefreshDue(last, 24*time.Hour, now); got != c.want {
+			t.Errorf("fullRefreshDue(%s) = %t, want %t", c.last, got, c.want)
+		}
+	}
+}
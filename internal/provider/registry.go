@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// registryEntry is a value an apply published to the registry, stored as
+// the JSON file named for it in the registry directory.
+type registryEntry struct {
+	// Value is the JSON-encoded value of the nested output.
+	Value       json.RawMessage `json:"value"`
+	Sensitive   bool            `json:"sensitive"`
+	WorkingDir  string          `json:"working_dir"`
+	Output      string          `json:"output"`
+	RunID       string          `json:"run_id,omitempty"`
+	PublishedAt time.Time       `json:"published_at"`
+}
+
+// defaultRegistryDir returns the directory of the registry if registry_dir
+// isn't set: one for each outer workspace, in the outer data directory, so
+// that it's shared by everything in the outer configuration.
+func defaultRegistryDir(getenv func(string) string) string {
+	return filepath.Join(outerDataDir(getenv), "pteraform-registry", outerWorkspace(getenv))
+}
+
+// registryDir returns the directory of the registry. It may be called on a
+// nil providerData, for a provider that hasn't been configured.
+func (pd *providerData) registryDir() string {
+	if pd == nil || pd.RegistryDir == "" {
+		return defaultRegistryDir(os.Getenv)
+	}
+	return pd.RegistryDir
+}
+
+// registryPath returns the file of the entry name in the registry in dir.
+func registryPath(dir, name string) string {
+	return filepath.Join(dir, name+".json")
+}
+
+// publish writes the nested outputs in out, the output of terraform output
+// -json, to the registry in dir as given by publish, which maps each name
+// to publish under to the output to publish. Entries are written with
+// permissions mode, or secretFileMode if it's 0, since they may hold
+// sensitive outputs.
+func publish(dir string, publish map[string]string, out string, e registryEntry, mode os.FileMode) error {
+	values, sensitive, err := stateOutputs(out)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(publish))
+	for name := range publish {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for _, name := range names {
+		e := e
+		e.Output = publish[name]
+		v, ok := values[e.Output]
+		if !ok {
+			if v, ok = sensitive[e.Output]; !ok {
+				return fmt.Errorf("the nested configuration has no output %q to publish as %q", e.Output, name)
+			}
+			e.Sensitive = true
+		}
+		e.Value = json.RawMessage(v)
+		b, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return err
+		}
+		// Write atomically, so a concurrent read never sees part of it.
+		tmp := registryPath(dir, name) + ".tmp"
+		if err := writeFile(tmp, b, modeOr(mode, secretFileMode)); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, registryPath(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRegistry returns the entry name of the registry in dir, or nil if
+// nothing has been published under it.
+func readRegistry(dir, name string) (*registryEntry, error) {
+	b, err := os.ReadFile(registryPath(dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var e registryEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("Unable to parse registry entry %q, got error: %s", name, err)
+	}
+	return &e, nil
+}
+
+// publishOutputs publishes m's publish_outputs after an apply.
+func (r *ApplyResource) publishOutputs(ctx context.Context, m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.PublishOutputs.IsNull() {
+		return diags
+	}
+	var names map[string]string
+	if diags.Append(m.PublishOutputs.ElementsAs(ctx, &names, false)...); diags.HasError() {
+		return diags
+	}
+	out, err := r.nestedOutputJSON(ctx, m)
+	if err == nil {
+		err = publish(r.provider.registryDir(), names, out, registryEntry{
+			WorkingDir:  m.WorkingDir.ValueString(),
+			RunID:       m.RunID.ValueString(),
+			PublishedAt: time.Now().UTC(),
+		}, r.fileMode())
+	}
+	if err != nil {
+		diags.AddAttributeError(path.Root("publish_outputs"), "Unable to Publish Nested Outputs", err.Error())
+	}
+	return diags
+}
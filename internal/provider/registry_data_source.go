@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &RegistryDataSource{}
+
+func NewRegistryDataSource() datasource.DataSource {
+	return &RegistryDataSource{}
+}
+
+// RegistryDataSource defines the data source implementation.
+type RegistryDataSource struct {
+	provider *providerData
+}
+
+// RegistryDataSourceModel describes the data source data model.
+type RegistryDataSourceModel struct {
+	Name           types.String `tfsdk:"name"`
+	Published      types.Bool   `tfsdk:"published"`
+	Value          types.String `tfsdk:"value"`
+	SensitiveValue types.String `tfsdk:"sensitive_value"`
+	WorkingDir     types.String `tfsdk:"working_dir"`
+	PublishedAt    types.String `tfsdk:"published_at"`
+}
+
+func (d *RegistryDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData))
+		return
+	}
+	d.provider = data
+}
+
+func (d *RegistryDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_registry"
+}
+
+func (d *RegistryDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reads a value a `pteraform_apply` published to the provider's registry with `publish_outputs`, so loosely coupled nested stacks in the same outer configuration can share values without referring to each other. The value is the one published by the last apply, which may be in an earlier outer run.",
+
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name the value was published under.",
+				Required:            true,
+			},
+			"published": schema.BoolAttribute{
+				MarkdownDescription: "Whether anything has been published under `name` yet. If not, the other attributes are null.",
+				Computed:            true,
+			},
+			"value": schema.StringAttribute{
+				MarkdownDescription: "The JSON-encoded value, if its output isn't sensitive; use `jsondecode` to get its value.",
+				Computed:            true,
+			},
+			"sensitive_value": schema.StringAttribute{
+				MarkdownDescription: "The JSON-encoded value, if its output is sensitive.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Working directory of the `pteraform_apply` that published the value.",
+				Computed:            true,
+			},
+			"published_at": schema.StringAttribute{
+				MarkdownDescription: "When the value was published, in RFC 3339 format.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *RegistryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data RegistryDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !hclsyntax.ValidIdentifier(data.Name.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Invalid Registry Name", fmt.Sprintf("%q is not a valid registry name.", data.Name.ValueString()))
+		return
+	}
+
+	e, err := readRegistry(d.provider.registryDir(), data.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read registry, got error: %s", err))
+		return
+	}
+	data.Published = types.BoolValue(e != nil)
+	data.Value, data.SensitiveValue = types.StringNull(), types.StringNull()
+	data.WorkingDir, data.PublishedAt = types.StringNull(), types.StringNull()
+	if e != nil {
+		if e.Sensitive {
+			data.SensitiveValue = types.StringValue(string(e.Value))
+		} else {
+			data.Value = types.StringValue(string(e.Value))
+		}
+		data.WorkingDir = types.StringValue(e.WorkingDir)
+		data.PublishedAt = types.StringValue(e.PublishedAt.Format(time.RFC3339))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "registry")
+	out := `{
+  "vpc_id": {"sensitive": false, "type": "string", "value": "vpc-1"},
+  "db_password": {"sensitive": true, "type": "string", "value": "hunter2"}
+}`
+	if err := publish(dir, map[string]string{"network_vpc_id": "vpc_id", "db_password": "db_password"}, out, registryEntry{WorkingDir: "network"}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := readRegistry(dir, "network_vpc_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || string(e.Value) != `"vpc-1"` || e.Sensitive || e.Output != "vpc_id" || e.WorkingDir != "network" {
+		t.Errorf("readRegistry(network_vpc_id) = %+v", e)
+	}
+	if e, err := readRegistry(dir, "db_password"); err != nil || e == nil || !e.Sensitive {
+		t.Errorf("readRegistry(db_password) = %+v, %v, want it sensitive", e, err)
+	}
+	if e, err := readRegistry(dir, "missing"); err != nil || e != nil {
+		t.Errorf("readRegistry(missing) = %+v, %v", e, err)
+	}
+
+	if err := publish(dir, map[string]string{"subnet": "subnet_id"}, out, registryEntry{}, 0); err == nil || !strings.Contains(err.Error(), `no output "subnet_id"`) {
+		t.Errorf("got error %v publishing a missing output", err)
+	}
+}
+
+func TestDefaultRegistryDir(t *testing.T) {
+	env := map[string]string{"TF_DATA_DIR": "/outer/data", "TF_WORKSPACE": "prod"}
+	if got, want := defaultRegistryDir(func(k string) string { return env[k] }), filepath.Join("/outer/data", "pteraform-registry", "prod"); got != want {
+		t.Errorf("defaultRegistryDir = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultRemoteHostname is the HCP Terraform hostname used if none is
+// configured.
+const defaultRemoteHostname = "app.terraform.io"
+
+// remotePollInterval is how often the status of an upload or run is checked.
+var remotePollInterval = 5 * time.Second
+
+// remoteToken returns the API token for hostname from the environment, in
+// the TF_TOKEN_<hostname> variable terraform itself reads, or TFE_TOKEN.
+func remoteToken(getenv func(string) string, hostname string) string {
+	name := "TF_TOKEN_" + strings.NewReplacer(".", "_", "-", "__").Replace(hostname)
+	if t := getenv(name); t != "" {
+		return t
+	}
+	return getenv("TFE_TOKEN")
+}
+
+// tfeClient calls the HCP Terraform or Terraform Enterprise API.
+type tfeClient struct {
+	client *http.Client
+	// base is the API's base URL, like https://app.terraform.io/api/v2.
+	base  string
+	token string
+}
+
+// jsonAPIDocument is a response document, with the subset of JSON:API used
+// here.
+type jsonAPIDocument struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// jsonAPIResource is a resource object in a JSON:API document.
+type jsonAPIResource struct {
+	ID            string                         `json:"id,omitempty"`
+	Type          string                         `json:"type"`
+	Attributes    map[string]interface{}         `json:"attributes,omitempty"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships,omitempty"`
+}
+
+// jsonAPIRelationship is a to-one relationship.
+type jsonAPIRelationship struct {
+	Data jsonAPIResource `json:"data"`
+}
+
+// do sends a request for path with a JSON:API document body, if body is
+// non-nil, and decodes the response's data into out, if it's non-nil.
+func (c tfeClient) do(ctx context.Context, method, path string, body *jsonAPIResource, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(map[string]interface{}{"data": body})
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	var doc jsonAPIDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("%s %s: unable to parse response, got error: %s", method, path, err)
+	}
+	return json.Unmarshal(doc.Data, out)
+}
+
+// workspaceID returns the ID of the workspace with the given name.
+func (c tfeClient) workspaceID(ctx context.Context, organization, workspace string) (string, error) {
+	var ws jsonAPIResource
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/organizations/%s/workspaces/%s", url.PathEscape(organization), url.PathEscape(workspace)), nil, &ws); err != nil {
+		return "", fmt.Errorf("Unable to find workspace, got error: %s", err)
+	}
+	return ws.ID, nil
+}
+
+// uploadConfiguration creates a configuration version in the workspace
+// from the configuration in dir, waits until it's been processed, and
+// returns its ID.
+func (c tfeClient) uploadConfiguration(ctx context.Context, workspaceID, dir string) (string, error) {
+	archive, err := packConfiguration(dir)
+	if err != nil {
+		return "", fmt.Errorf("Unable to package configuration, got error: %s", err)
+	}
+	var cv jsonAPIResource
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/workspaces/%s/configuration-versions", workspaceID), &jsonAPIResource{
+		Type:       "configuration-versions",
+		Attributes: map[string]interface{}{"auto-queue-runs": false},
+	}, &cv); err != nil {
+		return "", fmt.Errorf("Unable to create configuration version, got error: %s", err)
+	}
+	uploadURL, _ := cv.Attributes["upload-url"].(string)
+	if uploadURL == "" {
+		return "", fmt.Errorf("configuration version %s has no upload URL", cv.ID)
+	}
+
+	// The upload URL is pre-signed, so it's used without the token.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(archive))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Unable to upload configuration, got error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("Unable to upload configuration, got status: %s", resp.Status)
+	}
+
+	for {
+		if err := c.do(ctx, http.MethodGet, "/configuration-versions/"+cv.ID, nil, &cv); err != nil {
+			return "", fmt.Errorf("Unable to get configuration version, got error: %s", err)
+		}
+		switch status, _ := cv.Attributes["status"].(string); status {
+		case "uploaded":
+			return cv.ID, nil
+		case "errored":
+			msg, _ := cv.Attributes["error-message"].(string)
+			return "", fmt.Errorf("configuration version %s errored: %s", cv.ID, msg)
+		}
+		if err := sleep(ctx, remotePollInterval); err != nil {
+			return "", err
+		}
+	}
+}
+
+// remoteRun is the outcome of a run.
+type remoteRun struct {
+	ID     string
+	Status string
+}
+
+// run starts a run applying the configuration version in the workspace,
+// and waits for it to finish.
+func (c tfeClient) run(ctx context.Context, workspaceID, configVersionID, message string) (remoteRun, error) {
+	attrs := map[string]interface{}{"auto-apply": true}
+	if message != "" {
+		attrs["message"] = message
+	}
+	var run jsonAPIResource
+	if err := c.do(ctx, http.MethodPost, "/runs", &jsonAPIResource{
+		Type:       "runs",
+		Attributes: attrs,
+		Relationships: map[string]jsonAPIRelationship{
+			"workspace":             {Data: jsonAPIResource{Type: "workspaces", ID: workspaceID}},
+			"configuration-version": {Data: jsonAPIResource{Type: "configuration-versions", ID: configVersionID}},
+		},
+	}, &run); err != nil {
+		return remoteRun{}, fmt.Errorf("Unable to create run, got error: %s", err)
+	}
+
+	for {
+		status, _ := run.Attributes["status"].(string)
+		r := remoteRun{ID: run.ID, Status: status}
+		switch status {
+		case "applied", "planned_and_finished":
+			return r, nil
+		case "errored", "discarded", "canceled", "force_canceled", "policy_soft_failed":
+			return r, fmt.Errorf("run %s finished with status %s", run.ID, status)
+		}
+		if err := sleep(ctx, remotePollInterval); err != nil {
+			return r, err
+		}
+		if err := c.do(ctx, http.MethodGet, "/runs/"+run.ID, nil, &run); err != nil {
+			return r, fmt.Errorf("Unable to get run, got error: %s", err)
+		}
+	}
+}
+
+// outputs returns the JSON-encoded values of the outputs in the workspace's
+// current state, split by whether they're sensitive.
+func (c tfeClient) outputs(ctx context.Context, workspaceID string) (map[string]string, map[string]string, error) {
+	var outputs []jsonAPIResource
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/workspaces/%s/current-state-version-outputs", workspaceID), nil, &outputs); err != nil {
+		return nil, nil, fmt.Errorf("Unable to get outputs, got error: %s", err)
+	}
+	values, sensitive := map[string]string{}, map[string]string{}
+	for _, o := range outputs {
+		name, _ := o.Attributes["name"].(string)
+		if s, _ := o.Attributes["sensitive"].(bool); s {
+			// Sensitive values are only included when outputs are read
+			// individually.
+			if err := c.do(ctx, http.MethodGet, "/state-version-outputs/"+o.ID, nil, &o); err != nil {
+				return nil, nil, fmt.Errorf("Unable to get output %s, got error: %s", name, err)
+			}
+			b, err := json.Marshal(o.Attributes["value"])
+			if err != nil {
+				return nil, nil, err
+			}
+			sensitive[name] = string(b)
+			continue
+		}
+		b, err := json.Marshal(o.Attributes["value"])
+		if err != nil {
+			return nil, nil, err
+		}
+		values[name] = string(b)
+	}
+	return values, sensitive, nil
+}
+
+// packConfiguration returns a gzipped tar archive of the configuration in
+// dir, without the .terraform directory or local state.
+func packConfiguration(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".terraform" {
+			return filepath.SkipDir
+		}
+		if strings.HasSuffix(d.Name(), ".tfstate") || strings.HasSuffix(d.Name(), ".tfstate.backup") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sleep waits for d, or returns an error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
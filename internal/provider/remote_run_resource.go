@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &RemoteRunResource{}
+
+func NewRemoteRunResource() resource.Resource {
+	return &RemoteRunResource{}
+}
+
+// RemoteRunResource defines the resource implementation.
+type RemoteRunResource struct {
+	// client, if set, is the HTTP client used to call the API. It is
+	// overridden in tests.
+	client *http.Client
+}
+
+// RemoteRunResourceModel describes the resource data model.
+type RemoteRunResourceModel struct {
+	Hostname     types.String `tfsdk:"hostname"`
+	Organization types.String `tfsdk:"organization"`
+	Workspace    types.String `tfsdk:"workspace"`
+	WorkingDir   types.String `tfsdk:"working_dir"`
+	Message      types.String `tfsdk:"message"`
+	Token        types.String `tfsdk:"token"`
+	Triggers     types.Map    `tfsdk:"triggers"`
+
+	Id                     types.String `tfsdk:"id"`
+	WorkspaceID            types.String `tfsdk:"workspace_id"`
+	ConfigurationVersionID types.String `tfsdk:"configuration_version_id"`
+	Status                 types.String `tfsdk:"status"`
+	Outputs                types.Map    `tfsdk:"outputs"`
+	SensitiveOutputs       types.Map    `tfsdk:"sensitive_outputs"`
+}
+
+func (r *RemoteRunResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_remote_run"
+}
+
+func (r *RemoteRunResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Runs a nested configuration in an HCP Terraform or Terraform Enterprise workspace through its API, instead of running `terraform` locally. The configuration in `working_dir` is uploaded as a new configuration version and applied by a run, which is waited for. Destroying the resource doesn't destroy anything in the workspace.",
+
+		Attributes: map[string]schema.Attribute{
+			"hostname": schema.StringAttribute{
+				MarkdownDescription: "Hostname of HCP Terraform or Terraform Enterprise. Defaults to `app.terraform.io`.",
+				Optional:            true,
+			},
+			"organization": schema.StringAttribute{
+				MarkdownDescription: "Organization the workspace is in.",
+				Required:            true,
+			},
+			"workspace": schema.StringAttribute{
+				MarkdownDescription: "Name of the workspace to run in. It must already exist, and not be connected to a VCS repository.",
+				Required:            true,
+			},
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory of the configuration to upload. Its `.terraform` directory and any local state files are left out.",
+				Required:            true,
+			},
+			"message": schema.StringAttribute{
+				MarkdownDescription: "Message recorded for each run.",
+				Optional:            true,
+			},
+			"token": schema.StringAttribute{
+				MarkdownDescription: "API token. Defaults to the `TF_TOKEN_<hostname>` environment variable terraform uses, like `TF_TOKEN_app_terraform_io`, or `TFE_TOKEN`.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values that start a new run whenever they change.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "ID of the last run.",
+				Computed:            true,
+			},
+			"workspace_id": schema.StringAttribute{
+				MarkdownDescription: "ID of the workspace.",
+				Computed:            true,
+			},
+			"configuration_version_id": schema.StringAttribute{
+				MarkdownDescription: "ID of the configuration version the last run applied.",
+				Computed:            true,
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "Final status of the last run: `applied`, or `planned_and_finished` if there was nothing to change.",
+				Computed:            true,
+			},
+			"outputs": schema.MapAttribute{
+				MarkdownDescription: "Values of the workspace's outputs not marked sensitive, keyed by name. Each is JSON-encoded; use `jsondecode` to get its value.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+			"sensitive_outputs": schema.MapAttribute{
+				MarkdownDescription: "Values of the workspace's outputs marked sensitive, keyed by name and JSON-encoded like `outputs`.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+				Sensitive:           true,
+			},
+		},
+	}
+}
+
+// tfe returns a client for the API of the configured host.
+func (r *RemoteRunResource) tfe(m *RemoteRunResourceModel) tfeClient {
+	host := m.Hostname.ValueString()
+	if host == "" {
+		host = defaultRemoteHostname
+	}
+	token := m.Token.ValueString()
+	if token == "" {
+		token = remoteToken(os.Getenv, host)
+	}
+	client := r.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return tfeClient{client: client, base: "https://" + host + "/api/v2", token: token}
+}
+
+// run uploads the configuration and applies it in the workspace.
+func (r *RemoteRunResource) run(ctx context.Context, m *RemoteRunResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	c := r.tfe(m)
+	wsID, err := c.workspaceID(ctx, m.Organization.ValueString(), m.Workspace.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return diags
+	}
+	m.WorkspaceID = types.StringValue(wsID)
+	cvID, err := c.uploadConfiguration(ctx, wsID, m.WorkingDir.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return diags
+	}
+	m.ConfigurationVersionID = types.StringValue(cvID)
+	run, err := c.run(ctx, wsID, cvID, m.Message.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to run %s/%s, got error: %s", m.Organization.ValueString(), m.Workspace.ValueString(), err))
+		return diags
+	}
+	m.Id, m.Status = types.StringValue(run.ID), types.StringValue(run.Status)
+	diags.Append(r.readOutputs(ctx, m)...)
+	return diags
+}
+
+// readOutputs sets the outputs to those of the workspace's current state.
+func (r *RemoteRunResource) readOutputs(ctx context.Context, m *RemoteRunResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	values, sensitive, err := r.tfe(m).outputs(ctx, m.WorkspaceID.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return diags
+	}
+	var d diag.Diagnostics
+	m.Outputs, d = types.MapValueFrom(ctx, types.StringType, values)
+	diags.Append(d...)
+	m.SensitiveOutputs, d = types.MapValueFrom(ctx, types.StringType, sensitive)
+	diags.Append(d...)
+	return diags
+}
+
+func (r *RemoteRunResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data RemoteRunResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.run(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RemoteRunResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data RemoteRunResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.readOutputs(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RemoteRunResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data RemoteRunResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.run(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RemoteRunResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Nothing to delete; the workspace and what it manages are left as they
+	// are.
+}
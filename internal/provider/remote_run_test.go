@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRemoteToken(t *testing.T) {
+	env := map[string]string{"TF_TOKEN_tfe_example_com": "host", "TFE_TOKEN": "fallback"}
+	getenv := func(k string) string { return env[k] }
+	if got := remoteToken(getenv, "tfe.example.com"); got != "host" {
+		t.Errorf("remoteToken(tfe.example.com) = %q, want host", got)
+	}
+	if got := remoteToken(getenv, "app.terraform.io"); got != "fallback" {
+		t.Errorf("remoteToken(app.terraform.io) = %q, want fallback", got)
+	}
+}
+
+func TestPackConfiguration(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf":                      "",
+		"modules/child/main.tf":        "",
+		"terraform.tfstate":            "{}",
+		".terraform/providers/x":       "",
+		".terraform.lock.hcl":          "",
+		"terraform.tfstate.backup":     "{}",
+		"modules/child/variables.tf":   "",
+		"terraform.tfstate.d/dev/x.tf": "",
+	})
+	b, err := packConfiguration(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var got []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			got = append(got, hdr.Name)
+		}
+	}
+	sort.Strings(got)
+	want := []string{".terraform.lock.hcl", "main.tf", "modules/child/main.tf", "modules/child/variables.tf", "terraform.tfstate.d/dev/x.tf"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("archive (-want +got):\n%s", diff)
+	}
+}
+
+func TestRemoteRun(t *testing.T) {
+	defer func(d time.Duration) { remotePollInterval = d }(remotePollInterval)
+	remotePollInterval = 0
+	polls := 0
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload" && r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		reply := func(v interface{}) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": v})
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/organizations/acme/workspaces/network":
+			reply(map[string]interface{}{"id": "ws-1", "type": "workspaces"})
+		case "POST /api/v2/workspaces/ws-1/configuration-versions":
+			reply(map[string]interface{}{"id": "cv-1", "type": "configuration-versions", "attributes": map[string]interface{}{"upload-url": srv.URL + "/upload", "status": "pending"}})
+		case "PUT /upload":
+			if _, err := gzip.NewReader(r.Body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+		case "GET /api/v2/configuration-versions/cv-1":
+			reply(map[string]interface{}{"id": "cv-1", "type": "configuration-versions", "attributes": map[string]interface{}{"status": "uploaded"}})
+		case "POST /api/v2/runs":
+			var doc struct {
+				Data jsonAPIResource `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&doc); err != nil || doc.Data.Relationships["configuration-version"].Data.ID != "cv-1" {
+				http.Error(w, "bad run", http.StatusBadRequest)
+				return
+			}
+			reply(map[string]interface{}{"id": "run-1", "type": "runs", "attributes": map[string]interface{}{"status": "pending"}})
+		case "GET /api/v2/runs/run-1":
+			polls++
+			status := "applying"
+			if polls > 1 {
+				status = "applied"
+			}
+			reply(map[string]interface{}{"id": "run-1", "type": "runs", "attributes": map[string]interface{}{"status": status}})
+		case "GET /api/v2/workspaces/ws-1/current-state-version-outputs":
+			reply([]interface{}{
+				map[string]interface{}{"id": "wsout-1", "type": "state-version-outputs", "attributes": map[string]interface{}{"name": "vpc_id", "sensitive": false, "value": "vpc-123"}},
+				map[string]interface{}{"id": "wsout-2", "type": "state-version-outputs", "attributes": map[string]interface{}{"name": "password", "sensitive": true, "value": nil}},
+			})
+		case "GET /api/v2/state-version-outputs/wsout-2":
+			reply(map[string]interface{}{"id": "wsout-2", "type": "state-version-outputs", "attributes": map[string]interface{}{"name": "password", "sensitive": true, "value": "hunter2"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	r := &RemoteRunResource{client: srv.Client()}
+	m := RemoteRunResourceModel{
+		Hostname:     types.StringValue(strings.TrimPrefix(srv.URL, "https://")),
+		Organization: types.StringValue("acme"),
+		Workspace:    types.StringValue("network"),
+		WorkingDir:   types.StringValue(writeFiles(t, map[string]string{"main.tf": ""})),
+		Token:        types.StringValue("secret"),
+	}
+	if diags := r.run(ctx, &m); diags.HasError() {
+		t.Fatalf("run: %v", diags)
+	}
+	if m.Id.ValueString() != "run-1" || m.Status.ValueString() != "applied" || m.ConfigurationVersionID.ValueString() != "cv-1" {
+		t.Errorf("got run %s with status %s from %s, want run-1 applied from cv-1", m.Id, m.Status, m.ConfigurationVersionID)
+	}
+	var outputs, sensitive map[string]string
+	m.Outputs.ElementsAs(ctx, &outputs, false)
+	m.SensitiveOutputs.ElementsAs(ctx, &sensitive, false)
+	if diff := cmp.Diff(map[string]string{"vpc_id": `"vpc-123"`}, outputs); diff != "" {
+		t.Errorf("outputs (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"password": `"hunter2"`}, sensitive); diff != "" {
+		t.Errorf("sensitive_outputs (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// resolvedSource returns the exact version of the remote module m installed
+// in root, or "" for local modules. For modules cloned with git this is the
+// source with its ref replaced by the commit checked out; for other remote
+// modules, such as registry modules and archives, it's the sha256 digest of
+// the installed files.
+func resolvedSource(ctx context.Context, root string, m moduleManifestEntry) (string, error) {
+	if isLocalModuleSource(m.Source) {
+		return "", nil
+	}
+	dir := filepath.Join(root, m.Dir)
+	if gitClone(root, dir) {
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			return "", fmt.Errorf("Unable to get commit of module %s, got error: %s", m.Key, err)
+		}
+		return withRef(m.Source, strings.TrimSpace(string(out)))
+	}
+	digest, err := treeDigest(dir)
+	if err != nil {
+		return "", fmt.Errorf("Unable to digest module %s, got error: %s", m.Key, err)
+	}
+	return "sha256:" + digest, nil
+}
+
+// gitClone reports whether dir is in a git clone installed by terraform
+// init in root, rather than, say, root being in a git repository.
+func gitClone(root, dir string) bool {
+	modules := filepath.Join(root, ".terraform", "modules")
+	for d := dir; strings.HasPrefix(d, modules+string(filepath.Separator)); d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// withRef returns the git module source with its ref argument set to ref.
+func withRef(source, ref string) (string, error) {
+	s, query, _ := strings.Cut(source, "?")
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse module source %q, got error: %s", source, err)
+	}
+	q.Set("ref", ref)
+	return s + "?" + q.Encode(), nil
+}
+
+// treeDigest returns the hex-encoded SHA-256 digest of the files in dir,
+// their paths and contents, ignoring any .git directory.
+func treeDigest(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(fn string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, fn)
+		if err != nil {
+			return err
+		}
+		digest, err := fileDigest(fn)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s  %s\n", digest, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
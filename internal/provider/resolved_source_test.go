@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithRef(t *testing.T) {
+	for source, want := range map[string]string{
+		"git::https://example.com/vpc.git":                       "git::https://example.com/vpc.git?ref=abc123",
+		"git::https://example.com/vpc.git?ref=v1.2.0":            "git::https://example.com/vpc.git?ref=abc123",
+		"git::https://example.com/infra.git//modules/vpc?ref=v1": "git::https://example.com/infra.git//modules/vpc?ref=abc123",
+		"github.com/example/vpc?depth=1&ref=main":                "github.com/example/vpc?depth=1&ref=abc123",
+	} {
+		got, err := withRef(source, "abc123")
+		if err != nil {
+			t.Errorf("withRef(%q): %v", source, err)
+		} else if got != want {
+			t.Errorf("withRef(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestTreeDigest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": "a", "sub/x.tf": "b"})
+	before, err := treeDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := treeDigest(dir); err != nil || got != before {
+		t.Errorf("treeDigest with .git = %s, %v, want %s", got, err, before)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "sub", "x.tf"), []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := treeDigest(dir); err != nil || got == before {
+		t.Errorf("treeDigest after change = %s, %v, want a different digest", got, err)
+	}
+}
+
+func TestResolvedSource(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		".terraform/modules/archive/main.tf": "",
+		".terraform/modules/git/main.tf":     "",
+		"modules/local/main.tf":              "",
+	})
+	ctx := context.Background()
+
+	if got, err := resolvedSource(ctx, root, moduleManifestEntry{Key: "local", Source: "./modules/local", Dir: "modules/local"}); err != nil || got != "" {
+		t.Errorf("local module: got %q, %v, want empty", got, err)
+	}
+	got, err := resolvedSource(ctx, root, moduleManifestEntry{Key: "archive", Source: "https://example.com/vpc.zip", Dir: ".terraform/modules/archive"})
+	if err != nil || !strings.HasPrefix(got, "sha256:") {
+		t.Errorf("archive module: got %q, %v, want a sha256 digest", got, err)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := filepath.Join(root, ".terraform", "modules", "git")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "git::https://example.com/vpc.git?ref=" + strings.TrimSpace(string(out))
+	if got, err := resolvedSource(ctx, root, moduleManifestEntry{Key: "git", Source: "git::https://example.com/vpc.git?ref=v1", Dir: ".terraform/modules/git"}); err != nil || got != want {
+		t.Errorf("git module: got %q, %v, want %q", got, err, want)
+	}
+}
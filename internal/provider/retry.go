@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"math/rand"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// backoff is an exponential backoff policy with jitter.
+type backoff struct {
+	Attempts int
+	Base     time.Duration
+	Max      time.Duration
+}
+
+// delay returns how long to wait before retrying after the given attempt,
+// which starts at 1. The delay doubles each attempt up to Max, and is
+// jittered to between half and all of that.
+func (b backoff) delay(attempt int) time.Duration {
+	d := b.Base << (attempt - 1)
+	if d > b.Max || d <= 0 {
+		d = b.Max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// registryBackoff is used to retry terraform init when a registry
+// rate-limits provider or module downloads. It is independent of any other
+// retries, since rate limits usually clear up given enough time.
+var registryBackoff = backoff{
+	Attempts: 6,
+	Base:     2 * time.Second,
+	Max:      time.Minute,
+}
+
+// rateLimitedPattern matches terraform init output reporting that a
+// registry responded with HTTP 429.
+var rateLimitedPattern = regexp.MustCompile(`(?i)429 Too Many Requests|status(?: code)?:? 429\b|rate limit(?:ed| exceeded)`)
+
+// retryRateLimited calls run until it succeeds, fails with output that
+// doesn't indicate rate limiting, or the policy's attempts are exhausted.
+func retryRateLimited(ctx context.Context, b backoff, run func() (string, error)) (string, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := run()
+		if err == nil || attempt >= b.Attempts || !rateLimitedPattern.MatchString(out) {
+			return out, err
+		}
+		d := b.delay(attempt)
+		tflog.Warn(ctx, "Registry rate limit hit during terraform init, retrying", map[string]interface{}{
+			"attempt": attempt,
+			"delay":   d.String(),
+		})
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return out, err
+		case <-t.C:
+		}
+	}
+}
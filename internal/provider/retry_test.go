@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := backoff{Attempts: 10, Base: time.Second, Max: 10 * time.Second}
+	for attempt, max := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 8 * time.Second,
+		5: 10 * time.Second,
+		9: 10 * time.Second,
+	} {
+		for i := 0; i < 100; i++ {
+			if d := b.delay(attempt); d < max/2 || d > max {
+				t.Errorf("delay(%d) = %s, want between %s and %s", attempt, d, max/2, max)
+			}
+		}
+	}
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	b := backoff{Attempts: 3, Base: time.Millisecond, Max: time.Millisecond}
+	errFailed := errors.New("exit status 1")
+
+	for _, c := range []struct {
+		desc      string
+		outputs   []string
+		wantCalls int
+		wantErr   bool
+	}{{
+		desc:      "succeeds after rate limit",
+		outputs:   []string{"Error: ... 429 Too Many Requests", ""},
+		wantCalls: 2,
+	}, {
+		desc:      "other failure is not retried",
+		outputs:   []string{"Error: Failed to query available provider packages"},
+		wantCalls: 1,
+		wantErr:   true,
+	}, {
+		desc:      "gives up after attempts",
+		outputs:   []string{"status code 429", "status code 429", "status code 429", ""},
+		wantCalls: 3,
+		wantErr:   true,
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			calls := 0
+			_, err := retryRateLimited(context.Background(), b, func() (string, error) {
+				out := c.outputs[calls]
+				calls++
+				if out == "" {
+					return "", nil
+				}
+				return out, errFailed
+			})
+			if calls != c.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, c.wantCalls)
+			}
+			if (err != nil) != c.wantErr {
+				t.Errorf("got error %v, want error: %t", err, c.wantErr)
+			}
+		})
+	}
+}
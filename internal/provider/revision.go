@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// revisionTimeout bounds how long reading a revision from a URL waits.
+const revisionTimeout = 30 * time.Second
+
+// maxRevisionSize is the most a revision is read, since it's meant to be a
+// version or digest, not a document.
+const maxRevisionSize = 64 << 10
+
+// readRevision returns the revision published at url with the given request
+// headers, or in the file fn, with surrounding whitespace trimmed.
+func readRevision(ctx context.Context, client *http.Client, url string, headers map[string]string, fn string) (string, error) {
+	if fn != "" {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			return "", fmt.Errorf("Unable to read revision, got error: %s", err)
+		}
+		if len(b) > maxRevisionSize {
+			return "", fmt.Errorf("revision in %s is larger than %d bytes", fn, maxRevisionSize)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, revisionTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Unable to get revision, got error: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to get revision, got status: %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRevisionSize+1))
+	if err != nil {
+		return "", fmt.Errorf("Unable to read revision, got error: %s", err)
+	}
+	if len(b) > maxRevisionSize {
+		return "", fmt.Errorf("revision at %s is larger than %d bytes", url, maxRevisionSize)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &RevisionDataSource{}
+var _ datasource.DataSourceWithValidateConfig = &RevisionDataSource{}
+
+func NewRevisionDataSource() datasource.DataSource {
+	return &RevisionDataSource{}
+}
+
+// RevisionDataSource defines the data source implementation.
+type RevisionDataSource struct{}
+
+// RevisionDataSourceModel describes the data source data model.
+type RevisionDataSourceModel struct {
+	URL            types.String `tfsdk:"url"`
+	RequestHeaders types.Map    `tfsdk:"request_headers"`
+	File           types.String `tfsdk:"file"`
+	Value          types.String `tfsdk:"value"`
+}
+
+func (d *RevisionDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_revision"
+}
+
+func (d *RevisionDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reads a revision, like the module version a CD system wants deployed, from a URL or file. Its `value` can be passed to a `pteraform_apply` resource's `triggers`, so the nested configuration is applied again whenever it changes.",
+
+		Attributes: map[string]schema.Attribute{
+			"url": schema.StringAttribute{
+				MarkdownDescription: "URL to `GET` the revision from. It must respond with status 200. Exactly one of `url` or `file` must be set.",
+				Optional:            true,
+			},
+			"request_headers": schema.MapAttribute{
+				MarkdownDescription: "Headers to send with the request to `url`, like `Authorization`.",
+				ElementType:         basetypes.StringType{},
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"file": schema.StringAttribute{
+				MarkdownDescription: "File to read the revision from.",
+				Optional:            true,
+			},
+			"value": schema.StringAttribute{
+				MarkdownDescription: "The revision, with surrounding whitespace trimmed.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *RevisionDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data RevisionDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.URL.IsNull() == data.File.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("url"), "Invalid Revision Source", "Exactly one of url or file must be set.")
+	}
+	if !data.RequestHeaders.IsNull() && data.URL.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("request_headers"), "Missing URL", "request_headers can only be set with url.")
+	}
+}
+
+func (d *RevisionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data RevisionDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var headers map[string]string
+	resp.Diagnostics.Append(data.RequestHeaders.ElementsAs(ctx, &headers, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	value, err := readRevision(ctx, http.DefaultClient, data.URL.ValueString(), headers, data.File.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+	data.Value = types.StringValue(value)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
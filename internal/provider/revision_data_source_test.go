@@ -0,0 +1,24 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccRevisionDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+data "pteraform_revision" "this" {
+	file = "testdata/revision/revision"
+}
+`,
+			Check: resource.TestCheckResourceAttr("data.pteraform_revision.this", "value", "v1.2.3"),
+		}},
+	})
+}
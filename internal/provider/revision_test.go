@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadRevision(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/private" && r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/large":
+			w.Write([]byte(strings.Repeat("x", maxRevisionSize+1)))
+		default:
+			w.Write([]byte("v1.2.3\n"))
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	if got, err := readRevision(ctx, srv.Client(), srv.URL+"/version", nil, ""); err != nil || got != "v1.2.3" {
+		t.Errorf("readRevision(url) = %q, %v, want %q", got, err, "v1.2.3")
+	}
+	if _, err := readRevision(ctx, srv.Client(), srv.URL+"/private", nil, ""); err == nil {
+		t.Error("readRevision(unauthorized): expected error")
+	}
+	if got, err := readRevision(ctx, srv.Client(), srv.URL+"/private", map[string]string{"Authorization": "Bearer token"}, ""); err != nil || got != "v1.2.3" {
+		t.Errorf("readRevision(authorized) = %q, %v, want %q", got, err, "v1.2.3")
+	}
+	if _, err := readRevision(ctx, srv.Client(), srv.URL+"/large", nil, ""); err == nil {
+		t.Error("readRevision(large): expected error")
+	}
+
+	dir := writeFiles(t, map[string]string{"revision": "  abc123\n"})
+	if got, err := readRevision(ctx, nil, "", nil, filepath.Join(dir, "revision")); err != nil || got != "abc123" {
+		t.Errorf("readRevision(file) = %q, %v, want %q", got, err, "abc123")
+	}
+	if _, err := readRevision(ctx, nil, "", nil, filepath.Join(dir, "missing")); err == nil {
+		t.Error("readRevision(missing file): expected error")
+	}
+}
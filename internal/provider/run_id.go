@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// runIDHeader is the header event sink requests carry the run ID in.
+const runIDHeader = "X-Pteraform-Run-Id"
+
+// newRunID returns a new ID for one nested operation, and ctx with it added
+// to the fields of everything logged with it, so the operation's logs can be
+// correlated with its user agent, attestation and events.
+func newRunID(ctx context.Context) (context.Context, string, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return ctx, "", fmt.Errorf("Unable to generate run ID, got error: %s", err)
+	}
+	return tflog.SetField(ctx, "pteraform_run_id", id), id, nil
+}
+
+// userAgentEnv returns the TF_APPEND_USER_AGENT variable for a nested run,
+// which providers append to the user agent of their API requests, adding
+// the provider's user_agent_suffix and the run ID, if either is set, to
+// anything already in the provider's own TF_APPEND_USER_AGENT, looked up
+// with getenv. It returns nil if there's nothing to add.
+func userAgentEnv(getenv func(string) string, suffix, runID string) []string {
+	suffix = strings.TrimSpace(suffix)
+	if suffix == "" && runID == "" {
+		return nil
+	}
+	parts := []string{}
+	if ua := strings.TrimSpace(getenv("TF_APPEND_USER_AGENT")); ua != "" {
+		parts = append(parts, ua)
+	}
+	if suffix != "" {
+		parts = append(parts, suffix)
+	}
+	if runID != "" {
+		parts = append(parts, "pteraform-run/"+runID)
+	}
+	return []string{"TF_APPEND_USER_AGENT=" + strings.Join(parts, " ")}
+}
+
+// runEnv returns the environment variables set for every nested run in the
+// nested operation runID: those pointing it at temp_dir, and its user
+// agent. It may be called on a nil providerData.
+func (pd *providerData) runEnv(runID string) []string {
+	var suffix string
+	if pd != nil {
+		suffix = pd.UserAgentSuffix
+	}
+	return append(tempDirEnv(pd.tempDir()), userAgentEnv(os.Getenv, suffix, runID)...)
+}
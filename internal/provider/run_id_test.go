@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewRunID(t *testing.T) {
+	_, a, err := newRunID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, b, err := newRunID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 36 || a == b {
+		t.Errorf("newRunID = %q, then %q, want distinct UUIDs", a, b)
+	}
+}
+
+func TestUserAgentEnv(t *testing.T) {
+	for _, c := range []struct {
+		inherited, suffix, runID string
+		want                     []string
+	}{
+		{runID: "1234", want: []string{"TF_APPEND_USER_AGENT=pteraform-run/1234"}},
+		{inherited: " ci/build ", runID: "1234", want: []string{"TF_APPEND_USER_AGENT=ci/build pteraform-run/1234"}},
+		{inherited: "ci/build", suffix: "outer/prod", runID: "1234", want: []string{"TF_APPEND_USER_AGENT=ci/build outer/prod pteraform-run/1234"}},
+		{suffix: "outer/prod", want: []string{"TF_APPEND_USER_AGENT=outer/prod"}},
+		{inherited: "ci/build"},
+	} {
+		getenv := func(string) string { return c.inherited }
+		if diff := cmp.Diff(c.want, userAgentEnv(getenv, c.suffix, c.runID)); diff != "" {
+			t.Errorf("userAgentEnv(%q, %q) with %q inherited (-want +got):\n%s", c.suffix, c.runID, c.inherited, diff)
+		}
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	t.Setenv("TF_APPEND_USER_AGENT", "")
+	pd := &providerData{TempDir: "/scratch", UserAgentSuffix: "outer/prod"}
+	want := []string{"TMPDIR=/scratch", "TMP=/scratch", "TEMP=/scratch", "TF_APPEND_USER_AGENT=outer/prod pteraform-run/1234"}
+	if diff := cmp.Diff(want, pd.runEnv("1234")); diff != "" {
+		t.Errorf("runEnv (-want +got):\n%s", diff)
+	}
+	var unconfigured *providerData
+	if diff := cmp.Diff([]string{"TF_APPEND_USER_AGENT=pteraform-run/1234"}, unconfigured.runEnv("1234")); diff != "" {
+		t.Errorf("runEnv of an unconfigured provider (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// applyFingerprintKey is the private state key the fingerprint of the inputs
+// of the last successful apply is stored under, if skip_unchanged is set.
+const applyFingerprintKey = "apply_fingerprint"
+
+// sourceFile is a file of a nested configuration, with its path relative
+// to the configuration's directory.
+type sourceFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// sourceFiles returns the files of the nested configuration in dir, in path
+// order, ignoring the .terraform directory, local state and the .git
+// directory, which change without the configuration changing.
+func sourceFiles(dir string) ([]sourceFile, error) {
+	files := []sourceFile{}
+	err := filepath.WalkDir(dir, func(fn string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && fn != dir {
+			switch d.Name() {
+			case ".git", ".terraform", "terraform.tfstate.d":
+				return filepath.SkipDir
+			}
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".tfstate") || strings.HasSuffix(d.Name(), ".tfstate.backup") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, fn)
+		if err != nil {
+			return err
+		}
+		digest, err := fileDigest(fn)
+		if err != nil {
+			return err
+		}
+		files = append(files, sourceFile{Path: filepath.ToSlash(rel), SHA256: digest})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// filesDigest returns the hex-encoded SHA-256 digest of files, their paths
+// and digests.
+func filesDigest(files []sourceFile) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s  %s\n", f.SHA256, f.Path)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// sourceDigest returns the hex-encoded SHA-256 digest of the nested
+// configuration in dir, the paths and contents of its files, as listed by
+// sourceFiles.
+func sourceDigest(dir string) (string, error) {
+	files, err := sourceFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	return filesDigest(files), nil
+}
+
+// applyFingerprint returns a digest of everything that determines what an
+// apply of m does: the arguments and variables passed to terraform, and the
+// contents of the variable definitions files they name, the backend
+// configuration and its files, the workspace, terraform_version, the
+// triggers, environment, generated_provider_config, override_files, the
+// variables templating references, the dependency lock file and the nested
+// configuration itself.
+func (r *ApplyResource) applyFingerprint(ctx context.Context, m *ApplyResourceModel) (string, error) {
+	var args []string
+	if diags := m.Args.ElementsAs(ctx, &args, false); diags.HasError() {
+		return "", fmt.Errorf("errors getting args: %v", diags.Errors())
+	}
+	args, err := r.nestedArgs(ctx, m, args)
+	if err != nil {
+		return "", err
+	}
+	var triggers map[string]string
+	if diags := m.Triggers.ElementsAs(ctx, &triggers, false); diags.HasError() {
+		return "", fmt.Errorf("errors getting triggers: %v", diags.Errors())
+	}
+	names := make([]string, 0, len(triggers))
+	for k := range triggers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var overrides map[string]string
+	if diags := m.OverrideFiles.ElementsAs(ctx, &overrides, false); diags.HasError() {
+		return "", fmt.Errorf("errors getting override_files: %v", diags.Errors())
+	}
+	overrideNames := make([]string, 0, len(overrides))
+	for k := range overrides {
+		overrideNames = append(overrideNames, k)
+	}
+	sort.Strings(overrideNames)
+
+	dir := m.WorkingDir.ValueString()
+	lock, err := lockFileDigest(dir)
+	if err != nil {
+		return "", err
+	}
+	source, err := sourceDigest(dir)
+	if err != nil {
+		return "", err
+	}
+	templates, err := m.Templating.inputsDigest(ctx, dir, r.nestedEnviron(m))
+	if err != nil {
+		return "", err
+	}
+	varFiles, err := varFilesDigest(dir, args)
+	if err != nil {
+		return "", err
+	}
+	backend, err := m.backendDigest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, a := range args {
+		fmt.Fprintf(h, "arg %s\x00", a)
+	}
+	if varFiles != "" {
+		fmt.Fprintf(h, "var files %s\x00", varFiles)
+	}
+	if backend != "" {
+		fmt.Fprintf(h, "backend %s\x00", backend)
+	}
+	fmt.Fprintf(h, "workspace %s\x00terraform %s\x00", m.WorkspaceName.ValueString(), m.TerraformVersion.ValueString())
+	for _, k := range names {
+		fmt.Fprintf(h, "trigger %s=%s\x00", k, triggers[k])
+	}
+	// The override file written for generated_provider_config is part of the
+	// source, but only after the apply that writes it.
+	for _, kv := range m.environmentEnv() {
+		fmt.Fprintf(h, "env %s\x00", kv)
+	}
+	fmt.Fprintf(h, "providers %s\x00", m.ProviderConfig.ValueString())
+	// override_files are removed after each apply.
+	for _, k := range overrideNames {
+		fmt.Fprintf(h, "override %s=%s\x00", k, overrides[k])
+	}
+	// Templates are rendered after the fingerprint is compared, so the
+	// variables they reference are hashed instead of the rendered files.
+	if m.Templating != nil {
+		fmt.Fprintf(h, "templates %s\x00", templates)
+	}
+	fmt.Fprintf(h, "lock %s\x00source %s\x00", lock, source)
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// unchanged reports whether the fingerprint of m matches that of the last
+// successful apply, so the apply can be skipped. It's never unchanged if
+// the last refresh found verify_outputs changed, since applying again is
+// what fixes them.
+func (r *ApplyResource) unchanged(ctx context.Context, m *ApplyResourceModel, prior privateStateReader) (bool, diag.Diagnostics) {
+	if !m.SkipUnchanged.ValueBool() {
+		return false, nil
+	}
+	b, diags := prior.GetKey(ctx, applyFingerprintKey)
+	var recorded string
+	if diags.HasError() || len(b) == 0 || json.Unmarshal(b, &recorded) != nil || recorded == "" {
+		return false, diags
+	}
+	drift, d := prior.GetKey(ctx, outputDriftKey)
+	diags.Append(d...)
+	var changed []string
+	if len(drift) > 0 && json.Unmarshal(drift, &changed) == nil && len(changed) > 0 {
+		return false, diags
+	}
+	now, err := r.applyFingerprint(ctx, m)
+	if err != nil {
+		diags.AddWarning("Unable to Fingerprint Nested Configuration", fmt.Sprintf("Applying it anyway: %s", err))
+		return false, diags
+	}
+	return now == recorded, diags
+}
+
+// recordFingerprint stores the fingerprint of m after an apply, or clears it
+// if the apply failed or skip_unchanged isn't set, so the next update isn't
+// skipped.
+func (r *ApplyResource) recordFingerprint(ctx context.Context, m *ApplyResourceModel, private privateState, applyErr error) diag.Diagnostics {
+	if applyErr != nil || !m.SkipUnchanged.ValueBool() {
+		return private.SetKey(ctx, applyFingerprintKey, []byte("null"))
+	}
+	var diags diag.Diagnostics
+	fingerprint, err := r.applyFingerprint(ctx, m)
+	if err != nil {
+		diags.AddWarning("Unable to Fingerprint Nested Configuration", fmt.Sprintf("The next update will apply it even if it's unchanged: %s", err))
+		diags.Append(private.SetKey(ctx, applyFingerprintKey, []byte("null"))...)
+		return diags
+	}
+	b, err := json.Marshal(fingerprint)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record apply fingerprint, got error: %s", err))
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, applyFingerprintKey, b)...)
+	return diags
+}
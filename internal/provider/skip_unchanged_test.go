@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestSourceDigest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": "a", "modules/vpc/main.tf": "b"})
+	before, err := sourceDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{
+		".terraform/modules/modules.json":               "{}",
+		"terraform.tfstate":                             "{}",
+		"terraform.tfstate.backup":                      "{}",
+		"terraform.tfstate.d/staging/terraform.tfstate": "{}",
+	} {
+		fn := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := sourceDigest(dir); err != nil || got != before {
+		t.Errorf("sourceDigest with state = %s, %v, want %s", got, err, before)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "modules", "vpc", "main.tf"), []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sourceDigest(dir); err != nil || got == before {
+		t.Errorf("sourceDigest after change = %s, %v, want a different digest", got, err)
+	}
+}
+
+func TestApplyFingerprint(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	r := &ApplyResource{}
+	m := testApplyModel(dir)
+	before, err := r.applyFingerprint(ctx, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.applyFingerprint(ctx, &m); err != nil || got != before {
+		t.Errorf("applyFingerprint again = %s, %v, want %s", got, err, before)
+	}
+
+	for desc, modify := range map[string]func(m *ApplyResourceModel){
+		"variables": func(m *ApplyResourceModel) {
+			m.Variables = types.MapValueMust(types.StringType, map[string]attr.Value{"region": types.StringValue("us-east-1")})
+		},
+		"triggers": func(m *ApplyResourceModel) {
+			m.Triggers = types.MapValueMust(types.StringType, map[string]attr.Value{"version": types.StringValue("2")})
+		},
+		"backend_config": func(m *ApplyResourceModel) {
+			m.BackendConfig = types.MapValueMust(types.StringType, map[string]attr.Value{"key": types.StringValue("network/terraform.tfstate")})
+		},
+		"workspace":     func(m *ApplyResourceModel) { m.WorkspaceName = types.StringValue("staging") },
+		"desired_state": func(m *ApplyResourceModel) { m.DesiredState = types.StringValue("absent") },
+		"lock file": func(m *ApplyResourceModel) {
+			if err := os.WriteFile(filepath.Join(dir, lockFileName), []byte("# locked"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		},
+	} {
+		m := testApplyModel(dir)
+		modify(&m)
+		if got, err := r.applyFingerprint(ctx, &m); err != nil || got == before {
+			t.Errorf("applyFingerprint with %s = %s, %v, want a different fingerprint", desc, got, err)
+		}
+		os.Remove(filepath.Join(dir, lockFileName))
+	}
+}
+
+func TestApplyFingerprintTemplates(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"backend.tf.tmpl": `bucket = "$BUCKET"`})
+	r := &ApplyResource{}
+	m := testApplyModel(dir)
+	m.Templating = &ApplyTemplatingModel{Engine: types.StringNull(), Files: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("backend.tf.tmpl")})}
+	t.Setenv("BUCKET", "state")
+	before, err := r.applyFingerprint(ctx, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUCKET", "rotated")
+	if got, err := r.applyFingerprint(ctx, &m); err != nil || got == before {
+		t.Errorf("applyFingerprint after the templated variable changed = %s, %v, want a different fingerprint", got, err)
+	}
+}
+
+func TestApplyFingerprintVarFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"main.tf": `variable "region" {}`})
+	envs := writeFiles(t, map[string]string{"prod.tfvars": `region = "us-east-1"`})
+	r := &ApplyResource{}
+	m := testApplyModel(dir)
+	m.VarFiles = types.ListValueMust(types.StringType, []attr.Value{types.StringValue(filepath.Join(envs, "prod.tfvars"))})
+	before, err := r.applyFingerprint(ctx, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := r.inputManifest(ctx, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(envs, "prod.tfvars"), []byte(`region = "us-west-2"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.applyFingerprint(ctx, &m); err != nil || got == before {
+		t.Errorf("applyFingerprint after the var file changed = %s, %v, want a different fingerprint", got, err)
+	}
+	if got, err := r.inputManifest(ctx, &m); err != nil || got == manifest {
+		t.Errorf("inputManifest after the var file changed = %v, want a different manifest", err)
+	}
+}
+
+// fakePrivateState is private state kept in memory.
+type fakePrivateState map[string][]byte
+
+func (p fakePrivateState) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func (p fakePrivateState) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	p[key] = value
+	return nil
+}
+
+func TestUnchangedBackendConfig(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"main.tf": "", "prod.tfbackend": `bucket = "state"`})
+	r := &ApplyResource{}
+	m := testApplyModel(dir)
+	m.SkipUnchanged = types.BoolValue(true)
+	m.BackendConfigFiles = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("prod.tfbackend")})
+	private := fakePrivateState{}
+	if diags := r.recordFingerprint(ctx, &m, private, nil); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if skipped, diags := r.unchanged(ctx, &m, private); diags.HasError() || !skipped {
+		t.Fatalf("unchanged() = %t, %v, want true", skipped, diags)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "prod.tfbackend"), []byte(`bucket = "moved"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if skipped, diags := r.unchanged(ctx, &m, private); diags.HasError() || skipped {
+		t.Errorf("unchanged() after the backend configuration file changed = %t, %v, want false", skipped, diags)
+	}
+	if diags := r.recordFingerprint(ctx, &m, private, nil); diags.HasError() {
+		t.Fatal(diags)
+	}
+	m.BackendConfig = types.MapValueMust(types.StringType, map[string]attr.Value{"key": types.StringValue("network/terraform.tfstate")})
+	if skipped, diags := r.unchanged(ctx, &m, private); diags.HasError() || skipped {
+		t.Errorf("unchanged() after backend_config changed = %t, %v, want false", skipped, diags)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ApplyCheckModel describes an entry in checks.
+type ApplyCheckModel struct {
+	Command     types.String `tfsdk:"command"`
+	Interpreter types.List   `tfsdk:"interpreter"`
+}
+
+var applyCheckAttrTypes = map[string]attr.Type{
+	"command":     types.StringType,
+	"interpreter": types.ListType{ElemType: types.StringType},
+}
+
+// smokeCheck is a command run after a successful apply to check that the
+// nested stack is healthy.
+type smokeCheck struct {
+	Command     string
+	Interpreter []string
+}
+
+// defaultInterpreter returns the interpreter checks are run with on goos if
+// none is given.
+func defaultInterpreter(goos string) []string {
+	if goos == "windows" {
+		return []string{"cmd", "/C"}
+	}
+	return []string{"/bin/sh", "-c"}
+}
+
+// outputEnv returns environment variables exposing the nested outputs, as
+// returned by stateOutputs: PTERAFORM_OUTPUT_<name> for each output, which
+// is the value of strings and JSON-encoded otherwise, and PTERAFORM_OUTPUTS
+// with all of them as a JSON object.
+func outputEnv(outputs map[string]string) []string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	all := map[string]json.RawMessage{}
+	var env []string
+	for _, name := range names {
+		v := outputs[name]
+		all[name] = json.RawMessage(v)
+		var s string
+		if err := json.Unmarshal([]byte(v), &s); err == nil {
+			v = s
+		}
+		env = append(env, fmt.Sprintf("PTERAFORM_OUTPUT_%s=%s", name, v))
+	}
+	b, _ := json.Marshal(all)
+	return append(env, "PTERAFORM_OUTPUTS="+string(b))
+}
+
+// run runs the check in dir with env added to the environment. If it fails
+// with long output, the output is saved in logDir with permissions mode.
+func (c smokeCheck) run(ctx context.Context, dir string, env []string, logDir string, mode os.FileMode) error {
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Interpreter[0], append(c.Interpreter[1:], c.Command)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	if err := cmd.Run(); err != nil {
+		out := normalizeOutput(buf.String())
+		return fmt.Errorf("check %q failed, got error: %s, output: %s", c.Command, err, truncateOutput(out, saveOutput(logDir, "check", out, mode)))
+	}
+	return nil
+}
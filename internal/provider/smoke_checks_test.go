@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOutputEnv(t *testing.T) {
+	got := outputEnv(map[string]string{
+		"url":   `"https://example.com"`,
+		"ports": `[80,443]`,
+	})
+	want := []string{
+		"PTERAFORM_OUTPUT_ports=[80,443]",
+		"PTERAFORM_OUTPUT_url=https://example.com",
+		`PTERAFORM_OUTPUTS={"ports":[80,443],"url":"https://example.com"}`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("outputEnv (-want,+got): %s", diff)
+	}
+}
+
+func TestSmokeCheckRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks use /bin/sh")
+	}
+	env := outputEnv(map[string]string{"url": `"https://example.com"`})
+	for command, ok := range map[string]bool{
+		`test "$PTERAFORM_OUTPUT_url" = https://example.com`: true,
+		`test "$PTERAFORM_OUTPUT_url" = https://example.org`: false,
+		"exit 1": false,
+	} {
+		c := smokeCheck{Command: command, Interpreter: defaultInterpreter(runtime.GOOS)}
+		if err := c.run(context.Background(), t.TempDir(), env, t.TempDir(), 0); (err == nil) != ok {
+			t.Errorf("run(%q) = %v, want ok %t", command, err, ok)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ApplyStageModel describes a stage of a staged apply.
+type ApplyStageModel struct {
+	Name      types.String `tfsdk:"name"`
+	Targets   types.List   `tfsdk:"targets"`
+	OnFailure types.String `tfsdk:"on_failure"`
+}
+
+var applyStageAttrTypes = map[string]attr.Type{
+	"name":       types.StringType,
+	"targets":    types.ListType{ElemType: types.StringType},
+	"on_failure": types.StringType,
+}
+
+// ApplyStageResultModel describes the outcome of a stage.
+type ApplyStageResultModel struct {
+	Name   types.String `tfsdk:"name"`
+	Status types.String `tfsdk:"status"`
+	Error  types.String `tfsdk:"error"`
+}
+
+var applyStageResultAttrTypes = map[string]attr.Type{
+	"name":   types.StringType,
+	"status": types.StringType,
+	"error":  types.StringType,
+}
+
+// Stage statuses.
+const (
+	stageSucceeded = "succeeded"
+	stageFailed    = "failed"
+	stageSkipped   = "skipped"
+)
+
+// stage is a targeted apply run before the full apply.
+type stage struct {
+	name    string
+	targets []string
+	// abort is whether a failure stops the later stages.
+	abort bool
+}
+
+// stageResult is the outcome of a stage.
+type stageResult struct {
+	name   string
+	status string
+	err    error
+}
+
+// stages returns the stages described by m.
+func stages(ctx context.Context, m types.List) ([]stage, error) {
+	var models []ApplyStageModel
+	if diags := m.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting stages: %v", diags.Errors())
+	}
+	var out []stage
+	for _, sm := range models {
+		s := stage{name: sm.Name.ValueString(), abort: sm.OnFailure.ValueString() != "continue"}
+		if diags := sm.Targets.ElementsAs(ctx, &s.targets, false); diags.HasError() {
+			return nil, fmt.Errorf("errors getting stages: %v", diags.Errors())
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// runStages applies each of stages in order with apply, which is passed
+// the -target arguments of the stage. Later stages are skipped after a
+// stage that aborts on failure fails. The returned error, if any, names
+// the stages that failed and wraps the first failure.
+func runStages(stages []stage, apply func(args ...string) (string, error)) ([]stageResult, error) {
+	results := make([]stageResult, len(stages))
+	var failed []string
+	var first error
+	aborted := false
+	for i, s := range stages {
+		results[i].name = s.name
+		if aborted {
+			results[i].status = stageSkipped
+			continue
+		}
+		args := make([]string, len(s.targets))
+		for j, t := range s.targets {
+			args[j] = "-target=" + t
+		}
+		if _, err := apply(args...); err != nil {
+			results[i].status, results[i].err = stageFailed, err
+			failed = append(failed, s.name)
+			if first == nil {
+				first = err
+			}
+			aborted = s.abort
+			continue
+		}
+		results[i].status = stageSucceeded
+	}
+	if first != nil {
+		return results, fmt.Errorf("stages %s failed, so the full apply was skipped: %w", strings.Join(failed, ", "), first)
+	}
+	return results, nil
+}
+
+// stageResultsValue returns the stage_results attribute for results.
+func stageResultsValue(ctx context.Context, results []stageResult) (types.List, diag.Diagnostics) {
+	models := make([]ApplyStageResultModel, len(results))
+	for i, r := range results {
+		models[i] = ApplyStageResultModel{Name: types.StringValue(r.name), Status: types.StringValue(r.status), Error: types.StringNull()}
+		if r.err != nil {
+			models[i].Error = types.StringValue(r.err.Error())
+		}
+	}
+	return types.ListValueFrom(ctx, types.ObjectType{AttrTypes: applyStageResultAttrTypes}, models)
+}
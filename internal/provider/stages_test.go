@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRunStages(t *testing.T) {
+	ss := []stage{
+		{name: "network", targets: []string{"module.network"}, abort: true},
+		{name: "dns", targets: []string{"module.dns"}},
+		{name: "db", targets: []string{"module.db", "aws_iam_role.db"}, abort: true},
+		{name: "app", targets: []string{"module.app"}},
+	}
+	for _, c := range []struct {
+		desc    string
+		fail    string
+		want    []string
+		wantErr string
+	}{{
+		desc: "success",
+		want: []string{"network succeeded", "dns succeeded", "db succeeded", "app succeeded"},
+	}, {
+		desc:    "continue",
+		fail:    "-target=module.dns",
+		want:    []string{"network succeeded", "dns failed", "db succeeded", "app succeeded"},
+		wantErr: "stages dns failed",
+	}, {
+		desc:    "abort",
+		fail:    "-target=module.db -target=aws_iam_role.db",
+		want:    []string{"network succeeded", "dns succeeded", "db failed", "app skipped"},
+		wantErr: "stages db failed",
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			results, err := runStages(ss, func(args ...string) (string, error) {
+				if strings.Join(args, " ") == c.fail {
+					return "", errors.New("boom")
+				}
+				return "", nil
+			})
+			var got []string
+			for _, r := range results {
+				got = append(got, r.name+" "+r.status)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("results (-want,+got): %s", diff)
+			}
+			if c.wantErr == "" && err != nil {
+				t.Fatal(err)
+			} else if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+				t.Errorf("got error %v, want %q", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestDoApplyStages(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	m := testApplyModel(dir)
+	m.Stages = types.ListValueMust(types.ObjectType{AttrTypes: applyStageAttrTypes}, []attr.Value{
+		types.ObjectValueMust(applyStageAttrTypes, map[string]attr.Value{
+			"name":       types.StringValue("network"),
+			"targets":    types.ListValueMust(types.StringType, []attr.Value{types.StringValue("module.network")}),
+			"on_failure": types.StringNull(),
+		}),
+	})
+
+	fake := &fakeRunner{}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	if _, _, err := r.doApply(context.Background(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"init", "apply -auto-approve -target=module.network", "apply -auto-approve"}, fake.commands); diff != "" {
+		t.Errorf("commands (-want,+got): %s", diff)
+	}
+	var results []ApplyStageResultModel
+	if diags := m.StageResults.ElementsAs(context.Background(), &results, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if len(results) != 1 || results[0].Status.ValueString() != stageSucceeded || !results[0].Error.IsNull() {
+		t.Errorf("got stage_results %v, want network to have succeeded", results)
+	}
+}
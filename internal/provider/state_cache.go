@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// stateCache remembers the digests and parsed headers of state files, so
+// that resources refreshed at once don't each read the same large files
+// again. Entries are keyed by path, and only used while the file's
+// modification time and size are unchanged. A nil *stateCache caches
+// nothing.
+type stateCache struct {
+	mu      sync.Mutex
+	entries map[string]stateCacheEntry
+}
+
+type stateCacheEntry struct {
+	modTime time.Time
+	size    int64
+
+	// digest and header are set once they've been read.
+	digest string
+	header *stateHeader
+}
+
+func newStateCache() *stateCache {
+	return &stateCache{entries: map[string]stateCacheEntry{}}
+}
+
+// states returns the provider's state cache, or nil if cache_state_reads
+// isn't set.
+func (pd *providerData) states() *stateCache {
+	if pd == nil {
+		return nil
+	}
+	return pd.StateCache
+}
+
+// lookup returns the entry for fn, its absolute path, and fn's current
+// modification time and size in a new entry if the cached one is stale.
+func (c *stateCache) lookup(fn string) (string, stateCacheEntry, error) {
+	abs, err := filepath.Abs(fn)
+	if err != nil {
+		return "", stateCacheEntry{}, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return "", stateCacheEntry{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[abs]; ok && e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+		return abs, e, nil
+	}
+	return abs, stateCacheEntry{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// store records e for abs, unless a newer version of the file was cached
+// in the meantime.
+func (c *stateCache) store(abs string, e stateCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[abs]; ok && old.modTime.After(e.modTime) {
+		return
+	}
+	c.entries[abs] = e
+}
+
+// digest returns the hex-encoded SHA-256 digest of the file fn.
+func (c *stateCache) digest(fn string) (string, error) {
+	if c == nil {
+		return fileDigest(fn)
+	}
+	abs, e, err := c.lookup(fn)
+	if err != nil {
+		return "", err
+	}
+	if e.digest == "" {
+		// The file's stat was taken first, so if it changes while it's
+		// read, the entry is stale and read again next time.
+		if e.digest, err = fileDigest(abs); err != nil {
+			return "", err
+		}
+		c.store(abs, e)
+	}
+	return e.digest, nil
+}
+
+// header returns the header of the state file fn, which mustn't be
+// modified.
+func (c *stateCache) header(fn string) (*stateHeader, error) {
+	if c == nil {
+		return readStateHeader(fn)
+	}
+	abs, e, err := c.lookup(fn)
+	if err != nil {
+		return nil, err
+	}
+	if e.header == nil {
+		if e.header, err = readStateHeader(abs); err != nil {
+			return nil, err
+		}
+		c.store(abs, e)
+	}
+	return e.header, nil
+}
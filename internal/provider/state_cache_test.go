@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStateCache(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"terraform.tfstate": `{"version":4,"lineage":"abc"}`})
+	fn := filepath.Join(dir, "terraform.tfstate")
+	want, err := fileDigest(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for desc, c := range map[string]*stateCache{"nil": nil, "cache": newStateCache()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got, err := c.digest(fn); err != nil || got != want {
+					t.Errorf("%s: digest = %s, %v, want %s", desc, got, err, want)
+				}
+			}()
+		}
+		wg.Wait()
+		if s, err := c.header(fn); err != nil || s.Lineage != "abc" {
+			t.Errorf("%s: header = %+v, %v, want lineage abc", desc, s, err)
+		}
+	}
+
+	c := newStateCache()
+	if _, err := c.digest(fn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.header(fn); err != nil {
+		t.Fatal(err)
+	}
+	// Rewrite it with the same size and modification time, which isn't
+	// noticed.
+	fi, err := os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fn, []byte(`{"version":4,"lineage":"xyz"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(fn, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.digest(fn); err != nil || got != want {
+		t.Errorf("digest of unchanged stat = %s, %v, want cached %s", got, err, want)
+	}
+
+	later := fi.ModTime().Add(time.Second)
+	if err := os.Chtimes(fn, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.digest(fn); err != nil || got == want {
+		t.Errorf("digest after change = %s, %v, want a new digest", got, err)
+	}
+	if s, err := c.header(fn); err != nil || s.Lineage != "xyz" {
+		t.Errorf("header after change = %+v, %v, want lineage xyz", s, err)
+	}
+
+	if _, err := c.digest(filepath.Join(dir, "missing.tfstate")); !os.IsNotExist(err) {
+		t.Errorf("digest of missing file: got %v, want not exist", err)
+	}
+}
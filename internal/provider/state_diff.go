@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// stateSnapshot is the part of a state file, in format version 4, that
+// snapshots are compared by.
+type stateSnapshot struct {
+	Version   int    `json:"version"`
+	Serial    int64  `json:"serial"`
+	Lineage   string `json:"lineage"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{} `json:"index_key"`
+			Attributes interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// readStateSnapshot reads the state file fn.
+func readStateSnapshot(fn string) (*stateSnapshot, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var s stateSnapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s, got error: %s", fn, err)
+	}
+	if s.Version != 4 {
+		return nil, fmt.Errorf("%s has unsupported state format version %d", fn, s.Version)
+	}
+	return &s, nil
+}
+
+// instances returns the attributes of each resource instance in s, keyed by
+// address.
+func (s *stateSnapshot) instances() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, r := range s.Resources {
+		addr := r.Type + "." + r.Name
+		if r.Mode == "data" {
+			addr = "data." + addr
+		}
+		if r.Module != "" {
+			addr = r.Module + "." + addr
+		}
+		for _, i := range r.Instances {
+			key := addr
+			switch k := i.IndexKey.(type) {
+			case string:
+				key += fmt.Sprintf("[%q]", k)
+			case float64:
+				key += fmt.Sprintf("[%d]", int64(k))
+			}
+			out[key] = i.Attributes
+		}
+	}
+	return out
+}
+
+// stateDiff is how the resource instances in one state snapshot differ from
+// those in another, by address.
+type stateDiff struct {
+	Added, Removed, Changed []string
+}
+
+// diffStates compares the instances in to with those in from.
+func diffStates(from, to *stateSnapshot) stateDiff {
+	before, after := from.instances(), to.instances()
+	d := stateDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for addr, attrs := range after {
+		if was, ok := before[addr]; !ok {
+			d.Added = append(d.Added, addr)
+		} else if !reflect.DeepEqual(was, attrs) {
+			d.Changed = append(d.Changed, addr)
+		}
+	}
+	for addr := range before {
+		if _, ok := after[addr]; !ok {
+			d.Removed = append(d.Removed, addr)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// stateBackups returns the backups of the state file fn, newest first: the
+// .backup terraform writes before each change, and the timestamped backups
+// state subcommands like terraform state mv write.
+func stateBackups(fn string) ([]string, error) {
+	matches, err := filepath.Glob(fn + ".*backup")
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		fn    string
+		mtime int64
+	}
+	var backups []backup
+	for _, m := range matches {
+		rest := strings.TrimPrefix(m, fn)
+		if rest != ".backup" && !strings.HasSuffix(rest, ".backup") {
+			continue
+		}
+		fi, err := os.Stat(m)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, backup{m, fi.ModTime().UnixNano()})
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].mtime != backups[j].mtime {
+			return backups[i].mtime > backups[j].mtime
+		}
+		return backups[i].fn > backups[j].fn
+	})
+	out := make([]string, len(backups))
+	for i, b := range backups {
+		out[i] = b.fn
+	}
+	return out, nil
+}
+
+// snapshotPath returns the state file to compare: path, relative to dir, if
+// it's set; otherwise the backup'th most recent backup of the state of
+// workspace in dir, if backup is positive; otherwise that state itself.
+func snapshotPath(dir, workspace, path string, backup int64) (string, error) {
+	if path != "" {
+		if filepath.IsAbs(path) {
+			return path, nil
+		}
+		return filepath.Join(dir, path), nil
+	}
+	fn := filepath.Join(dir, statePath(workspace))
+	if backup <= 0 {
+		return fn, nil
+	}
+	backups, err := stateBackups(fn)
+	if err != nil {
+		return "", err
+	}
+	if int(backup) > len(backups) {
+		return "", fmt.Errorf("backup %d requested, but %s has %d backups", backup, fn, len(backups))
+	}
+	return backups[backup-1], nil
+}
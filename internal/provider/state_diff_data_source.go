@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &StateDiffDataSource{}
+var _ datasource.DataSourceWithValidateConfig = &StateDiffDataSource{}
+
+func NewStateDiffDataSource() datasource.DataSource {
+	return &StateDiffDataSource{}
+}
+
+// StateDiffDataSource defines the data source implementation.
+type StateDiffDataSource struct{}
+
+// StateDiffDataSourceModel describes the data source data model.
+type StateDiffDataSourceModel struct {
+	WorkingDir types.String `tfsdk:"working_dir"`
+	Workspace  types.String `tfsdk:"workspace"`
+	From       types.String `tfsdk:"from"`
+	FromBackup types.Int64  `tfsdk:"from_backup"`
+	To         types.String `tfsdk:"to"`
+	ToBackup   types.Int64  `tfsdk:"to_backup"`
+	FromSerial types.Int64  `tfsdk:"from_serial"`
+	ToSerial   types.Int64  `tfsdk:"to_serial"`
+	Added      []string     `tfsdk:"added"`
+	Removed    []string     `tfsdk:"removed"`
+	Changed    []string     `tfsdk:"changed"`
+}
+
+func (d *StateDiffDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_state_diff"
+}
+
+func (d *StateDiffDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Compares two snapshots of a nested configuration's local state, such as its current state and a backup terraform wrote before the last change, without running `terraform`. Each snapshot is given by a path or by a backup index; the default is the current state.",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory of the nested configuration.",
+				Required:            true,
+			},
+			"workspace": schema.StringAttribute{
+				MarkdownDescription: "Workspace whose state and backups are compared. Defaults to `default`.",
+				Optional:            true,
+			},
+			"from": schema.StringAttribute{
+				MarkdownDescription: "Path of the state file to compare from, relative to `working_dir`.",
+				Optional:            true,
+			},
+			"from_backup": schema.Int64Attribute{
+				MarkdownDescription: "Compare from a backup of the state instead: 1 for the most recent, like the `terraform.tfstate.backup` written by the last apply, 2 for the one before, and so on. Conflicts with `from`.",
+				Optional:            true,
+			},
+			"to": schema.StringAttribute{
+				MarkdownDescription: "Path of the state file to compare to, relative to `working_dir`.",
+				Optional:            true,
+			},
+			"to_backup": schema.Int64Attribute{
+				MarkdownDescription: "Compare to a backup of the state instead, like `from_backup`. Conflicts with `to`.",
+				Optional:            true,
+			},
+			"from_serial": schema.Int64Attribute{
+				MarkdownDescription: "Serial number of the snapshot compared from.",
+				Computed:            true,
+			},
+			"to_serial": schema.Int64Attribute{
+				MarkdownDescription: "Serial number of the snapshot compared to.",
+				Computed:            true,
+			},
+			"added": schema.ListAttribute{
+				MarkdownDescription: "Addresses of resource instances in `to` but not `from`.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+			"removed": schema.ListAttribute{
+				MarkdownDescription: "Addresses of resource instances in `from` but not `to`.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+			"changed": schema.ListAttribute{
+				MarkdownDescription: "Addresses of resource instances in both whose attributes differ.",
+				ElementType:         basetypes.StringType{},
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *StateDiffDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var data StateDiffDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, s := range []struct {
+		name   string
+		path   types.String
+		backup types.Int64
+	}{{"from", data.From, data.FromBackup}, {"to", data.To, data.ToBackup}} {
+		if !s.path.IsNull() && !s.backup.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root(s.name+"_backup"), "Conflicting Snapshots", fmt.Sprintf("Only one of %s and %s_backup can be set.", s.name, s.name))
+		}
+		if b := s.backup; !b.IsNull() && !b.IsUnknown() && b.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root(s.name+"_backup"), "Invalid Backup", fmt.Sprintf("%s_backup must be at least 1, got %d.", s.name, b.ValueInt64()))
+		}
+	}
+}
+
+func (d *StateDiffDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data StateDiffDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir, ws := data.WorkingDir.ValueString(), data.Workspace.ValueString()
+	var snapshots [2]*stateSnapshot
+	for i, s := range []struct {
+		path   types.String
+		backup types.Int64
+	}{{data.From, data.FromBackup}, {data.To, data.ToBackup}} {
+		fn, err := snapshotPath(dir, ws, s.path.ValueString(), s.backup.ValueInt64())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to find state snapshot, got error: %s", err))
+			return
+		}
+		if snapshots[i], err = readStateSnapshot(fn); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read state snapshot, got error: %s", err))
+			return
+		}
+	}
+
+	diff := diffStates(snapshots[0], snapshots[1])
+	data.FromSerial = types.Int64Value(snapshots[0].Serial)
+	data.ToSerial = types.Int64Value(snapshots[1].Serial)
+	data.Added, data.Removed, data.Changed = diff.Added, diff.Removed, diff.Changed
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+const (
+	stateBefore = `{"version": 4, "serial": 3, "resources": [
+  {"mode": "managed", "type": "null_resource", "name": "kept", "instances": [{"attributes": {"id": "1"}}]},
+  {"mode": "managed", "type": "null_resource", "name": "gone", "instances": [{"attributes": {"id": "2"}}]},
+  {"module": "module.vpc", "mode": "managed", "type": "aws_subnet", "name": "this", "instances": [
+    {"index_key": 0, "attributes": {"cidr_block": "10.0.0.0/24"}},
+    {"index_key": 1, "attributes": {"cidr_block": "10.0.1.0/24"}}
+  ]}
+]}`
+	stateAfter = `{"version": 4, "serial": 5, "resources": [
+  {"mode": "managed", "type": "null_resource", "name": "kept", "instances": [{"attributes": {"id": "1"}}]},
+  {"mode": "data", "type": "http", "name": "x", "instances": [{"attributes": {"url": "https://example.com"}}]},
+  {"module": "module.vpc", "mode": "managed", "type": "aws_subnet", "name": "this", "instances": [
+    {"index_key": 0, "attributes": {"cidr_block": "10.0.0.0/24"}},
+    {"index_key": 1, "attributes": {"cidr_block": "10.0.2.0/24"}}
+  ]},
+  {"mode": "managed", "type": "aws_iam_user", "name": "u", "instances": [{"index_key": "alice", "attributes": {}}]}
+]}`
+)
+
+func TestDiffStates(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"before.tfstate": stateBefore, "after.tfstate": stateAfter})
+	from, err := readStateSnapshot(filepath.Join(dir, "before.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := readStateSnapshot(filepath.Join(dir, "after.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stateDiff{
+		Added:   []string{`aws_iam_user.u["alice"]`, "data.http.x"},
+		Removed: []string{"null_resource.gone"},
+		Changed: []string{"module.vpc.aws_subnet.this[1]"},
+	}
+	if diff := cmp.Diff(want, diffStates(from, to)); diff != "" {
+		t.Errorf("diffStates (-want +got):\n%s", diff)
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"terraform.tfstate":                         stateAfter,
+		"terraform.tfstate.backup":                  stateBefore,
+		"terraform.tfstate.1697000000.backup":       stateBefore,
+		"terraform.tfstate.d/dev/terraform.tfstate": stateAfter,
+	})
+	older := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "terraform.tfstate.1697000000.backup"), older, older); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		workspace, path string
+		backup          int64
+		want            string
+	}{
+		{want: "terraform.tfstate"},
+		{workspace: "dev", want: "terraform.tfstate.d/dev/terraform.tfstate"},
+		{path: "other.tfstate", want: "other.tfstate"},
+		{backup: 1, want: "terraform.tfstate.backup"},
+		{backup: 2, want: "terraform.tfstate.1697000000.backup"},
+	} {
+		got, err := snapshotPath(dir, c.workspace, c.path, c.backup)
+		if err != nil {
+			t.Errorf("snapshotPath(%q, %q, %d): %v", c.workspace, c.path, c.backup, err)
+		} else if want := filepath.Join(dir, c.want); got != want {
+			t.Errorf("snapshotPath(%q, %q, %d) = %s, want %s", c.workspace, c.path, c.backup, got, want)
+		}
+	}
+	if _, err := snapshotPath(dir, "", "", 3); err == nil {
+		t.Error("snapshotPath for a missing backup succeeded, want error")
+	}
+}
+
+func TestAccStateDiffDataSource(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"terraform.tfstate": stateAfter, "terraform.tfstate.backup": stateBefore})
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: fmt.Sprintf(`
+data "pteraform_state_diff" "this" {
+	working_dir = %q
+	from_backup = 1
+}
+`, dir),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("data.pteraform_state_diff.this", "from_serial", "3"),
+				resource.TestCheckResourceAttr("data.pteraform_state_diff.this", "to_serial", "5"),
+				resource.TestCheckResourceAttr("data.pteraform_state_diff.this", "added.#", "2"),
+				resource.TestCheckResourceAttr("data.pteraform_state_diff.this", "removed.0", "null_resource.gone"),
+				resource.TestCheckResourceAttr("data.pteraform_state_diff.this", "changed.0", "module.vpc.aws_subnet.this[1]"),
+			),
+		}},
+	})
+}
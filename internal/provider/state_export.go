@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ApplyStateExportModel describes the state_export block.
+type ApplyStateExportModel struct {
+	Address  types.String `tfsdk:"address"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+// uploadState uploads state to address the way terraform's http backend
+// does, so terraform_remote_state with the http backend can read it back
+// from the same address.
+func uploadState(ctx context.Context, client *http.Client, m *ApplyStateExportModel, state []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.Address.ValueString(), bytes.NewReader(state))
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(state)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	if !m.Username.IsNull() || !m.Password.IsNull() {
+		req.SetBasicAuth(m.Username.ValueString(), m.Password.ValueString())
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("got status: %s", resp.Status)
+	}
+	return nil
+}
+
+// checkPulledState returns an error if state, as output by terraform state
+// pull, isn't state in format version 4, so nothing else is uploaded in its
+// place.
+func checkPulledState(state string) error {
+	var h stateHeader
+	if err := json.Unmarshal([]byte(state), &h); err != nil {
+		return fmt.Errorf("terraform state pull didn't output state, got error: %s", err)
+	}
+	if h.Version != 4 {
+		return fmt.Errorf("terraform state pull output unsupported state format version %d", h.Version)
+	}
+	return nil
+}
+
+// exportState uploads the nested state to state_export's address after an
+// apply.
+func (r *ApplyResource) exportState(ctx context.Context, m *ApplyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.StateExport == nil {
+		return diags
+	}
+	limits, err := m.ResourceLimits.limits()
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return diags
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err == nil {
+		var tf runner
+		if tf, err = r.runner(limits, m, runID); err == nil {
+			var state string
+			// Warnings are written to stderr, which mustn't end up in the
+			// uploaded state.
+			if state, err = runStdout(ctx, tf, m.WorkingDir.ValueString(), "state", "pull"); err == nil {
+				if err = checkPulledState(state); err == nil {
+					err = uploadState(ctx, http.DefaultClient, m.StateExport, []byte(state))
+				}
+			}
+		}
+	}
+	if err != nil {
+		diags.AddAttributeError(path.Root("state_export"), "Unable to Export Nested State", fmt.Sprintf("Unable to upload the nested state to %s, got error: %s", m.StateExport.Address.ValueString(), err))
+	}
+	return diags
+}
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestExportState(t *testing.T) {
+	state := `{"version": 4, "serial": 3, "lineage": "abc", "outputs": {}}`
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); r.Method != http.MethodPost || user != "ci" || pass != "secret" || r.Header.Get("Content-MD5") == "" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}))
+	defer srv.Close()
+
+	m := testApplyModel(t.TempDir())
+	m.StateExport = &ApplyStateExportModel{
+		Address:  types.StringValue(srv.URL + "/network"),
+		Username: types.StringValue("ci"),
+		Password: types.StringValue("secret"),
+	}
+	fake := &fakeRunner{outputs: map[string]string{"state pull": state}}
+	r := &ApplyResource{newRunner: func(resourceLimits) runner { return fake }}
+	if diags := r.exportState(context.Background(), &m); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got != state {
+		t.Errorf("uploaded %q, want %q", got, state)
+	}
+
+	// Anything that isn't state isn't uploaded.
+	for _, output := range []string{"", "Warning: deprecated\n" + state, `{"version": 3}`} {
+		got = ""
+		fake.outputs["state pull"] = output
+		if diags := r.exportState(context.Background(), &m); !diags.HasError() || got != "" {
+			t.Errorf("exportState of %q = %v, uploaded %q, want an error", output, diags, got)
+		}
+	}
+	fake.outputs["state pull"] = state
+
+	m.StateExport.Password = types.StringValue("wrong")
+	if diags := r.exportState(context.Background(), &m); !diags.HasError() {
+		t.Error("exportState with the wrong password succeeded")
+	}
+}
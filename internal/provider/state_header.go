@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// stateHeader is the part of a state file, in format version 4, besides its
+// resources, which can be read without reading the whole file.
+type stateHeader struct {
+	Version          int                        `json:"version"`
+	TerraformVersion string                     `json:"terraform_version"`
+	Serial           int64                      `json:"serial"`
+	Lineage          string                     `json:"lineage"`
+	Outputs          map[string]json.RawMessage `json:"outputs"`
+}
+
+// readStateHeader reads the header of the state file fn. terraform writes
+// resources after the header, so reading stops there, and the resources of
+// state files written by other tools are skipped without being held in
+// memory.
+func readStateHeader(fn string) (*stateHeader, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h, err := decodeStateHeader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s, got error: %s", fn, err)
+	}
+	if h.Version != 4 {
+		return nil, fmt.Errorf("%s has unsupported state format version %d", fn, h.Version)
+	}
+	return h, nil
+}
+
+func decodeStateHeader(r io.Reader) (*stateHeader, error) {
+	dec := json.NewDecoder(r)
+	if t, err := dec.Token(); err != nil {
+		return nil, err
+	} else if t != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object, got %v", t)
+	}
+	var h stateHeader
+	// Stop once all of the header has been read.
+	for found := 0; found < 5 && dec.More(); {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		switch t {
+		case "version":
+			v = &h.Version
+		case "terraform_version":
+			v = &h.TerraformVersion
+		case "serial":
+			v = &h.Serial
+		case "lineage":
+			v = &h.Lineage
+		case "outputs":
+			v = &h.Outputs
+		}
+		if v == nil {
+			err = skipValue(dec)
+		} else {
+			err = dec.Decode(v)
+			found++
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &h, nil
+}
+
+// skipValue reads the next value from dec, token by token, so that large
+// values aren't held in memory.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
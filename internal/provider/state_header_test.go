@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStateHeader(t *testing.T) {
+	for _, c := range []struct {
+		desc, state string
+	}{{
+		desc:  "terraform",
+		state: `{"version":4,"terraform_version":"1.6.0","serial":7,"lineage":"abc","outputs":{"endpoint":{"value":"x","type":"string"}},"resources":[{"mode":"managed","type":"null_resource","name":"a","instances":[{"attributes":{"id":"1"}}]}],"check_results":null}`,
+	}, {
+		desc:  "resources first",
+		state: `{"resources":[{"instances":[{"attributes":{"nested":{"deep":[1,[2,{"x":3}]]}}}]}],"check_results":null,"outputs":{"endpoint":{"value":"x"}},"lineage":"abc","serial":7,"terraform_version":"1.6.0","version":4}`,
+	}, {
+		// Nothing after the header is read.
+		desc:  "truncated resources",
+		state: `{"version":4,"terraform_version":"1.6.0","serial":7,"lineage":"abc","outputs":{"endpoint":{"value":"x"}},"resources":[{"mode":`,
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			h, err := decodeStateHeader(strings.NewReader(c.state))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Version != 4 || h.TerraformVersion != "1.6.0" || h.Serial != 7 || h.Lineage != "abc" || len(h.Outputs) != 1 || h.Outputs["endpoint"] == nil {
+				t.Errorf("got %+v", h)
+			}
+		})
+	}
+
+	if _, err := decodeStateHeader(strings.NewReader(`[]`)); err == nil {
+		t.Error("array: got no error")
+	}
+	if _, err := decodeStateHeader(strings.NewReader(`{"resources":[{"mode":`)); err == nil {
+		t.Error("truncated before header: got no error")
+	}
+}
+
+func TestReadStateHeader(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"v4.tfstate": `{"version":4,"serial":1,"lineage":"abc","outputs":{}}`,
+		"v3.tfstate": `{"version":3,"serial":1,"lineage":"abc"}`,
+	})
+	if h, err := readStateHeader(filepath.Join(dir, "v4.tfstate")); err != nil || h.Lineage != "abc" {
+		t.Errorf("v4: got %+v, %v", h, err)
+	}
+	if _, err := readStateHeader(filepath.Join(dir, "v3.tfstate")); err == nil {
+		t.Error("v3: got no error")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+)
+
+// newerMinorVersion reports whether the major or minor version of written is
+// newer than that of cli. Patch releases never change the state format, and
+// versions that can't be parsed are never newer.
+func newerMinorVersion(written, cli string) bool {
+	w, err := version.NewVersion(written)
+	if err != nil {
+		return false
+	}
+	c, err := version.NewVersion(cli)
+	if err != nil {
+		return false
+	}
+	ws, cs := w.Segments(), c.Segments()
+	return ws[0] > cs[0] || ws[0] == cs[0] && ws[1] > cs[1]
+}
+
+// checkStateVersion returns an error if m's nested state was written by a
+// newer version of terraform than the one that would apply it, which would
+// otherwise fail with an error buried in terraform's output, or could drop
+// what the newer version stored in the state. State that isn't stored
+// locally, or can't be read, is left for terraform to check.
+func checkStateVersion(ctx context.Context, m *ApplyResourceModel) error {
+	fn := filepath.Join(m.WorkingDir.ValueString(), statePath(m.WorkspaceName.ValueString()))
+	h, err := m.states.header(fn)
+	if err != nil || h.TerraformVersion == "" {
+		return nil
+	}
+	cli, err := m.cliVersion(ctx)
+	if err != nil || !newerMinorVersion(h.TerraformVersion, cli) {
+		return nil
+	}
+	return fmt.Errorf("the nested state in %s was written by terraform %s, but would be applied by terraform %s, which may not be able to read it. Set terraform_version to %s, or upgrade the terraform found on PATH, to apply it", fn, h.TerraformVersion, cli, h.TerraformVersion)
+}
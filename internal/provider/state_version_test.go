@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestNewerMinorVersion(t *testing.T) {
+	for _, c := range []struct {
+		written, cli string
+		want         bool
+	}{
+		{"1.6.0", "1.6.0", false},
+		{"1.6.5", "1.6.0", false},
+		{"1.7.0", "1.6.5", true},
+		{"2.0.0", "1.9.0", true},
+		{"1.6.0", "1.7.0", false},
+		{"1.9.0", "2.0.0", false},
+		{"1.7.0-beta1", "1.6.0", true},
+		{"not a version", "1.6.0", false},
+	} {
+		if got := newerMinorVersion(c.written, c.cli); got != c.want {
+			t.Errorf("newerMinorVersion(%q, %q) = %t, want %t", c.written, c.cli, got, c.want)
+		}
+	}
+}
+
+func TestCheckStateVersion(t *testing.T) {
+	dir := t.TempDir()
+	m := &ApplyResourceModel{
+		WorkingDir:       types.StringValue(dir),
+		WorkspaceName:    types.StringNull(),
+		TerraformVersion: types.StringValue("1.6.0"),
+	}
+	ctx := context.Background()
+
+	// No state yet.
+	if err := checkStateVersion(ctx, m); err != nil {
+		t.Errorf("no state: %v", err)
+	}
+
+	write := func(state string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "terraform.tfstate"), []byte(state), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"version":4,"terraform_version":"1.6.3","serial":1,"lineage":"abc","outputs":{}}`)
+	if err := checkStateVersion(ctx, m); err != nil {
+		t.Errorf("same minor version: %v", err)
+	}
+	write(`{"version":4,"terraform_version":"1.8.0","serial":2,"lineage":"abc","outputs":{}}`)
+	if err := checkStateVersion(ctx, m); err == nil || !strings.Contains(err.Error(), "Set terraform_version to 1.8.0") {
+		t.Errorf("newer state: got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+// tempDir returns the directory scratch files are written in, or "" for the
+// system temporary directory. It may be called on a nil providerData, for a
+// provider that hasn't been configured.
+func (pd *providerData) tempDir() string {
+	if pd == nil {
+		return ""
+	}
+	return pd.TempDir
+}
+
+// tempDirEnv returns the environment variables that point nested terraform,
+// and anything it runs, at dir for temporary files, including the modules
+// it downloads before copying them into .terraform. TMPDIR is used on Unix,
+// and TMP and TEMP on Windows.
+func tempDirEnv(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	return []string{"TMPDIR=" + dir, "TMP=" + dir, "TEMP=" + dir}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDoApplyTempDir(t *testing.T) {
+	tmp := t.TempDir()
+	dir := writeFiles(t, map[string]string{"main.tf": "", ".terraform/.keep": ""})
+	m := testApplyModel(dir)
+	m.Attestation = &ApplyAttestationModel{Path: types.StringValue(filepath.Join(t.TempDir(), "att.json")), SigningKeyFile: types.StringNull()}
+
+	fake := &fakeRunner{}
+	r := &ApplyResource{
+		newRunner: func(resourceLimits) runner { return fake },
+		provider:  &providerData{TempDir: tmp},
+	}
+	if _, _, err := r.doApply(context.Background(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.argv) != 3 || !strings.HasPrefix(strings.Join(fake.argv[1], " "), "plan -out="+tmp+string(filepath.Separator)) {
+		t.Errorf("got commands %q, want the plan saved in %s", fake.commands, tmp)
+	}
+	if entries, err := os.ReadDir(tmp); err != nil || len(entries) != 0 {
+		t.Errorf("temp_dir has %v, %v after the apply, want it empty", entries, err)
+	}
+
+	env, err := r.env("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range env {
+		found = found || e == "TMPDIR="+tmp
+	}
+	if !found {
+		t.Errorf("env() = %q, want it to set TMPDIR", env)
+	}
+}
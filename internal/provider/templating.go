@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ApplyTemplatingModel describes the templating block.
+type ApplyTemplatingModel struct {
+	Engine types.String `tfsdk:"engine"`
+	Files  types.List   `tfsdk:"files"`
+}
+
+// templatingEngines are the values of templating's engine, the first of
+// which is the default.
+var templatingEngines = []string{"envsubst", "gomplate"}
+
+// templateSuffix is the suffix of templates, which are rendered to the same
+// path without it.
+const templateSuffix = ".tmpl"
+
+// engine returns m's engine, or the default if it isn't set.
+func (m *ApplyTemplatingModel) engine() string {
+	if e := m.Engine.ValueString(); e != "" {
+		return e
+	}
+	return templatingEngines[0]
+}
+
+// templates returns the templates m renders, relative to the working
+// directory, or an error if m is invalid.
+func (m *ApplyTemplatingModel) templates(ctx context.Context) ([]string, error) {
+	if !slices.Contains(templatingEngines, m.engine()) {
+		return nil, fmt.Errorf("engine must be one of %s, got %q", strings.Join(templatingEngines, ", "), m.engine())
+	}
+	var files []string
+	if diags := m.Files.ElementsAs(ctx, &files, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting files: %v", diags.Errors())
+	}
+	for _, fn := range files {
+		if !strings.HasSuffix(fn, templateSuffix) || fn == templateSuffix {
+			return nil, fmt.Errorf("files must end in %s, so they can be rendered next to themselves, got %q", templateSuffix, fn)
+		}
+		if !filepath.IsLocal(fn) {
+			return nil, fmt.Errorf("files must be relative paths in the working directory, got %q", fn)
+		}
+	}
+	return files, nil
+}
+
+// render renders m's templates in dir with the environment variables in
+// environ, to files with permissions mode, and returns the rendered files.
+func (m *ApplyTemplatingModel) render(ctx context.Context, dir string, environ []string, mode os.FileMode) ([]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	files, err := m.templates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rendered []string
+	for _, fn := range files {
+		in := filepath.Join(dir, fn)
+		out := strings.TrimSuffix(in, templateSuffix)
+		if err := renderTemplate(ctx, m.engine(), dir, in, out, environ, mode); err != nil {
+			return rendered, fmt.Errorf("Unable to render %s, got error: %s", fn, err)
+		}
+		rendered = append(rendered, strings.TrimSuffix(fn, templateSuffix))
+	}
+	return rendered, nil
+}
+
+// renderTemplate renders the template in to out, with permissions mode,
+// with engine.
+func renderTemplate(ctx context.Context, engine, dir, in, out string, environ []string, mode os.FileMode) error {
+	if engine == "gomplate" {
+		cmd := exec.CommandContext(ctx, "gomplate", "--file", in, "--out", out, "--chmod", fmt.Sprintf("%o", mode))
+		cmd.Dir = dir
+		cmd.Env = environ
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	b, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	return writeFile(out, envsubst(b, environ), mode)
+}
+
+// inputsDigest returns a digest of the values of the environment variables
+// in environ m's templates in dir reference, so changes to them can be
+// detected without recording them, or "" if m is nil. Only envsubst
+// templates can be digested, since gomplate ones can read any variable.
+func (m *ApplyTemplatingModel) inputsDigest(ctx context.Context, dir string, environ []string) (string, error) {
+	if m == nil {
+		return "", nil
+	}
+	if m.engine() != "envsubst" {
+		return "", fmt.Errorf("the inputs of %s templates can't be digested", m.engine())
+	}
+	files, err := m.templates(ctx)
+	if err != nil {
+		return "", err
+	}
+	vars := environMap(environ)
+	refs := map[string]bool{}
+	for _, fn := range files {
+		b, err := os.ReadFile(filepath.Join(dir, fn))
+		if err != nil {
+			return "", fmt.Errorf("Unable to read %s, got error: %s", fn, err)
+		}
+		for _, ref := range envsubstPattern.FindAllSubmatch(b, -1) {
+			refs[string(ref[1])+string(ref[2])] = true
+		}
+	}
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\x00", name, vars[name])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// environMap returns the variables of environ by name. As with exec, the
+// last value of each is used.
+func environMap(environ []string) map[string]string {
+	vars := map[string]string{}
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// envsubstPattern matches the references to environment variables envsubst
+// replaces: $NAME and ${NAME}. Others, like terraform's own ${var.name}
+// interpolations, are left alone.
+var envsubstPattern = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// envsubst replaces references to the environment variables in environ in
+// b, like GNU envsubst: references to variables that aren't set are
+// replaced with nothing.
+func envsubst(b []byte, environ []string) []byte {
+	vars := environMap(environ)
+	return envsubstPattern.ReplaceAllFunc(b, func(ref []byte) []byte {
+		m := envsubstPattern.FindSubmatch(ref)
+		name := m[1]
+		if name == nil {
+			name = m[2]
+		}
+		return []byte(vars[string(name)])
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEnvsubst(t *testing.T) {
+	environ := []string{"REGION=us-east-1", "BUCKET=old", "BUCKET=state"}
+	for in, want := range map[string]string{
+		`bucket = "$BUCKET"`:             `bucket = "state"`,
+		`region = "${REGION}"`:           `region = "us-east-1"`,
+		`key = "${UNSET}/$UNSET"`:        `key = "/"`,
+		`name = "${var.name}-${REGION}"`: `name = "${var.name}-us-east-1"`,
+		`price = "$5"`:                   `price = "$5"`,
+	} {
+		if got := string(envsubst([]byte(in), environ)); got != want {
+			t.Errorf("envsubst(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTemplatingRender(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"backend.tf.tmpl":      `terraform { backend "s3" { bucket = "$BUCKET" } }`,
+		"env/prod.tfvars.tmpl": `region = "${REGION}"`,
+	})
+	files := func(fns ...string) types.List {
+		var elems []attr.Value
+		for _, fn := range fns {
+			elems = append(elems, types.StringValue(fn))
+		}
+		return types.ListValueMust(types.StringType, elems)
+	}
+	m := &ApplyTemplatingModel{Engine: types.StringNull(), Files: files("backend.tf.tmpl", filepath.Join("env", "prod.tfvars.tmpl"))}
+	rendered, err := m.render(context.Background(), dir, []string{"BUCKET=state", "REGION=us-east-1"}, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rendered) != 2 {
+		t.Errorf("rendered %q, want 2 files", rendered)
+	}
+	for fn, want := range map[string]string{
+		"backend.tf":                        `terraform { backend "s3" { bucket = "state" } }`,
+		filepath.Join("env", "prod.tfvars"): `region = "us-east-1"`,
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, fn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s = %q, want %q", fn, b, want)
+		}
+		if fi, err := os.Stat(filepath.Join(dir, fn)); err != nil || runtime.GOOS != "windows" && fi.Mode().Perm() != 0o600 {
+			t.Errorf("%s has permissions %v, %v, want 0600", fn, fi.Mode().Perm(), err)
+		}
+	}
+
+	for _, m := range []*ApplyTemplatingModel{
+		{Engine: types.StringValue("jinja"), Files: files("backend.tf.tmpl")},
+		{Engine: types.StringNull(), Files: files("backend.tf")},
+		{Engine: types.StringNull(), Files: files(".tmpl")},
+		{Engine: types.StringNull(), Files: files(filepath.Join("..", "backend.tf.tmpl"))},
+	} {
+		if _, err := m.templates(context.Background()); err == nil {
+			t.Errorf("templates(%s, %s): got no error", m.Engine, m.Files)
+		}
+	}
+
+	// No templating block.
+	var none *ApplyTemplatingModel
+	if rendered, err := none.render(context.Background(), dir, nil, 0o600); err != nil || rendered != nil {
+		t.Errorf("nil render = %q, %v", rendered, err)
+	}
+}
+
+func TestTemplatingInputsDigest(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"backend.tf.tmpl": `bucket = "$BUCKET" # ${var.name}`})
+	m := &ApplyTemplatingModel{Engine: types.StringNull(), Files: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("backend.tf.tmpl")})}
+	before, err := m.inputsDigest(ctx, dir, []string{"BUCKET=state", "OTHER=a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := m.inputsDigest(ctx, dir, []string{"BUCKET=state", "OTHER=b"}); err != nil || got != before {
+		t.Errorf("inputsDigest with an unreferenced variable changed = %s, %v, want %s", got, err, before)
+	}
+	got, err := m.inputsDigest(ctx, dir, []string{"BUCKET=rotated", "OTHER=a"})
+	if err != nil || got == before {
+		t.Errorf("inputsDigest with a referenced variable changed = %s, %v, want a different digest", got, err)
+	}
+	if strings.Contains(got, "rotated") {
+		t.Errorf("inputsDigest() = %s, contains a value", got)
+	}
+
+	m.Engine = types.StringValue("gomplate")
+	if _, err := m.inputsDigest(ctx, dir, nil); err == nil {
+		t.Error("inputsDigest() of gomplate templates succeeded, want error")
+	}
+}
+
+func TestTemplatingInherit(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "secret")
+	dir := writeFiles(t, map[string]string{"main.tf.tmpl": `# $DEPLOY_TOKEN`})
+	r := &ApplyResource{provider: &providerData{InheritEnvironment: inheritEnvironment{deny: []string{"*_TOKEN"}}}}
+	m := testApplyModel(dir)
+	m.Templating = &ApplyTemplatingModel{Engine: types.StringNull(), Files: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("main.tf.tmpl")})}
+	if _, err := m.Templating.render(context.Background(), dir, r.nestedEnviron(&m), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "main.tf")); err != nil || strings.Contains(string(b), "secret") {
+		t.Errorf("main.tf = %q, %v, want the denied variable left out", b, err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &TerraformCLIDataSource{}
+
+func NewTerraformCLIDataSource() datasource.DataSource {
+	return &TerraformCLIDataSource{}
+}
+
+// TerraformCLIDataSource defines the data source implementation.
+type TerraformCLIDataSource struct{}
+
+// TerraformCLIDataSourceModel describes the data source data model.
+type TerraformCLIDataSourceModel struct {
+	WorkingDir   types.String `tfsdk:"working_dir"`
+	Path         types.String `tfsdk:"path"`
+	ResolvedPath types.String `tfsdk:"resolved_path"`
+	Shim         types.String `tfsdk:"shim"`
+	Version      types.String `tfsdk:"version"`
+}
+
+func (d *TerraformCLIDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_terraform_cli"
+}
+
+func (d *TerraformCLIDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reports which `terraform` binary and version nested applies run, following version manager shims such as asdf and mise.",
+
+		Attributes: map[string]schema.Attribute{
+			"working_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory to resolve `terraform` in. Version managers may pick a different version per directory. Defaults to the current directory.",
+				Optional:            true,
+			},
+			"path": schema.StringAttribute{
+				MarkdownDescription: "Path to `terraform` found on `PATH`.",
+				Computed:            true,
+			},
+			"resolved_path": schema.StringAttribute{
+				MarkdownDescription: "Path to the binary that actually runs, after following symlinks and version manager shims.",
+				Computed:            true,
+			},
+			"shim": schema.StringAttribute{
+				MarkdownDescription: "Version manager whose shim `path` is (`asdf`, `mise` or `tfenv`), or null if none was detected.",
+				Computed:            true,
+			},
+			"version": schema.StringAttribute{
+				MarkdownDescription: "Version reported by the resolved binary.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *TerraformCLIDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data TerraformCLIDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cli, err := resolveTerraformCLI(ctx, data.WorkingDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", err.Error())
+		return
+	}
+
+	data.Path = types.StringValue(cli.Path)
+	data.ResolvedPath = types.StringValue(cli.ResolvedPath)
+	data.Shim = types.StringNull()
+	if cli.Shim != "" {
+		data.Shim = types.StringValue(cli.Shim)
+	}
+	data.Version = types.StringValue(cli.Version)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
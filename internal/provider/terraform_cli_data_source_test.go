@@ -0,0 +1,28 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccTerraformCLIDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+data "pteraform_terraform_cli" "this" {
+	working_dir = "testdata/second"
+}
+`,
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttrSet("data.pteraform_terraform_cli.this", "resolved_path"),
+				resource.TestMatchResourceAttr("data.pteraform_terraform_cli.this", "version", regexp.MustCompile(`^\d+\.\d+\.\d+`)),
+			),
+		}},
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hc-install/product"
+	"github.com/hashicorp/hc-install/releases"
+)
+
+// terraformInstall is an exact version of terraform to run instead of the
+// one on PATH, installed in a directory shared by all resources.
+type terraformInstall struct {
+	version string
+	dir     string
+	// offline, if set, fails rather than downloading a version that isn't
+	// installed yet.
+	offline bool
+}
+
+// downloadTerraform downloads terraform v from releases.hashicorp.com into
+// dir, verifying its signature, and returns the path of the binary. Tests
+// substitute a fake.
+var downloadTerraform = func(ctx context.Context, v *version.Version, dir string) (string, error) {
+	ev := &releases.ExactVersion{Product: product.Terraform, Version: v, InstallDir: dir}
+	return ev.Install(ctx)
+}
+
+// terraformInstallLocks serializes installs of each version by resources in
+// this provider, keyed by the version's directory. Installs in different
+// processes are serialized by a lock file.
+var terraformInstallLocks sync.Map
+
+// defaultTerraformVersionsDir returns the directory terraform versions are
+// installed in if the provider's terraform_versions_dir isn't set.
+func defaultTerraformVersionsDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pteraform", "terraform"), nil
+}
+
+// terraformVersionsDir returns the directory terraform versions are
+// installed in.
+func (pd *providerData) terraformVersionsDir() (string, error) {
+	if pd == nil || pd.TerraformVersionsDir == "" {
+		return defaultTerraformVersionsDir()
+	}
+	return pd.TerraformVersionsDir, nil
+}
+
+// parseTerraformVersion parses v, which must be an exact version like
+// 1.7.5 rather than a constraint.
+func parseTerraformVersion(v string) (*version.Version, error) {
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return nil, fmt.Errorf("%q isn't an exact terraform version, like 1.7.5: %s", v, err)
+	}
+	return parsed, nil
+}
+
+// path returns the path of the terraform binary of the version, installing
+// it if it isn't already. However many resources ask for a version at once,
+// it's only downloaded once.
+func (i terraformInstall) path(ctx context.Context) (string, error) {
+	v, err := parseTerraformVersion(i.version)
+	if err != nil {
+		return "", err
+	}
+	versionDir := filepath.Join(i.dir, v.String())
+	bin := filepath.Join(versionDir, terraformBinary(runtime.GOOS))
+	if _, err := os.Stat(bin); err == nil {
+		return bin, nil
+	}
+	if i.offline {
+		return "", fmt.Errorf("terraform %s isn't installed in %s, and offline is set", v, i.dir)
+	}
+
+	mu, _ := terraformInstallLocks.LoadOrStore(versionDir, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+	if err := os.MkdirAll(i.dir, 0o755); err != nil {
+		return "", err
+	}
+	lock, err := os.OpenFile(versionDir+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return "", err
+	}
+	// Closing the file releases the lock.
+	defer lock.Close()
+	if err := lockExclusive(lock); err != nil {
+		return "", fmt.Errorf("Unable to lock %s, got error: %s", lock.Name(), err)
+	}
+	// Another resource or process may have installed it while this one
+	// waited.
+	if _, err := os.Stat(bin); err == nil {
+		return bin, nil
+	}
+
+	// Download next to where it's installed, so that a failed download
+	// never leaves a partial version behind.
+	tmp, err := os.MkdirTemp(i.dir, v.String()+".download-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := downloadTerraform(ctx, v, tmp); err != nil {
+		return "", fmt.Errorf("Unable to download terraform %s, got error: %s", v, err)
+	}
+	if err := os.Rename(tmp, versionDir); err != nil {
+		return "", err
+	}
+	return bin, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestTerraformInstall(t *testing.T) {
+	var downloads atomic.Int32
+	fail := false
+	old := downloadTerraform
+	downloadTerraform = func(ctx context.Context, v *version.Version, dir string) (string, error) {
+		downloads.Add(1)
+		if fail {
+			return "", errors.New("connection refused")
+		}
+		bin := filepath.Join(dir, terraformBinary(runtime.GOOS))
+		return bin, os.WriteFile(bin, []byte(v.String()), 0o755)
+	}
+	t.Cleanup(func() { downloadTerraform = old })
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	want := filepath.Join(dir, "1.7.5", terraformBinary(runtime.GOOS))
+
+	if _, err := (terraformInstall{version: "1.7.5", dir: dir, offline: true}).path(ctx); err == nil {
+		t.Error("offline before install: got no error")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := (terraformInstall{version: "1.7.5", dir: dir}).path(ctx); err != nil || got != want {
+				t.Errorf("path = %s, %v, want %s", got, err, want)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("downloaded %d times, want once", n)
+	}
+	if got, err := (terraformInstall{version: "1.7.5", dir: dir, offline: true}).path(ctx); err != nil || got != want {
+		t.Errorf("offline after install: path = %s, %v, want %s", got, err, want)
+	}
+
+	fail = true
+	if _, err := (terraformInstall{version: "1.6.0", dir: dir}).path(ctx); err == nil {
+		t.Error("failed download: got no error")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// Only lock files are left of the failed download.
+	if len(names) != 3 || names[0] != "1.6.0.lock" || names[1] != "1.7.5" || names[2] != "1.7.5.lock" {
+		t.Errorf("after failed download, got %v", names)
+	}
+
+	if _, err := (terraformInstall{version: "~> 1.7", dir: dir}).path(ctx); err == nil {
+		t.Error("constraint: got no error")
+	}
+}
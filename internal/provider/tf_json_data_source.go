@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &TFJSONDataSource{}
+
+func NewTFJSONDataSource() datasource.DataSource {
+	return &TFJSONDataSource{}
+}
+
+// TFJSONDataSource defines the data source implementation.
+type TFJSONDataSource struct{}
+
+// TFJSONDataSourceModel describes the data source data model.
+type TFJSONDataSourceModel struct {
+	Value           types.String `tfsdk:"value"`
+	EscapeTemplates types.Bool   `tfsdk:"escape_templates"`
+	JSON            types.String `tfsdk:"json"`
+}
+
+func (d *TFJSONDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tf_json"
+}
+
+func (d *TFJSONDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Generates a fragment of terraform's JSON configuration syntax, for a `.tf.json` file in a nested configuration, from outer values. Unlike `jsonencode`, strings in the result are escaped so that terraform takes them literally, instead of evaluating `${...}` and `%{...}` sequences in them as templates. The provider can't define provider functions, like `pteraform::to_tf_json`, until it's built on a newer plugin framework.",
+
+		Attributes: map[string]schema.Attribute{
+			"value": schema.StringAttribute{
+				MarkdownDescription: "The configuration, as JSON, like `jsonencode({ variable = { zones = { default = var.zones } } })`.",
+				Required:            true,
+			},
+			"escape_templates": schema.BoolAttribute{
+				MarkdownDescription: "Whether to escape template sequences in strings. Defaults to true; set it to false for strings with references and expressions to be evaluated by the nested configuration, like `\"${var.name}\"`.",
+				Optional:            true,
+			},
+			"json": schema.StringAttribute{
+				MarkdownDescription: "The configuration in terraform's JSON syntax, to write to a `.tf.json` file or pass to a `pteraform_apply` resource's `override_files`.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *TFJSONDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data TFJSONDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	out, err := tfJSON(data.Value.ValueString(), data.EscapeTemplates.IsNull() || data.EscapeTemplates.ValueBool())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("value"), "Invalid Configuration", err.Error())
+		return
+	}
+	data.JSON = types.StringValue(out)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// tfJSON returns the JSON value in, which must be an object like the body
+// of a .tf.json file, indented, with template sequences in strings escaped
+// if escape is true.
+func tfJSON(in string, escape bool) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(in))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", fmt.Errorf("Unable to parse value, got error: %s", err)
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return "", fmt.Errorf("value must be a JSON object, like the body of a .tf.json file")
+	}
+	if escape {
+		v = escapeTemplates(v)
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	// Keep <, > and &, as in terraform's own JSON.
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// templateEscaper escapes the template sequences in a string.
+var templateEscaper = strings.NewReplacer("${", "$${", "%{", "%%{")
+
+// escapeTemplates returns v, decoded from JSON, with the template sequences
+// in its strings escaped. Object keys are escaped too, since they're
+// templates in expressions, like object values. Keys that are names or block
+// labels, which aren't, can't contain template sequences anyway.
+func escapeTemplates(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return templateEscaper.Replace(v)
+	case []interface{}:
+		for i, e := range v {
+			v[i] = escapeTemplates(e)
+		}
+	case map[string]interface{}:
+		escaped := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			escaped[templateEscaper.Replace(k)] = escapeTemplates(e)
+		}
+		return escaped
+	}
+	return v
+}
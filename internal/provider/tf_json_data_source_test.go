@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTFJSON(t *testing.T) {
+	out, err := tfJSON(`{"locals": {"greeting": "hello ${name} %{if x}<b>&amp;", "sizes": [1.50, 20], "nested": {"${key}": ["${v}"]}}}`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, diags := hcljson.Parse([]byte(out), "out.tf.json")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	content, _, diags := f.Body.PartialContent(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "locals"}}})
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	attrs, diags := content.Blocks[0].Body.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	// Evaluated as terraform would, with templates, they're the values
+	// that were given.
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{}}
+	for name, want := range map[string]cty.Value{
+		"greeting": cty.StringVal("hello ${name} %{if x}<b>&amp;"),
+		"sizes":    cty.TupleVal([]cty.Value{cty.NumberFloatVal(1.5), cty.NumberIntVal(20)}),
+		"nested":   cty.ObjectVal(map[string]cty.Value{"${key}": cty.TupleVal([]cty.Value{cty.StringVal("${v}")})}),
+	} {
+		got, diags := attrs[name].Expr.Value(ctx)
+		if diags.HasErrors() {
+			t.Errorf("%s: %s", name, diags)
+		} else if !got.RawEquals(want) {
+			t.Errorf("%s = %#v, want %#v", name, got, want)
+		}
+	}
+
+	if out, err := tfJSON(`{"output": {"x": {"value": "${var.x}"}}}`, false); err != nil || out != "{\n  \"output\": {\n    \"x\": {\n      \"value\": \"${var.x}\"\n    }\n  }\n}\n" {
+		t.Errorf("without escaping = %q, %v", out, err)
+	}
+	for _, invalid := range []string{`[]`, `"x"`, `{`} {
+		if _, err := tfJSON(invalid, true); err == nil {
+			t.Errorf("tfJSON(%s): got no error", invalid)
+		}
+	}
+}
+
+func TestAccTFJSONDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{{
+			Config: `
+data "pteraform_tf_json" "this" {
+	value = jsonencode({ locals = { x = "$${y}" } })
+}
+`,
+			Check: resource.TestCheckResourceAttr("data.pteraform_tf_json.this", "json", "{\n  \"locals\": {\n    \"x\": \"$${y}\"\n  }\n}\n"),
+		}},
+	})
+}
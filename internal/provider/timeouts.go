@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ApplyTimeoutsModel describes the timeouts block.
+type ApplyTimeoutsModel struct {
+	Create types.String `tfsdk:"create"`
+	Update types.String `tfsdk:"update"`
+	Delete types.String `tfsdk:"delete"`
+}
+
+// timeout returns the time limit for the operation op, create, update or
+// delete, or 0 if there's none. m may be nil.
+func (m *ApplyTimeoutsModel) timeout(op string) (time.Duration, error) {
+	if m == nil {
+		return 0, nil
+	}
+	v := map[string]types.String{"create": m.Create, "update": m.Update, "delete": m.Delete}[op]
+	if v.IsNull() || v.IsUnknown() {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v.ValueString())
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s timeout: %s", op, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s timeout must be positive, got %q", op, v.ValueString())
+	}
+	return d, nil
+}
+
+// withTimeout returns ctx limited to m's timeout for op, if it has one.
+func (m *ApplyTimeoutsModel) withTimeout(ctx context.Context, op string) (context.Context, context.CancelFunc, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	d, err := m.timeout(op)
+	if err != nil {
+		diags.AddAttributeError(path.Root("timeouts").AtName(op), "Invalid Timeouts", err.Error())
+		return ctx, func() {}, diags
+	}
+	if d == 0 {
+		return ctx, func() {}, diags
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, diags
+}
+
+// timedOut returns the error diagnostic reported when op failed with err
+// because ctx, limited by m's timeout for it, expired, including what
+// terraform printed before it was interrupted, or nil if op didn't time
+// out.
+func (m *ApplyTimeoutsModel) timedOut(ctx context.Context, op string, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	d, _ := m.timeout(op)
+	if err == nil || d == 0 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	output := "(none)"
+	var re *runError
+	if errors.As(err, &re) && re.Output != "" {
+		output = truncateOutput(re.Output, re.LogFile)
+	}
+	diags.AddError("Timed Out", fmt.Sprintf("The nested terraform didn't finish within the %s timeout, %s, so it was interrupted. The nested state may be locked or partially applied; check it before retrying.\n\nOutput before it was interrupted:\n%s", op, d, output))
+	return diags
+}
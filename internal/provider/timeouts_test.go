@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestTimeouts(t *testing.T) {
+	m := &ApplyTimeoutsModel{Create: types.StringValue("2h"), Update: types.StringNull(), Delete: types.StringValue("-1m")}
+	if d, err := m.timeout("create"); err != nil || d != 2*time.Hour {
+		t.Errorf("timeout(create) = %s, %v, want 2h", d, err)
+	}
+	if d, err := m.timeout("update"); err != nil || d != 0 {
+		t.Errorf("timeout(update) = %s, %v, want none", d, err)
+	}
+	if _, err := m.timeout("delete"); err == nil {
+		t.Error("timeout(delete) with a negative timeout succeeded, want error")
+	}
+	var none *ApplyTimeoutsModel
+	if d, err := none.timeout("create"); err != nil || d != 0 {
+		t.Errorf("timeout(create) without timeouts = %s, %v, want none", d, err)
+	}
+}
+
+func TestTimedOut(t *testing.T) {
+	m := &ApplyTimeoutsModel{Create: types.StringValue("1ms"), Update: types.StringNull(), Delete: types.StringNull()}
+	ctx, cancel, diags := m.withTimeout(context.Background(), "create")
+	defer cancel()
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	<-ctx.Done()
+	err := &phaseError{Phase: "apply", Err: &runError{Command: "apply", ExitCode: -1, Output: "null_resource.a: Still creating... [10s elapsed]", Err: errors.New("signal: interrupt")}}
+	diags = m.timedOut(ctx, "create", err)
+	if !diags.HasError() {
+		t.Fatal("timedOut() reported nothing, want a timeout")
+	}
+	if got := diags[0].Detail(); !strings.Contains(got, "create timeout, 1ms") || !strings.Contains(got, "Still creating") {
+		t.Errorf("detail = %q, want the timeout and partial output", got)
+	}
+
+	if diags := m.timedOut(context.Background(), "create", err); diags.HasError() {
+		t.Errorf("timedOut() before the deadline = %v, want nothing", diags)
+	}
+	if diags := m.timedOut(ctx, "update", err); diags.HasError() {
+		t.Errorf("timedOut() without an update timeout = %v, want nothing", diags)
+	}
+}
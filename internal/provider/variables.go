@@ -0,0 +1,508 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// variableValue is a value supplied for a nested variable, before it is
+// parsed according to the variable's type.
+type variableValue struct {
+	// Source describes where the value came from, for diagnostics.
+	Source string
+	// Raw is set for values given on the command line or in the environment,
+	// which terraform interprets according to the variable's type.
+	Raw string
+	// Expr is set for values given in variable definitions files.
+	Expr hcl.Expression
+}
+
+// varArgs returns a -var argument setting each variable in vars, in name
+// order. Terraform is run without a shell, so each argument is passed to it
+// as it is, and values don't need quoting or escaping: terraform splits the
+// argument at the first = and takes the rest as the value.
+func varArgs(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names))
+	for _, name := range names {
+		args = append(args, "-var="+name+"="+vars[name])
+	}
+	return args
+}
+
+// varFileArgs returns a -var-file argument for each of files, in order.
+func varFileArgs(files []string) []string {
+	args := make([]string, 0, len(files))
+	for _, fn := range files {
+		args = append(args, "-var-file="+fn)
+	}
+	return args
+}
+
+// varFilePath returns the path of the variable definitions file fn, which
+// terraform run in dir reads relative to it.
+func varFilePath(dir, fn string) string {
+	if filepath.IsAbs(fn) {
+		return fn
+	}
+	return filepath.Join(dir, fn)
+}
+
+// varFilesDigest returns a digest of the contents of the variable
+// definitions files terraform run in dir with args reads, named by
+// -var-file arguments, or "" if there are none. Files outside dir aren't
+// part of the nested configuration's source, so this is how changes to
+// them are detected.
+func varFilesDigest(dir string, args []string) (string, error) {
+	var files []string
+	for i := 0; i < len(args); i++ {
+		if flagName(args[i]) != "-var-file" {
+			continue
+		}
+		if _, fn, ok := strings.Cut(args[i], "="); ok {
+			files = append(files, fn)
+		} else if i+1 < len(args) {
+			i++
+			files = append(files, args[i])
+		}
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	h := sha256.New()
+	for _, fn := range files {
+		digest, err := fileDigest(varFilePath(dir, fn))
+		if err != nil {
+			return "", fmt.Errorf("Unable to read variable definitions file %s, got error: %s", fn, err)
+		}
+		fmt.Fprintf(h, "%s  %s\n", digest, fn)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// typedVarArgs returns a -var argument setting each variable in the JSON
+// object variablesJSON, in name order. Strings are passed as they are, like
+// variables' values, and other values as HCL, which terraform parses
+// according to the variable's type.
+func typedVarArgs(variablesJSON string) ([]string, error) {
+	if variablesJSON == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(variablesJSON))
+	dec.UseNumber()
+	var vars map[string]interface{}
+	if err := dec.Decode(&vars); err != nil || vars == nil {
+		return nil, fmt.Errorf("variables_json must be a JSON object, like jsonencode({ name = \"value\" })")
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names))
+	for _, name := range names {
+		var value string
+		switch v := vars[name].(type) {
+		case nil:
+			return nil, fmt.Errorf("variable %q in variables_json is null; leave it out to use its default", name)
+		case string:
+			value = v
+		default:
+			var err error
+			if value, err = hclLiteral(v); err != nil {
+				return nil, fmt.Errorf("variable %q in variables_json: %s", name, err)
+			}
+		}
+		args = append(args, "-var="+name+"="+value)
+	}
+	return args, nil
+}
+
+// hclLiteral returns v, a value decoded from JSON with numbers as
+// json.Number, as an HCL expression.
+func hclLiteral(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return hclString(v), nil
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			elem, err := hclLiteral(e)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = elem
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]string, len(keys))
+		for i, k := range keys {
+			value, err := hclLiteral(v[k])
+			if err != nil {
+				return "", err
+			}
+			attrs[i] = hclString(k) + " = " + value
+		}
+		return "{" + strings.Join(attrs, ", ") + "}", nil
+	}
+	return "", fmt.Errorf("unexpected JSON value %T", v)
+}
+
+// hclString returns s as a quoted HCL string, with template sequences
+// escaped so they're taken literally.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// collectVariables returns the variables supplied to a terraform run in dir
+// with the given arguments and environment, following terraform's own
+// precedence rules: environment variables, then terraform.tfvars, then
+// *.auto.tfvars files, then -var and -var-file arguments in order.
+func collectVariables(dir string, args, environ []string) (map[string]variableValue, error) {
+	vars := map[string]variableValue{}
+
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, "TF_VAR_") {
+			continue
+		}
+		vars[strings.TrimPrefix(k, "TF_VAR_")] = variableValue{Source: "environment variable " + k, Raw: v}
+	}
+
+	var autoFiles []string
+	for _, fn := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		if _, err := os.Stat(filepath.Join(dir, fn)); err == nil {
+			autoFiles = append(autoFiles, fn)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read configuration directory %s, got error: %s", dir, err)
+	}
+	var autoVarFiles []string
+	for _, e := range entries {
+		if !e.IsDir() && (strings.HasSuffix(e.Name(), ".auto.tfvars") || strings.HasSuffix(e.Name(), ".auto.tfvars.json")) {
+			autoVarFiles = append(autoVarFiles, e.Name())
+		}
+	}
+	sort.Strings(autoVarFiles)
+	for _, fn := range append(autoFiles, autoVarFiles...) {
+		if err := readVarFile(filepath.Join(dir, fn), fn, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if name != "var" && name != "var-file" {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("argument %s requires a value", args[i])
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "var":
+			k, v, ok := strings.Cut(value, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid -var argument %q: expected name=value", value)
+			}
+			vars[k] = variableValue{Source: "-var argument", Raw: v}
+		case "var-file":
+			if err := readVarFile(varFilePath(dir, value), value, vars); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return vars, nil
+}
+
+// readVarFile reads the variable definitions file fn into vars.
+func readVarFile(fn, display string, vars map[string]variableValue) error {
+	parser := hclparse.NewParser()
+	var f *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(fn, ".json") {
+		f, diags = parser.ParseJSONFile(fn)
+	} else {
+		f, diags = parser.ParseHCLFile(fn)
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf("Unable to parse variable definitions file %s, got error: %s", display, diags.Error())
+	}
+	attrs, diags := f.Body.JustAttributes()
+	if diags.HasErrors() {
+		return fmt.Errorf("Unable to parse variable definitions file %s, got error: %s", display, diags.Error())
+	}
+	for name, attr := range attrs {
+		vars[name] = variableValue{Source: display, Expr: attr.Expr}
+	}
+	return nil
+}
+
+// missingVariables returns the sorted names of required variables declared
+// in mod that have no value in vars.
+func missingVariables(mod *moduleConfig, vars map[string]variableValue) []string {
+	var missing []string
+	for name, v := range mod.Variables {
+		if _, ok := vars[name]; !ok && v.Required() {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// invalidVariables parses each supplied value according to its declaration
+// in mod and evaluates the declaration's validation conditions, returning a
+// message for each value that is invalid. Values for undeclared variables are
+// ignored.
+func invalidVariables(mod *moduleConfig, vars map[string]variableValue) []string {
+	var names []string
+	for name := range vars {
+		if _, ok := mod.Variables[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var invalid []string
+	values := map[string]cty.Value{}
+	for _, name := range names {
+		value, err := mod.Variables[name].parse(vars[name])
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		values[name] = value
+	}
+	for _, name := range names {
+		if value, ok := values[name]; ok {
+			invalid = append(invalid, mod.Variables[name].validate(value, values)...)
+		}
+	}
+	return invalid
+}
+
+// parse returns val converted to the variable's type constraint, following
+// the same rules terraform uses: command-line and environment values are
+// taken literally for primitive types and otherwise parsed as HCL.
+func (v *variableConfig) parse(val variableValue) (cty.Value, error) {
+	var value cty.Value
+	if val.Expr == nil {
+		if v.Constraint == cty.DynamicPseudoType || v.Constraint.IsPrimitiveType() {
+			value = cty.StringVal(val.Raw)
+		} else {
+			expr, diags := hclsyntax.ParseExpression([]byte(val.Raw), val.Source, hcl.InitialPos)
+			if diags.HasErrors() {
+				return cty.NilVal, fmt.Errorf("invalid expression for variable %q from %s: %s", v.Name, val.Source, diags.Error())
+			}
+			val.Expr = expr
+		}
+	}
+	if val.Expr != nil {
+		var diags hcl.Diagnostics
+		value, diags = val.Expr.Value(nil)
+		if diags.HasErrors() {
+			return cty.NilVal, fmt.Errorf("invalid value for variable %q from %s: %s", v.Name, val.Source, diags.Error())
+		}
+	}
+	if v.Defaults != nil {
+		value = v.Defaults.Apply(value)
+	}
+	converted, err := convert.Convert(value, v.Constraint)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value for variable %q from %s: %s", v.Name, val.Source, err)
+	}
+	return converted, nil
+}
+
+// validationFunctions are the functions available when evaluating variable
+// validation conditions. Conditions using any other terraform function
+// can't be checked statically, and are left for terraform to evaluate.
+var validationFunctions = map[string]function.Function{
+	"alltrue":    allTrueFunc,
+	"anytrue":    anyTrueFunc,
+	"can":        tryfunc.CanFunc,
+	"contains":   stdlib.ContainsFunc,
+	"formatdate": stdlib.FormatDateFunc,
+	"keys":       stdlib.KeysFunc,
+	"length":     lengthFunc,
+	"lower":      stdlib.LowerFunc,
+	"max":        stdlib.MaxFunc,
+	"min":        stdlib.MinFunc,
+	"regex":      stdlib.RegexFunc,
+	"regexall":   stdlib.RegexAllFunc,
+	"strlen":     stdlib.StrlenFunc,
+	"substr":     stdlib.SubstrFunc,
+	"trimspace":  stdlib.TrimSpaceFunc,
+	"try":        tryfunc.TryFunc,
+	"upper":      stdlib.UpperFunc,
+	"values":     stdlib.ValuesFunc,
+}
+
+// lengthFunc is terraform's length function, which unlike cty's also
+// accepts strings.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name:             "value",
+		Type:             cty.DynamicPseudoType,
+		AllowDynamicType: true,
+		AllowUnknown:     true,
+	}},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		if ty != cty.String && !ty.IsCollectionType() && !ty.IsTupleType() && !ty.IsObjectType() && ty != cty.DynamicPseudoType {
+			return cty.Number, fmt.Errorf("argument must be a string, a collection type, or a structural type")
+		}
+		return cty.Number, nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
+
+// allTrueFunc is terraform's alltrue function, which returns whether every
+// element of a list of bools is true, or true if it's empty.
+var allTrueFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name: "list",
+		Type: cty.List(cty.Bool),
+	}},
+	Type: function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if !v.IsKnown() {
+				return cty.UnknownVal(cty.Bool), nil
+			}
+			if v.IsNull() || v.False() {
+				return cty.False, nil
+			}
+		}
+		return cty.True, nil
+	},
+})
+
+// anyTrueFunc is terraform's anytrue function, which returns whether any
+// element of a list of bools is true, or false if it's empty.
+var anyTrueFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name: "list",
+		Type: cty.List(cty.Bool),
+	}},
+	Type: function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		result := cty.False
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if !v.IsKnown() {
+				result = cty.UnknownVal(cty.Bool)
+				continue
+			}
+			if !v.IsNull() && v.True() {
+				return cty.True, nil
+			}
+		}
+		return result, nil
+	},
+})
+
+// validate evaluates the variable's validation conditions against value,
+// given the values of all variables, and returns the error message of each
+// one that fails. Conditions that can't be evaluated statically are skipped.
+func (v *variableConfig) validate(value cty.Value, values map[string]cty.Value) []string {
+	vals := map[string]cty.Value{}
+	for k, val := range values {
+		vals[k] = val
+	}
+	vals[v.Name] = value
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{"var": cty.ObjectVal(vals)},
+		Functions: validationFunctions,
+	}
+
+	var failures []string
+	for _, val := range v.Validations {
+		result, diags := val.Condition.Value(ctx)
+		if diags.HasErrors() || !result.IsKnown() || result.IsNull() {
+			continue
+		}
+		if result, err := convert.Convert(result, cty.Bool); err != nil || result.True() {
+			continue
+		}
+		msg := fmt.Sprintf("Invalid value for variable %q: the validation condition failed.", v.Name)
+		if val.ErrorMessage != nil {
+			if m, diags := val.ErrorMessage.Value(ctx); !diags.HasErrors() && m.IsKnown() && !m.IsNull() && m.Type() == cty.String {
+				msg = fmt.Sprintf("Invalid value for variable %q: %s", v.Name, m.AsString())
+			}
+		}
+		failures = append(failures, msg)
+	}
+	return failures
+}
@@ -0,0 +1,360 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCollectVariables(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `
+variable "a" {}
+variable "b" {}
+variable "c" {}
+variable "d" {}
+variable "e" {}
+variable "f" {}
+variable "optional" {
+  default = "x"
+}
+`,
+		"terraform.tfvars":      `a = "from tfvars"`,
+		"x.auto.tfvars.json":    `{"b": "from auto"}`,
+		"envs/prod.tfvars":      `c = "from var-file"`,
+		"terraform.tfvars.json": `{"a": "from tfvars json"}`,
+	})
+
+	vars, err := collectVariables(dir,
+		[]string{"-var=d=from-var", "-var", "e=x=y", "-var-file=envs/prod.tfvars", "-target=null_resource.x"},
+		[]string{"TF_VAR_a=from env", "TF_VAR_f=from env", "HOME=/root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for name, v := range vars {
+		got[name] = v.Source
+	}
+	if diff := cmp.Diff(map[string]string{
+		"a": "terraform.tfvars.json",
+		"b": "x.auto.tfvars.json",
+		"c": "envs/prod.tfvars",
+		"d": "-var argument",
+		"e": "-var argument",
+		"f": "environment variable TF_VAR_f",
+	}, got); diff != "" {
+		t.Errorf("sources (-want,+got): %s", diff)
+	}
+	if vars["e"].Raw != "x=y" {
+		t.Errorf("e = %q, want %q", vars["e"].Raw, "x=y")
+	}
+
+	mod, err := loadModule(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := missingVariables(mod, vars); len(missing) != 0 {
+		t.Errorf("missingVariables = %v, want none", missing)
+	}
+	delete(vars, "c")
+	delete(vars, "f")
+	if diff := cmp.Diff([]string{"c", "f"}, missingVariables(mod, vars)); diff != "" {
+		t.Errorf("missingVariables (-want,+got): %s", diff)
+	}
+}
+
+func TestCollectVariablesErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": ""})
+	for _, args := range [][]string{
+		{"-var"},
+		{"-var=novalue"},
+		{"-var-file=missing.tfvars"},
+	} {
+		if _, err := collectVariables(dir, args, nil); err == nil {
+			t.Errorf("collectVariables(%q): expected error", args)
+		}
+	}
+}
+
+func TestInvalidVariables(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `
+variable "name" {
+  type = string
+  validation {
+    condition     = length(var.name) <= 5
+    error_message = "Name must be at most 5 characters."
+  }
+}
+variable "zones" {
+  type = list(string)
+  validation {
+    condition     = alltrue([for z in var.zones : length(z) > 1])
+    error_message = "Zone names must be longer than one character."
+  }
+  validation {
+    condition     = anytrue([for z in var.zones : z == "us-a"])
+    error_message = "Zones must include us-a."
+  }
+}
+variable "config" {
+  type = object({
+    size = number
+    tags = optional(map(string), {})
+  })
+}
+variable "untyped" {}
+variable "custom" {
+  type = string
+  validation {
+    condition     = some_terraform_function(var.custom)
+    error_message = "Not checked statically."
+  }
+}
+`,
+	})
+	mod, err := loadModule(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		desc string
+		vars map[string]variableValue
+		want []string
+	}{{
+		desc: "valid",
+		vars: map[string]variableValue{
+			"name":    {Source: "-var argument", Raw: "short"},
+			"zones":   {Source: "-var argument", Raw: `["us-a", "us-b"]`},
+			"config":  {Source: "-var argument", Raw: `{size = 3}`},
+			"untyped": {Source: "-var argument", Raw: `["not", "parsed"]`},
+			"custom":  {Source: "-var argument", Raw: "x"},
+			"extra":   {Source: "-var argument", Raw: "undeclared"},
+		},
+	}, {
+		desc: "validation fails",
+		vars: map[string]variableValue{"name": {Source: "-var argument", Raw: "too long"}},
+		want: []string{`Invalid value for variable "name": Name must be at most 5 characters.`},
+	}, {
+		desc: "list validations fail",
+		vars: map[string]variableValue{"zones": {Source: "-var argument", Raw: `["us-b", "c"]`}},
+		want: []string{
+			`Invalid value for variable "zones": Zone names must be longer than one character.`,
+			`Invalid value for variable "zones": Zones must include us-a.`,
+		},
+	}, {
+		desc: "string given where list expected",
+		vars: map[string]variableValue{"zones": {Source: "-var argument", Raw: `"a"`}},
+		want: []string{`invalid value for variable "zones" from -var argument: list of string required`},
+	}, {
+		desc: "missing object attribute",
+		vars: map[string]variableValue{"config": {Source: "-var argument", Raw: `{tags = {}}`}},
+		want: []string{`invalid value for variable "config" from -var argument: attribute "size" is required`},
+	}, {
+		desc: "invalid expression",
+		vars: map[string]variableValue{"zones": {Source: "-var argument", Raw: `[`}},
+		want: []string{`invalid expression for variable "zones" from -var argument`},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			got := invalidVariables(mod, c.vars)
+			if len(got) != len(c.want) {
+				t.Fatalf("invalidVariables = %q, want %d messages", got, len(c.want))
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], c.want[i]) {
+					t.Errorf("message %d = %q, want prefix %q", i, got[i], c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVarArgs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `
+variable "newlines" {}
+variable "quotes" {}
+variable "unicode" {}
+variable "json" {}
+variable "equals" {}
+variable "zones" {
+  type = list(string)
+}
+variable "tags" {
+  type = map(string)
+}
+`,
+	})
+	want := map[string]cty.Value{
+		"newlines": cty.StringVal("line one\nline two\n"),
+		"quotes":   cty.StringVal(`she said "hi" and 'bye' \n`),
+		"unicode":  cty.StringVal("héllo, 世界 🦕"),
+		"json":     cty.StringVal(`{"a": [1, 2], "b": "c=d"}`),
+		"equals":   cty.StringVal("a=b=c"),
+		"zones":    cty.ListVal([]cty.Value{cty.StringVal("us-east1-a"), cty.StringVal(`quoted "zone"`)}),
+		"tags":     cty.MapVal(map[string]cty.Value{"team": cty.StringVal("platform")}),
+	}
+	args := varArgs(map[string]string{
+		"newlines": "line one\nline two\n",
+		"quotes":   `she said "hi" and 'bye' \n`,
+		"unicode":  "héllo, 世界 🦕",
+		"json":     `{"a": [1, 2], "b": "c=d"}`,
+		"equals":   "a=b=c",
+		"zones":    `["us-east1-a", "quoted \"zone\""]`,
+		"tags":     `{"team": "platform"}`,
+	})
+	if len(args) != len(want) {
+		t.Fatalf("varArgs returned %d arguments, want %d: %q", len(args), len(want), args)
+	}
+	if !strings.HasPrefix(args[0], "-var=equals=") {
+		t.Errorf("varArgs not sorted by name: %q", args)
+	}
+
+	vars, err := collectVariables(dir, args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := loadModule(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, w := range want {
+		got, err := mod.Variables[name].parse(vars[name])
+		if err != nil {
+			t.Errorf("parse(%s): %v", name, err)
+		} else if !got.RawEquals(w) {
+			t.Errorf("%s = %#v, want %#v", name, got, w)
+		}
+	}
+}
+
+func TestTypedVarArgs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `
+variable "name" {}
+variable "count" {
+  type = number
+}
+variable "enabled" {
+  type = bool
+}
+variable "zones" {
+  type = list(string)
+}
+variable "settings" {
+  type = object({
+    tags    = map(string)
+    sizes   = list(number)
+    comment = string
+  })
+}
+`,
+	})
+	want := map[string]cty.Value{
+		"name":    cty.StringVal(`web "${prod}"`),
+		"count":   cty.NumberIntVal(3),
+		"enabled": cty.True,
+		"zones":   cty.ListVal([]cty.Value{cty.StringVal("us-east1-a"), cty.StringVal(`quoted "zone"`)}),
+		"settings": cty.ObjectVal(map[string]cty.Value{
+			"tags":    cty.MapVal(map[string]cty.Value{"team": cty.StringVal("platform"), "with space": cty.StringVal("%{ if x }ok\\\n\t")}),
+			"sizes":   cty.ListVal([]cty.Value{cty.NumberFloatVal(1.5), cty.NumberIntVal(20)}),
+			"comment": cty.StringVal("${var.x} héllo 🦕 \x01"),
+		}),
+	}
+	args, err := typedVarArgs(`{
+		"name": "web \"${prod}\"",
+		"count": 3,
+		"enabled": true,
+		"zones": ["us-east1-a", "quoted \"zone\""],
+		"settings": {
+			"tags": {"team": "platform", "with space": "%{ if x }ok\\\n\t"},
+			"sizes": [1.5, 20],
+			"comment": "${var.x} héllo 🦕 \u0001"
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != len(want) || !strings.HasPrefix(args[0], "-var=count=") {
+		t.Fatalf("typedVarArgs = %q, want %d arguments sorted by name", args, len(want))
+	}
+
+	vars, err := collectVariables(dir, args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := loadModule(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, w := range want {
+		got, err := mod.Variables[name].parse(vars[name])
+		if err != nil {
+			t.Errorf("parse(%s): %v", name, err)
+		} else if !got.RawEquals(w) {
+			t.Errorf("%s = %#v, want %#v", name, got, w)
+		}
+	}
+
+	for _, invalid := range []string{`[]`, `"a"`, `null`, `{"a":`, `{"a": null}`} {
+		if _, err := typedVarArgs(invalid); err == nil {
+			t.Errorf("typedVarArgs(%s): got no error", invalid)
+		}
+	}
+}
+
+func TestHCLLiteralUnexpected(t *testing.T) {
+	// Numbers not decoded with UseNumber are reported, not panicked on.
+	if got, err := hclLiteral(map[string]interface{}{"sizes": []interface{}{1.5}}); err == nil {
+		t.Errorf("hclLiteral(float64) = %q, want an error", got)
+	}
+}
+
+func TestCheckVariablesVarFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf":         `variable "region" {}`,
+		"env/prod.tfvars": `region = "us-east-1"`,
+	})
+	m := testApplyModel(dir)
+	r := &ApplyResource{}
+
+	m.VarFiles = types.ListValueMust(types.StringType, []attr.Value{types.StringValue(filepath.Join("env", "prod.tfvars"))})
+	if diags := r.checkVariables(context.Background(), &m); diags.HasError() {
+		t.Errorf("var file supplying the variable: %v", diags)
+	}
+
+	m.VarFiles = types.ListValueMust(types.StringType, []attr.Value{types.StringValue(filepath.Join("env", "prod.tfvars")), types.StringValue(filepath.Join("env", "staging.tfvars"))})
+	diags := r.checkVariables(context.Background(), &m)
+	if !diags.HasError() || diags[0].Summary() != "Missing Variable Definitions File" {
+		t.Errorf("missing var file: got %v", diags)
+	}
+}
+
+func TestCheckVariablesRemoteBackend(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `variable "region" {}`, ".terraform/.keep": ""})
+	m := testApplyModel(dir)
+	r := &ApplyResource{}
+	if diags := r.checkVariables(context.Background(), &m); !diags.HasError() {
+		t.Errorf("checkVariables() with local state = %v, want a missing variable", diags)
+	}
+
+	// Workspaces of the remote backend can set it.
+	if err := os.WriteFile(filepath.Join(dir, ".terraform", "terraform.tfstate"), []byte(`{"backend": {"type": "remote"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if diags := r.checkVariables(context.Background(), &m); diags.HasError() || diags.WarningsCount() != 1 {
+		t.Errorf("checkVariables() with a remote backend = %v, want a warning", diags)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// verifiedOutputsKey is the private state key the digests of the values of
+// verify_outputs after the last apply are stored under. Digests are stored
+// rather than values, since outputs may be sensitive.
+const verifiedOutputsKey = "verified_outputs"
+
+// outputDriftKey is the private state key the names of verify_outputs found
+// changed when the resource was last refreshed are stored under, so that the
+// next plan updates the resource.
+const outputDriftKey = "output_drift"
+
+// outputDigests returns the hex-encoded SHA-256 digest of the value of each
+// of names in out, the output of terraform output -json, or "" for those
+// that aren't set.
+func outputDigests(out string, names []string) (map[string]string, error) {
+	values, sensitive, err := stateOutputs(out)
+	if err != nil {
+		return nil, err
+	}
+	digests := map[string]string{}
+	for _, name := range names {
+		v, ok := values[name]
+		if !ok {
+			v, ok = sensitive[name]
+		}
+		if ok {
+			digests[name] = fmt.Sprintf("%x", sha256.Sum256([]byte(v)))
+		} else {
+			digests[name] = ""
+		}
+	}
+	return digests, nil
+}
+
+// changedOutputs returns the sorted names of the outputs whose digests in
+// now differ from those recorded.
+func changedOutputs(recorded, now map[string]string) []string {
+	var changed []string
+	for name, digest := range now {
+		if was, ok := recorded[name]; ok && was != digest {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// nestedOutputJSON runs terraform output -json in m's working directory and
+// returns its output.
+func (r *ApplyResource) nestedOutputJSON(ctx context.Context, m *ApplyResourceModel) (string, error) {
+	limits, err := m.ResourceLimits.limits()
+	if err != nil {
+		return "", err
+	}
+	ctx, runID, err := newRunID(ctx)
+	if err != nil {
+		return "", err
+	}
+	tf, err := r.runner(limits, m, runID)
+	if err != nil {
+		return "", err
+	}
+	return tf.run(ctx, m.WorkingDir.ValueString(), "output", "-json")
+}
+
+// nestedOutputDigests runs terraform output in m's working directory and
+// returns the digests of verify_outputs.
+func (r *ApplyResource) nestedOutputDigests(ctx context.Context, m *ApplyResourceModel) (map[string]string, error) {
+	var names []string
+	if diags := m.VerifyOutputs.ElementsAs(ctx, &names, false); diags.HasError() {
+		return nil, fmt.Errorf("errors getting verify_outputs: %v", diags.Errors())
+	}
+	out, err := r.nestedOutputJSON(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return outputDigests(out, names)
+}
+
+// recordOutputs stores the digests of verify_outputs after an apply, and
+// clears any drift found before it.
+func (r *ApplyResource) recordOutputs(ctx context.Context, m *ApplyResourceModel, private privateState) diag.Diagnostics {
+	diags := private.SetKey(ctx, outputDriftKey, []byte("null"))
+	if m.VerifyOutputs.IsNull() {
+		diags.Append(private.SetKey(ctx, verifiedOutputsKey, []byte("null"))...)
+		return diags
+	}
+	digests, err := r.nestedOutputDigests(ctx, m)
+	if err != nil {
+		diags.AddWarning("Unable to Record Nested Outputs", fmt.Sprintf("Changes to verify_outputs won't be detected until the next apply: %s", err))
+		return diags
+	}
+	b, err := json.Marshal(digests)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to record nested outputs, got error: %s", err))
+		return diags
+	}
+	diags.Append(private.SetKey(ctx, verifiedOutputsKey, b)...)
+	return diags
+}
+
+// checkOutputs compares verify_outputs with their values after the last
+// apply, and records any that changed, so the next plan updates the
+// resource.
+func (r *ApplyResource) checkOutputs(ctx context.Context, m *ApplyResourceModel, prior privateStateReader, private privateState) diag.Diagnostics {
+	if m.VerifyOutputs.IsNull() {
+		return nil
+	}
+	b, diags := prior.GetKey(ctx, verifiedOutputsKey)
+	var recorded map[string]string
+	if diags.HasError() || len(b) == 0 || json.Unmarshal(b, &recorded) != nil || recorded == nil {
+		return diags
+	}
+	now, err := r.nestedOutputDigests(ctx, m)
+	if err != nil {
+		diags.AddWarning("Unable to Verify Nested Outputs", err.Error())
+		return diags
+	}
+	drift := []byte("null")
+	if changed := changedOutputs(recorded, now); len(changed) > 0 {
+		if drift, err = json.Marshal(changed); err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to record changed nested outputs, got error: %s", err))
+			return diags
+		}
+	}
+	diags.Append(private.SetKey(ctx, outputDriftKey, drift)...)
+	return diags
+}
+
+// planOutputDrift plans an update, by marking run_id unknown, if the last
+// refresh found any verify_outputs changed.
+func planOutputDrift(ctx context.Context, prior privateStateReader, plan *tfsdk.Plan) diag.Diagnostics {
+	b, diags := prior.GetKey(ctx, outputDriftKey)
+	var changed []string
+	if diags.HasError() || len(b) == 0 || json.Unmarshal(b, &changed) != nil || len(changed) == 0 {
+		return diags
+	}
+	diags.AddWarning("Nested Outputs Changed", fmt.Sprintf("The nested configuration's %s changed since it was last applied, perhaps because its state was edited or it was applied outside of this resource, so it will be applied again.", strings.Join(changed, ", ")))
+	diags.Append(plan.SetAttribute(ctx, path.Root("run_id"), types.StringUnknown())...)
+	return diags
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangedOutputs(t *testing.T) {
+	before, err := outputDigests(`{
+  "endpoint": {"sensitive": false, "type": "string", "value": "https://a.example.com"},
+  "vpc_id": {"sensitive": true, "type": "string", "value": "vpc-1"}
+}`, []string{"endpoint", "vpc_id", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before["missing"] != "" || before["endpoint"] == "" || before["vpc_id"] == "" {
+		t.Fatalf("outputDigests = %v", before)
+	}
+	if got := changedOutputs(before, before); len(got) != 0 {
+		t.Errorf("changedOutputs with no changes = %v", got)
+	}
+
+	after, err := outputDigests(`{
+  "endpoint": {"sensitive": false, "type": "string", "value": "https://b.example.com"},
+  "missing": {"sensitive": false, "type": "string", "value": "now set"}
+}`, []string{"endpoint", "vpc_id", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"endpoint", "missing", "vpc_id"}, changedOutputs(before, after)); diff != "" {
+		t.Errorf("changedOutputs (-want,+got): %s", diff)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ApplyWaitForHTTPModel describes the wait_for_http block.
+type ApplyWaitForHTTPModel struct {
+	URL      types.String `tfsdk:"url"`
+	Status   types.Int64  `tfsdk:"status"`
+	Timeout  types.String `tfsdk:"timeout"`
+	Interval types.String `tfsdk:"interval"`
+}
+
+// httpWait polls a URL until it responds with the wanted status.
+type httpWait struct {
+	URL      string
+	Status   int
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+// wait returns the wait described by m, which must not be nil.
+func (m *ApplyWaitForHTTPModel) wait() (httpWait, error) {
+	w := httpWait{
+		URL:      m.URL.ValueString(),
+		Status:   http.StatusOK,
+		Timeout:  5 * time.Minute,
+		Interval: 10 * time.Second,
+	}
+	if !m.Status.IsNull() {
+		w.Status = int(m.Status.ValueInt64())
+		if w.Status < 100 || w.Status > 599 {
+			return w, fmt.Errorf("status must be an HTTP status code, got %d", w.Status)
+		}
+	}
+	for _, d := range []struct {
+		name string
+		v    types.String
+		dst  *time.Duration
+	}{{"timeout", m.Timeout, &w.Timeout}, {"interval", m.Interval, &w.Interval}} {
+		if d.v.IsNull() {
+			continue
+		}
+		v, err := time.ParseDuration(d.v.ValueString())
+		if err != nil {
+			return w, fmt.Errorf("Unable to parse %s, got error: %s", d.name, err)
+		}
+		if v <= 0 {
+			return w, fmt.Errorf("%s must be positive, got %s", d.name, d.v.ValueString())
+		}
+		*d.dst = v
+	}
+	return w, nil
+}
+
+// expandOutputs expands $NAME and ${NAME} in s with the variables in env, as
+// returned by outputEnv. Other references are left as they are.
+func expandOutputs(s string, env []string) string {
+	vars := map[string]string{}
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		vars[k] = v
+	}
+	return os.Expand(s, func(name string) string {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return "${" + name + "}"
+	})
+}
+
+// wait polls url until it responds with w.Status, returning an error if it
+// hasn't within w.Timeout.
+func (w httpWait) wait(ctx context.Context, client *http.Client, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, w.Timeout)
+	defer cancel()
+
+	var last string
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := client.Do(req); err != nil {
+			last = err.Error()
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode == w.Status {
+				return nil
+			}
+			last = resp.Status
+		}
+		tflog.Debug(ctx, "Waiting for nested service", map[string]interface{}{"url": url, "got": last})
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s didn't respond with status %d within %s, last got: %s", url, w.Status, w.Timeout, last)
+		case <-time.After(w.Interval):
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestExpandOutputs(t *testing.T) {
+	env := outputEnv(map[string]string{"endpoint": `"https://example.com"`})
+	for s, want := range map[string]string{
+		"$PTERAFORM_OUTPUT_endpoint/healthz":   "https://example.com/healthz",
+		"${PTERAFORM_OUTPUT_endpoint}/healthz": "https://example.com/healthz",
+		"https://example.com/$OTHER":           "https://example.com/${OTHER}",
+	} {
+		if got := expandOutputs(s, env); got != want {
+			t.Errorf("expandOutputs(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestWaitForHTTPModel(t *testing.T) {
+	for _, c := range []struct {
+		m  ApplyWaitForHTTPModel
+		ok bool
+	}{
+		{ApplyWaitForHTTPModel{Status: types.Int64Null(), Timeout: types.StringNull(), Interval: types.StringNull()}, true},
+		{ApplyWaitForHTTPModel{Status: types.Int64Value(204), Timeout: types.StringValue("90s"), Interval: types.StringValue("1s")}, true},
+		{ApplyWaitForHTTPModel{Status: types.Int64Value(42), Timeout: types.StringNull(), Interval: types.StringNull()}, false},
+		{ApplyWaitForHTTPModel{Status: types.Int64Null(), Timeout: types.StringValue("soon"), Interval: types.StringNull()}, false},
+		{ApplyWaitForHTTPModel{Status: types.Int64Null(), Timeout: types.StringNull(), Interval: types.StringValue("0s")}, false},
+	} {
+		if _, err := c.m.wait(); (err == nil) != c.ok {
+			t.Errorf("wait(%+v) = %v, want ok %t", c.m, err, c.ok)
+		}
+	}
+}
+
+func TestHTTPWait(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	w := httpWait{Status: http.StatusOK, Timeout: time.Minute, Interval: time.Millisecond}
+	if err := w.wait(context.Background(), srv.Client(), srv.URL); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+
+	w = httpWait{Status: http.StatusNoContent, Timeout: 50 * time.Millisecond, Interval: time.Millisecond}
+	if err := w.wait(context.Background(), srv.Client(), srv.URL); err == nil {
+		t.Error("wait: expected timeout")
+	}
+}
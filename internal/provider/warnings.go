@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// warningSummaryPattern matches the summary line of a warning diagnostic in
+// terraform's human-readable output, which may be framed by box drawing.
+var warningSummaryPattern = regexp.MustCompile(`(?m)^[│╷\s]*Warning: (.+?)\s*$`)
+
+// compactWarningsPattern matches the list of warnings terraform prints with
+// -compact-warnings, with a summary on each line starting with "- ".
+var compactWarningsPattern = regexp.MustCompile(`(?m)^Warnings:\n\n((?:(?:- |  ).*\n?)+)`)
+
+// nestedWarnings returns the summaries of the warnings in terraform output,
+// in either its human-readable or JSON form, leaving out any that match one
+// of suppress. Repeated warnings are reported once, like -compact-warnings.
+func nestedWarnings(ctx context.Context, output string, suppress []*regexp.Regexp) []string {
+	var summaries []string
+	for _, m := range warningSummaryPattern.FindAllStringSubmatch(output, -1) {
+		summaries = append(summaries, m[1])
+	}
+	for _, m := range compactWarningsPattern.FindAllStringSubmatch(output, -1) {
+		for _, line := range strings.Split(m[1], "\n") {
+			if s, ok := strings.CutPrefix(line, "- "); ok {
+				summaries = append(summaries, strings.TrimSpace(s))
+			}
+		}
+	}
+	for _, e := range parseEvents(output) {
+		if e.Type == "diagnostic" && e.Diagnostic.Severity == "warning" {
+			summaries = append(summaries, e.Diagnostic.Summary)
+		}
+	}
+
+	var order []string
+	counts := map[string]int{}
+next:
+	for _, s := range summaries {
+		for _, re := range suppress {
+			if re.MatchString(s) {
+				tflog.Debug(ctx, "Suppressed nested warning", map[string]interface{}{"summary": s, "pattern": re.String()})
+				continue next
+			}
+		}
+		if counts[s] == 0 {
+			order = append(order, s)
+		}
+		counts[s]++
+	}
+
+	warnings := make([]string, 0, len(order))
+	for _, s := range order {
+		if n := counts[s]; n > 1 {
+			s = fmt.Sprintf("%s (and %d more similar)", s, n-1)
+		}
+		warnings = append(warnings, s)
+	}
+	return warnings
+}
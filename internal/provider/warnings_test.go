@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNestedWarnings(t *testing.T) {
+	output := `
+╷
+│ Warning: Argument is deprecated
+│ 
+│   with module.vpc.aws_eip.nat,
+╵
+╷
+│ Warning: Argument is deprecated
+╵
+╷
+│ Warning: Value for undeclared variable
+╵
+Warnings:
+
+- Experimental feature in use
+  on main.tf line 3, in terraform:
+- Experimental feature in use
+
+{"@level":"warn","@message":"Warning: Resource targeting is in effect","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Resource targeting is in effect"}}
+{"@level":"error","@message":"Error: nope","type":"diagnostic","diagnostic":{"severity":"error","summary":"nope"}}
+`
+	for _, c := range []struct {
+		desc     string
+		suppress []string
+		want     []string
+	}{{
+		desc: "all",
+		want: []string{"Argument is deprecated (and 1 more similar)", "Value for undeclared variable", "Experimental feature in use (and 1 more similar)", "Resource targeting is in effect"},
+	}, {
+		desc:     "suppressed",
+		suppress: []string{"(?i)deprecated", "^Resource targeting", "Experimental"},
+		want:     []string{"Value for undeclared variable"},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			var suppress []*regexp.Regexp
+			for _, p := range c.suppress {
+				suppress = append(suppress, regexp.MustCompile(p))
+			}
+			if diff := cmp.Diff(c.want, nestedWarnings(context.Background(), output, suppress)); diff != "" {
+				t.Errorf("nestedWarnings (-want,+got): %s", diff)
+			}
+		})
+	}
+}
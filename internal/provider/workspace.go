@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// autoWorkspace is the workspace value that picks a name automatically.
+const autoWorkspace = "auto"
+
+// workspaceName returns the nested workspace to use for the workspace
+// attribute: its value if it names one, "default" if it's not set, and for
+// "auto", the prior workspace_name if there is one or a new unique name.
+//
+// Terraform doesn't tell providers the address of the resource being
+// planned, so "auto" names can't be derived from it. A random name, kept in
+// state once created, keeps instances using one backend from colliding.
+func workspaceName(workspace, prior types.String) (string, error) {
+	switch ws := workspace.ValueString(); ws {
+	case "":
+		return "default", nil
+	case autoWorkspace:
+		if !prior.IsNull() && !prior.IsUnknown() && prior.ValueString() != "" {
+			return prior.ValueString(), nil
+		}
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return "pteraform-" + hex.EncodeToString(b), nil
+	default:
+		return ws, nil
+	}
+}
+
+// ensureWorkspace creates workspace in dir with tf if it doesn't exist yet.
+// Nested commands run in it with TF_WORKSPACE rather than workspace select,
+// which would change the workspace of every run sharing dir.
+func ensureWorkspace(ctx context.Context, tf runner, dir, workspace string) error {
+	if workspace == "" || workspace == "default" {
+		return nil
+	}
+	out, err := runStdout(ctx, tf, dir, "workspace", "list")
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*")) == workspace {
+			return nil
+		}
+	}
+	_, err = tf.run(ctx, dir, "workspace", "new", workspace)
+	return err
+}
+
+// workspaceEnv returns the TF_WORKSPACE variable that runs the terraform
+// command in workspace, or nil if workspace isn't set. It's not set for
+// init, which runs before the workspace is created, or for workspace
+// commands, which refuse to run with it set.
+func workspaceEnv(workspace, command string) []string {
+	if workspace == "" || command == "init" || command == "workspace" {
+		return nil
+	}
+	return []string{"TF_WORKSPACE=" + workspace}
+}
+
+// statePath returns the path, relative to the working directory, of the
+// local state of workspace.
+func statePath(workspace string) string {
+	if workspace == "" || workspace == "default" {
+		return "terraform.tfstate"
+	}
+	return filepath.Join("terraform.tfstate.d", workspace, "terraform.tfstate")
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestWorkspaceName(t *testing.T) {
+	for _, c := range []struct {
+		workspace, prior types.String
+		want             string
+	}{
+		{types.StringNull(), types.StringNull(), "default"},
+		{types.StringValue("staging"), types.StringNull(), "staging"},
+		{types.StringValue("staging"), types.StringValue("pteraform-0123abcd"), "staging"},
+		{types.StringValue("auto"), types.StringValue("pteraform-0123abcd"), "pteraform-0123abcd"},
+	} {
+		got, err := workspaceName(c.workspace, c.prior)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("workspaceName(%s, %s) = %q, want %q", c.workspace, c.prior, got, c.want)
+		}
+	}
+
+	a, err := workspaceName(types.StringValue("auto"), types.StringNull())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := workspaceName(types.StringValue("auto"), types.StringUnknown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pattern := regexp.MustCompile(`^pteraform-[0-9a-f]{8}$`); !pattern.MatchString(a) || !pattern.MatchString(b) {
+		t.Errorf("auto workspace names %q and %q don't match %s", a, b, pattern)
+	}
+	if a == b {
+		t.Errorf("auto workspace names collided: %q", a)
+	}
+}
+
+func TestStatePath(t *testing.T) {
+	for ws, want := range map[string]string{
+		"":        "terraform.tfstate",
+		"default": "terraform.tfstate",
+		"staging": filepath.Join("terraform.tfstate.d", "staging", "terraform.tfstate"),
+	} {
+		if got := statePath(ws); got != want {
+			t.Errorf("statePath(%q) = %q, want %q", ws, got, want)
+		}
+	}
+}
+
+func TestEnsureWorkspace(t *testing.T) {
+	for _, c := range []struct {
+		workspace, list string
+		want            []string
+	}{
+		{workspace: "default"},
+		{workspace: "staging", list: "* default\n  staging\n", want: []string{"workspace list"}},
+		{workspace: "staging", list: "* default\n  staging-2\n", want: []string{"workspace list", "workspace new staging"}},
+	} {
+		fake := &fakeRunner{outputs: map[string]string{"workspace list": c.list}}
+		if err := ensureWorkspace(context.Background(), fake, t.TempDir(), c.workspace); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(c.want, fake.commands); diff != "" {
+			t.Errorf("ensureWorkspace(%q) with workspaces %q (-want,+got): %s", c.workspace, c.list, diff)
+		}
+	}
+}
+
+func TestTerraformRunnerWorkspace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform is a shell script")
+	}
+	// The fake terraform prints the workspace it's run in.
+	bin := writeFiles(t, map[string]string{"terraform": "#!/bin/sh\necho \"$TF_WORKSPACE\"\n"})
+	if err := os.Chmod(filepath.Join(bin, "terraform"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	// Instances sharing a working directory each run in their own
+	// workspace, even when they run at the same time.
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for _, ws := range []string{"default", "staging"} {
+		ws := ws
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tf := terraformRunner{workspace: ws}
+			for i := 0; i < 5; i++ {
+				out, err := tf.run(context.Background(), dir, "plan")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got := strings.TrimSpace(out); got != ws {
+					t.Errorf("plan of %s ran in workspace %q", ws, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	out, err := terraformRunner{workspace: "staging"}.run(context.Background(), dir, "workspace", "new", "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out); got != "" {
+		t.Errorf("workspace new ran with TF_WORKSPACE=%s", got)
+	}
+}